@@ -4,11 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"net/url"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/internal/kpl"
 	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
 )
 
@@ -31,10 +33,17 @@ func ConvertToGenericEvent(ctx context.Context, eventBytes json.RawMessage) (cor
 		return event, nil // Retornar evento com dados brutos
 	}
 	
+	// Verificar se é um evento HTTP API (formato 2.0), Function URL ou ALB
+	if httpEvent, ok := decodeHTTPEvent(eventBytes, rawEvent); ok {
+		log.Printf("Evento identificado como HTTP (%v): ID=%s", httpEvent.Metadata["httpFormat"], httpEvent.ID)
+		return httpEvent, nil
+	}
+	
 	// Verificar se é um evento API Gateway
 	if _, ok := rawEvent["httpMethod"]; ok {
 		event.Type = "http.request"
 		event.Source = "api"
+		event.Metadata["httpFormat"] = HTTPFormatAPIGatewayV1
 		
 		// Extrair ID da requisição se disponível
 		if requestContext, ok := rawEvent["requestContext"].(map[string]interface{}); ok {
@@ -67,22 +76,80 @@ func ConvertToGenericEvent(ctx context.Context, eventBytes json.RawMessage) (cor
 		return event, nil
 	}
 	
-	// Verificar se é um evento SQS
+	// Verificar se é um evento do EventBridge entregue diretamente
+	if cwEvent, ok := decodeEventBridgeEnvelope(eventBytes, rawEvent); ok {
+		event = convertEventBridgeEvent(cwEvent)
+		log.Printf("Evento identificado como EventBridge: ID=%s, Type=%s", event.ID, event.Type)
+		return event, nil
+	}
+	
+	// Verificar se é um evento SQS, SNS, S3 ou Kinesis
 	if records, ok := rawEvent["Records"].([]interface{}); ok {
 		for _, record := range records {
 			if recordMap, ok := record.(map[string]interface{}); ok {
+				// Notificação S3 entregue diretamente
+				if eventSource, ok := recordMap["eventSource"].(string); ok && eventSource == "aws:s3" {
+					var s3Event events.S3Event
+					if err := json.Unmarshal(eventBytes, &s3Event); err != nil {
+						log.Printf("Erro ao decodificar evento S3: %v", err)
+						break
+					}
+					
+					event = convertS3Event(s3Event, eventBytes)
+					log.Printf("Evento identificado como S3: ID=%s, Type=%s", event.ID, event.Type)
+					return event, nil
+				}
+				
+				// Registros do Kinesis Data Streams
+				if eventSource, ok := recordMap["eventSource"].(string); ok && eventSource == "aws:kinesis" {
+					var kinesisEvent events.KinesisEvent
+					if err := json.Unmarshal(eventBytes, &kinesisEvent); err != nil {
+						log.Printf("Erro ao decodificar evento Kinesis: %v", err)
+						break
+					}
+					
+					event = convertKinesisEvent(kinesisEvent)
+					log.Printf("Evento identificado como Kinesis: ID=%s, Type=%s", event.ID, event.Type)
+					return event, nil
+				}
+				
 				if eventSource, ok := recordMap["eventSource"].(string); ok && strings.Contains(strings.ToLower(eventSource), "sqs") {
-					event.Type = "sqs.message"
-					event.Source = "queue"
+					var sqsEvent events.SQSEvent
+					if err := json.Unmarshal(eventBytes, &sqsEvent); err != nil || len(sqsEvent.Records) == 0 {
+						log.Printf("Erro ao decodificar evento SQS: %v", err)
+						break
+					}
 					
-					// Extrair ID da mensagem se disponível
-					if messageID, ok := recordMap["messageId"].(string); ok {
-						event.ID = messageID
+					// Notificações S3 e eventos do EventBridge entregues pela fila
+					if first := ConvertSQSRecord(sqsEvent.Records[0]); first.Type != sqsEventType {
+						log.Printf("Evento identificado via SQS: ID=%s, Type=%s", first.ID, first.Type)
+						return first, nil
 					}
 					
+					event = ConvertSQSEvent(sqsEvent)
 					log.Printf("Evento identificado como SQS: ID=%s", event.ID)
 					return event, nil
 				}
+				
+				// Notificação S3 encapsulada em uma mensagem SNS
+				if eventSource, ok := recordMap["EventSource"].(string); ok && eventSource == "aws:sns" {
+					if sns, ok := recordMap["Sns"].(map[string]interface{}); ok {
+						if message, ok := sns["Message"].(string); ok {
+							if s3Event, s3Bytes, envelope, ok := unwrapS3Notification(message); ok {
+								event = convertS3Event(s3Event, s3Bytes)
+								event.Metadata["envelope"] = append([]string{"sns"}, envelope...)
+								if messageID, ok := sns["MessageId"].(string); ok {
+									event.Metadata["messageId"] = messageID
+								}
+								if topicArn, ok := sns["TopicArn"].(string); ok {
+									event.Metadata["topicArn"] = topicArn
+								}
+								log.Printf("Evento identificado como S3 via SNS: ID=%s, Type=%s", event.ID, event.Type)
+								return event, nil
+							}
+						}
+					}
+				}
 			}
 		}
 	}
@@ -95,58 +162,297 @@ func ConvertToGenericEvent(ctx context.Context, eventBytes json.RawMessage) (cor
 	return event, nil
 }
 
-// Converter evento de API Gateway
-func convertAPIGatewayEvent(apiEvent events.APIGatewayProxyRequest) coreinterfaces.Event {
+// Converter evento de S3
+func convertS3Event(s3Event events.S3Event, data []byte) coreinterfaces.Event {
 	event := coreinterfaces.Event{
-		ID:        apiEvent.RequestContext.RequestID,
-		Source:    "api",
-		Type:      "http.request",
-		RequestID: apiEvent.RequestContext.RequestID,
-		Metadata: map[string]interface{}{
-			"method":     apiEvent.HTTPMethod,
-			"path":       apiEvent.Path,
-			"sourceIP":   apiEvent.RequestContext.Identity.SourceIP,
-			"userAgent":  apiEvent.RequestContext.Identity.UserAgent,
-		},
+		ID:        uuid.New().String(),
+		Source:    "storage",
+		Type:      "s3.event",
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      data,
+		Metadata:  make(map[string]interface{}),
+	}
+	
+	if len(s3Event.Records) == 0 {
+		return event
+	}
+	
+	// O primeiro registro define o evento; os demais ficam em "records"
+	first := s3Event.Records[0]
+	event.Type = s3EventType(first.EventName)
+	if !first.EventTime.IsZero() {
+		event.Timestamp = first.EventTime.UTC().Format(time.RFC3339)
+	}
+	if requestID, ok := first.ResponseElements["x-amz-request-id"]; ok {
+		event.ID = requestID
+		event.RequestID = requestID
+	}
+	
+	for k, v := range s3RecordMetadata(first) {
+		event.Metadata[k] = v
+	}
+	
+	if len(s3Event.Records) > 1 {
+		records := make([]map[string]interface{}, len(s3Event.Records))
+		for i, record := range s3Event.Records {
+			records[i] = s3RecordMetadata(record)
+		}
+		event.Metadata["records"] = records
 	}
+	
+	return event
+}
 
-	// Converter corpo para bytes
-	event.Data = []byte(apiEvent.Body)
+// s3RecordMetadata extrai os campos relevantes de um registro S3
+func s3RecordMetadata(record events.S3EventRecord) map[string]interface{} {
+	// URLDecodedKey é preenchido pelo decodificador; eventos reserializados
+	// após a decodificação ainda trazem a chave codificada em Key
+	key := record.S3.Object.URLDecodedKey
+	if key == "" {
+		if decoded, err := url.QueryUnescape(record.S3.Object.Key); err == nil {
+			key = decoded
+		} else {
+			key = record.S3.Object.Key
+		}
+	}
+	
+	return map[string]interface{}{
+		"eventName": record.EventName,
+		"bucket":    record.S3.Bucket.Name,
+		"key":       key,
+		"size":      record.S3.Object.Size,
+		"eTag":      record.S3.Object.ETag,
+		"versionId": record.S3.Object.VersionID,
+		"sequencer": record.S3.Object.Sequencer,
+		"region":    record.AWSRegion,
+	}
+}
 
-	return event
+// s3EventType mapeia o nome do evento S3 para o tipo genérico
+func s3EventType(eventName string) string {
+	category, _, _ := strings.Cut(eventName, ":")
+	switch category {
+	case "ObjectCreated":
+		return "s3.object.created"
+	case "ObjectRemoved":
+		return "s3.object.removed"
+	case "ObjectRestore":
+		return "s3.object.restore"
+	default:
+		return "s3.event"
+	}
 }
 
-// Converter evento de SQS
-func convertSQSEvent(sqsEvent events.SQSEvent) coreinterfaces.Event {
-	event := coreinterfaces.Event{
-		Source: "sqs",
-		Type:   "message.received",
+// unwrapS3Notification tenta extrair uma notificação S3 do corpo de uma
+// mensagem, inclusive quando ela chega dentro de uma notificação SNS
+// entregue a uma fila SQS. Retorna também o JSON da notificação e os
+// envelopes intermediários encontrados.
+func unwrapS3Notification(body string) (events.S3Event, []byte, []string, bool) {
+	var probe struct {
+		Records []struct {
+			EventSource string `json:"eventSource"`
+		} `json:"Records"`
+		Type    string `json:"Type"`
+		Message string `json:"Message"`
+	}
+	if err := json.Unmarshal([]byte(body), &probe); err != nil {
+		return events.S3Event{}, nil, nil, false
+	}
+	
+	if len(probe.Records) > 0 && probe.Records[0].EventSource == "aws:s3" {
+		var s3Event events.S3Event
+		if err := json.Unmarshal([]byte(body), &s3Event); err != nil {
+			return events.S3Event{}, nil, nil, false
+		}
+		return s3Event, []byte(body), nil, true
+	}
+	
+	// Notificação SNS sem entrega bruta (raw delivery)
+	if probe.Type == "Notification" && probe.Message != "" {
+		if s3Event, data, envelope, ok := unwrapS3Notification(probe.Message); ok {
+			return s3Event, data, append([]string{"sns"}, envelope...), true
+		}
+	}
+	
+	return events.S3Event{}, nil, nil, false
+}
+
+// Converter evento do Kinesis Data Streams. Os dados chegam em base64 e são
+// decodificados; registros agregados pela KPL são desagregados. O primeiro
+// registro define o evento e, havendo mais de um, todos ficam em "records".
+func convertKinesisEvent(kinesisEvent events.KinesisEvent) coreinterfaces.Event {
+	recordEvents := convertKinesisRecords(kinesisEvent)
+	if len(recordEvents) == 0 {
+		return coreinterfaces.Event{
+			ID:        uuid.New().String(),
+			Source:    "stream",
+			Type:      "kinesis.record",
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Metadata:  make(map[string]interface{}),
+		}
 	}
+	
+	event := recordEvents[0]
+	if len(recordEvents) > 1 {
+		records := make([]map[string]interface{}, len(recordEvents))
+		for i, recordEvent := range recordEvents {
+			records[i] = make(map[string]interface{}, len(recordEvent.Metadata))
+			for k, v := range recordEvent.Metadata {
+				if k != "shardId" {
+					records[i][k] = v
+				}
+			}
+			records[i]["data"] = recordEvent.Data
+		}
+		event.Metadata["records"] = records
+	}
+	
+	return event
+}
 
-	if len(sqsEvent.Records) > 0 {
-		event.ID = sqsEvent.Records[0].MessageId
-		event.Data = []byte(sqsEvent.Records[0].Body)
-		event.Metadata = map[string]interface{}{
-			"queueUrl": sqsEvent.Records[0].EventSourceARN,
+// convertKinesisRecords converte cada registro de usuário do lote, já
+// desagregado, em um evento
+func convertKinesisRecords(kinesisEvent events.KinesisEvent) []coreinterfaces.Event {
+	var recordEvents []coreinterfaces.Event
+	for _, record := range kinesisEvent.Records {
+		userRecords, err := kpl.Deaggregate(record.Kinesis.PartitionKey, record.Kinesis.Data)
+		if err != nil {
+			log.Printf("Erro ao desagregar registro Kinesis %s: %v", record.EventID, err)
+			userRecords = []kpl.Record{{PartitionKey: record.Kinesis.PartitionKey, Data: record.Kinesis.Data}}
+		}
+		aggregated := kpl.IsAggregated(record.Kinesis.Data)
+		
+		for _, userRecord := range userRecords {
+			event := coreinterfaces.Event{
+				ID:        record.EventID,
+				Source:    "stream",
+				Type:      "kinesis.record",
+				Timestamp: time.Now().UTC().Format(time.RFC3339),
+				Data:      userRecord.Data,
+				Metadata: map[string]interface{}{
+					"eventId":        record.EventID,
+					"partitionKey":   userRecord.PartitionKey,
+					"sequenceNumber": record.Kinesis.SequenceNumber,
+					"streamArn":      record.EventSourceArn,
+					"region":         record.AwsRegion,
+				},
+			}
+			if event.ID == "" {
+				event.ID = uuid.New().String()
+			}
+			if aggregated {
+				event.Metadata["subSequenceNumber"] = userRecord.SubSequenceNumber
+			}
+			if !record.Kinesis.ApproximateArrivalTimestamp.IsZero() {
+				arrival := record.Kinesis.ApproximateArrivalTimestamp.UTC().Format(time.RFC3339)
+				event.Timestamp = arrival
+				event.Metadata["approximateArrivalTimestamp"] = arrival
+			}
+			// O eventID tem o formato "shardId-000000000000:numeroDeSequencia"
+			if shardID, _, ok := strings.Cut(record.EventID, ":"); ok {
+				event.Metadata["shardId"] = shardID
+			}
+			recordEvents = append(recordEvents, event)
 		}
 	}
+	return recordEvents
+}
 
+// Converter evento do EventBridge. Type recebe o detail-type e Data recebe o
+// detail, para que o detalhe possa ser decodificado pelo registro de tipos
+func convertEventBridgeEvent(cwEvent events.CloudWatchEvent) coreinterfaces.Event {
+	event := coreinterfaces.Event{
+		ID:        cwEvent.ID,
+		Source:    "events",
+		Type:      cwEvent.DetailType,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      []byte(cwEvent.Detail),
+		Metadata: map[string]interface{}{
+			"source":     cwEvent.Source,
+			"detailType": cwEvent.DetailType,
+			"account":    cwEvent.AccountID,
+			"region":     cwEvent.Region,
+			"resources":  cwEvent.Resources,
+			"version":    cwEvent.Version,
+		},
+	}
+	
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if !cwEvent.Time.IsZero() {
+		event.Timestamp = cwEvent.Time.UTC().Format(time.RFC3339)
+	}
+	
+	// Tipo e versão do schema vão em messageAttributes, como nas mensagens SQS
+	if detail, attributes, ok := splitEventBridgeSchema(cwEvent.Detail); ok {
+		event.Data = detail
+		event.Metadata["messageAttributes"] = attributes
+	}
+	
 	return event
 }
 
-// ConvertToAWSResponse converte uma resposta genérica para o formato AWS
-func ConvertToAWSResponse(response *coreinterfaces.Response) interface{} {
-	// Converter para resposta API Gateway
-	apiResponse := events.APIGatewayProxyResponse{
-		StatusCode: response.StatusCode,
-		Headers:    make(map[string]string),
-		Body:       string(response.Body),
+// EventBridgeSchemaKey é a chave reservada do detail que leva os atributos de
+// tipo e versão do schema, já que o EventBridge não tem atributos de mensagem
+const EventBridgeSchemaKey = "_schema"
+
+// splitEventBridgeSchema separa a chave EventBridgeSchemaKey do detail,
+// retornando o detail sem ela e os atributos que continha
+func splitEventBridgeSchema(detail json.RawMessage) (json.RawMessage, map[string]string, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(detail, &fields); err != nil {
+		return nil, nil, false
+	}
+	raw, ok := fields[EventBridgeSchemaKey]
+	if !ok {
+		return nil, nil, false
+	}
+	var attributes map[string]string
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return nil, nil, false
 	}
 	
-	// Copiar headers
-	for k, v := range response.Headers {
-		apiResponse.Headers[k] = v
+	delete(fields, EventBridgeSchemaKey)
+	stripped, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, false
+	}
+	return stripped, attributes, true
+}
+
+// decodeEventBridgeEnvelope reconhece o envelope do EventBridge pelos campos
+// obrigatórios detail-type, source e detail
+func decodeEventBridgeEnvelope(eventBytes []byte, rawEvent map[string]interface{}) (events.CloudWatchEvent, bool) {
+	_, hasDetailType := rawEvent["detail-type"].(string)
+	_, hasSource := rawEvent["source"].(string)
+	_, hasDetail := rawEvent["detail"]
+	if !hasDetailType || !hasSource || !hasDetail {
+		return events.CloudWatchEvent{}, false
 	}
 	
+	var cwEvent events.CloudWatchEvent
+	if err := json.Unmarshal(eventBytes, &cwEvent); err != nil {
+		log.Printf("Erro ao decodificar evento EventBridge: %v", err)
+		return events.CloudWatchEvent{}, false
+	}
+	return cwEvent, true
+}
+
+// unwrapEventBridgeEnvelope tenta extrair um evento do EventBridge do corpo de uma mensagem
+func unwrapEventBridgeEnvelope(body string) (events.CloudWatchEvent, bool) {
+	var rawEvent map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &rawEvent); err != nil {
+		return events.CloudWatchEvent{}, false
+	}
+	return decodeEventBridgeEnvelope([]byte(body), rawEvent)
+}
+
+// ConvertToAWSResponse converte uma resposta genérica para o formato do API
+// Gateway REST. Para responder no formato da origem do evento, use
+// ConvertToHTTPResponse.
+func ConvertToAWSResponse(response *coreinterfaces.Response) interface{} {
+	// Respostas genéricas não falham na conversão
+	apiResponse, _ := ConvertToAPIGatewayResponse(response)
 	return apiResponse
 }
\ No newline at end of file
@@ -0,0 +1,140 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestConvertToGenericEventSQSMessageAttributes(t *testing.T) {
+	raw := json.RawMessage(`{"Records":[{
+		"messageId":"m-1",
+		"receiptHandle":"rh-1",
+		"body":"{\"n\":1}",
+		"attributes":{"ApproximateReceiveCount":"2","SentTimestamp":"1700000000000"},
+		"messageAttributes":{
+			"replyTo":{"stringValue":"https://sqs.us-east-1.amazonaws.com/111111111111/replies","dataType":"String"},
+			"correlationId":{"stringValue":"c-1","dataType":"String"},
+			"blob":{"binaryValue":"AQI=","dataType":"Binary"}
+		},
+		"eventSource":"aws:sqs",
+		"eventSourceARN":"arn:aws:sqs:us-east-1:111111111111:requests",
+		"awsRegion":"us-east-1"
+	}]}`)
+
+	event, err := ConvertToGenericEvent(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "sqs.message" || event.ID != "m-1" {
+		t.Fatalf("evento SQS não identificado: Type=%s, ID=%s", event.Type, event.ID)
+	}
+
+	want := map[string]string{
+		"replyTo":       "https://sqs.us-east-1.amazonaws.com/111111111111/replies",
+		"correlationId": "c-1",
+	}
+	if got := event.Metadata["messageAttributes"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messageAttributes: obtido %#v", got)
+	}
+	if got := event.Metadata["queueArn"]; got != "arn:aws:sqs:us-east-1:111111111111:requests" {
+		t.Fatalf("queueArn: obtido %v", got)
+	}
+	if got := event.Metadata["approximateReceiveCount"]; got != 2 {
+		t.Fatalf("approximateReceiveCount: obtido %v", got)
+	}
+}
+
+// s3TestNotification é uma notificação S3 com dois registros e chave codificada
+const s3TestNotification = `{"Records":[
+	{
+		"eventSource":"aws:s3",
+		"eventName":"ObjectCreated:Put",
+		"eventTime":"2024-05-01T10:00:00.000Z",
+		"awsRegion":"sa-east-1",
+		"responseElements":{"x-amz-request-id":"req-1"},
+		"s3":{
+			"bucket":{"name":"relatorios"},
+			"object":{"key":"vendas/maio+2024%C3%A7.csv","size":1024,"eTag":"abc","versionId":"v1","sequencer":"0055"}
+		}
+	},
+	{
+		"eventSource":"aws:s3",
+		"eventName":"ObjectRemoved:Delete",
+		"awsRegion":"sa-east-1",
+		"s3":{"bucket":{"name":"relatorios"},"object":{"key":"antigo.csv","sequencer":"0056"}}
+	}
+]}`
+
+func TestConvertToGenericEventS3Notification(t *testing.T) {
+	event, err := ConvertToGenericEvent(context.Background(), json.RawMessage(s3TestNotification))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Source != "storage" || event.Type != "s3.object.created" || event.ID != "req-1" || event.RequestID != "req-1" {
+		t.Fatalf("evento S3: Source=%s, Type=%s, ID=%s, RequestID=%s", event.Source, event.Type, event.ID, event.RequestID)
+	}
+	if event.Timestamp != "2024-05-01T10:00:00Z" {
+		t.Fatalf("Timestamp: obtido %s", event.Timestamp)
+	}
+
+	want := map[string]interface{}{
+		"eventName": "ObjectCreated:Put",
+		"bucket":    "relatorios",
+		"key":       "vendas/maio 2024ç.csv",
+		"size":      int64(1024),
+		"eTag":      "abc",
+		"versionId": "v1",
+		"sequencer": "0055",
+		"region":    "sa-east-1",
+	}
+	for k, v := range want {
+		if got := event.Metadata[k]; !reflect.DeepEqual(got, v) {
+			t.Fatalf("Metadata[%s]: obtido %#v, esperado %#v", k, got, v)
+		}
+	}
+
+	records, ok := event.Metadata["records"].([]map[string]interface{})
+	if !ok || len(records) != 2 || records[1]["eventName"] != "ObjectRemoved:Delete" || records[1]["key"] != "antigo.csv" {
+		t.Fatalf("records: obtido %#v", event.Metadata["records"])
+	}
+}
+
+func TestS3EventType(t *testing.T) {
+	cases := map[string]string{
+		"ObjectCreated:CompleteMultipartUpload": "s3.object.created",
+		"ObjectRemoved:DeleteMarkerCreated":     "s3.object.removed",
+		"ObjectRestore:Completed":               "s3.object.restore",
+		"Replication:OperationFailed":           "s3.event",
+	}
+	for eventName, want := range cases {
+		if got := s3EventType(eventName); got != want {
+			t.Fatalf("s3EventType(%s): obtido %s, esperado %s", eventName, got, want)
+		}
+	}
+}
+
+func TestConvertToGenericEventS3ViaSNSAndSQS(t *testing.T) {
+	snsBody, _ := json.Marshal(map[string]string{"Type": "Notification", "Message": s3TestNotification})
+	sqsEvent, _ := json.Marshal(map[string]interface{}{"Records": []map[string]string{{
+		"messageId":      "m-1",
+		"body":           string(snsBody),
+		"eventSource":    "aws:sqs",
+		"eventSourceARN": "arn:aws:sqs:sa-east-1:111111111111:arquivos",
+	}}})
+
+	event, err := ConvertToGenericEvent(context.Background(), sqsEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "s3.object.created" || event.Metadata["key"] != "vendas/maio 2024ç.csv" {
+		t.Fatalf("S3 via SNS e SQS: Type=%s, key=%v", event.Type, event.Metadata["key"])
+	}
+	if got := event.Metadata["envelope"]; !reflect.DeepEqual(got, []string{"sqs", "sns"}) {
+		t.Fatalf("envelope: obtido %v", got)
+	}
+	if event.Metadata["messageId"] != "m-1" || event.Metadata["queueArn"] != "arn:aws:sqs:sa-east-1:111111111111:arquivos" {
+		t.Fatalf("metadados da fila: %v", event.Metadata)
+	}
+}
@@ -0,0 +1,353 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Formatos de eventos HTTP, registrados em Metadata["httpFormat"] e usados
+// para montar a resposta no formato esperado pela origem
+const (
+	HTTPFormatAPIGatewayV1 = "apigateway.v1"
+	HTTPFormatAPIGatewayV2 = "apigateway.v2"
+	HTTPFormatFunctionURL  = "lambda.url"
+	HTTPFormatALB          = "alb"
+)
+
+// HeaderValueSeparator separa múltiplos valores de um cabeçalho em
+// Response.Headers, por exemplo vários Set-Cookie. Cada valor vira um cookie
+// no formato 2.0 e um item de multiValueHeaders no formato 1.0 e no ALB; no
+// ALB sem cabeçalhos multivalorados os valores são unidos por vírgula, exceto
+// Set-Cookie, do qual só o primeiro é enviado.
+const HeaderValueSeparator = "\n"
+
+// ConvertAPIGatewayV2Event converte uma requisição do API Gateway HTTP API
+// (formato 2.0) em evento genérico
+func ConvertAPIGatewayV2Event(request events.APIGatewayV2HTTPRequest) coreinterfaces.Event {
+	requestContext := request.RequestContext
+	event := newHTTPEvent(requestContext.RequestID, requestContext.TimeEpoch, request.Body, request.IsBase64Encoded)
+	event.Metadata["httpFormat"] = HTTPFormatAPIGatewayV2
+	event.Metadata["httpMethod"] = requestContext.HTTP.Method
+	event.Metadata["path"] = request.RawPath
+	event.Metadata["routeKey"] = request.RouteKey
+	event.Metadata["rawQueryString"] = request.RawQueryString
+	event.Metadata["headers"] = request.Headers
+	event.Metadata["cookies"] = request.Cookies
+	event.Metadata["queryStringParameters"] = request.QueryStringParameters
+	event.Metadata["pathParameters"] = request.PathParameters
+	event.Metadata["stageVariables"] = request.StageVariables
+	event.Metadata["stage"] = requestContext.Stage
+	event.Metadata["apiId"] = requestContext.APIID
+	event.Metadata["domainName"] = requestContext.DomainName
+	event.Metadata["accountId"] = requestContext.AccountID
+	event.Metadata["sourceIP"] = requestContext.HTTP.SourceIP
+	event.Metadata["userAgent"] = requestContext.HTTP.UserAgent
+	if requestContext.Authorizer != nil {
+		event.Metadata["authorizer"] = requestContext.Authorizer
+	}
+	return event
+}
+
+// ConvertFunctionURLEvent converte uma requisição de uma Lambda Function URL
+// em evento genérico
+func ConvertFunctionURLEvent(request events.LambdaFunctionURLRequest) coreinterfaces.Event {
+	requestContext := request.RequestContext
+	event := newHTTPEvent(requestContext.RequestID, requestContext.TimeEpoch, request.Body, request.IsBase64Encoded)
+	event.Metadata["httpFormat"] = HTTPFormatFunctionURL
+	event.Metadata["httpMethod"] = requestContext.HTTP.Method
+	event.Metadata["path"] = request.RawPath
+	event.Metadata["rawQueryString"] = request.RawQueryString
+	event.Metadata["headers"] = request.Headers
+	event.Metadata["cookies"] = request.Cookies
+	event.Metadata["queryStringParameters"] = request.QueryStringParameters
+	event.Metadata["domainName"] = requestContext.DomainName
+	event.Metadata["accountId"] = requestContext.AccountID
+	event.Metadata["sourceIP"] = requestContext.HTTP.SourceIP
+	event.Metadata["userAgent"] = requestContext.HTTP.UserAgent
+	if requestContext.Authorizer != nil {
+		event.Metadata["authorizer"] = requestContext.Authorizer
+	}
+	return event
+}
+
+// ConvertALBEvent converte uma requisição de um target group do ALB em
+// evento genérico. Com cabeçalhos multivalorados habilitados no target group,
+// Metadata["multiValueHeadersEnabled"] é true e a resposta usa multiValueHeaders.
+func ConvertALBEvent(request events.ALBTargetGroupRequest) coreinterfaces.Event {
+	multiValue := len(request.MultiValueHeaders) > 0 || len(request.MultiValueQueryStringParameters) > 0
+
+	// O ALB não informa um ID de requisição; o trace ID é o mais próximo
+	traceID := request.Headers["x-amzn-trace-id"]
+	if values := request.MultiValueHeaders["x-amzn-trace-id"]; traceID == "" && len(values) > 0 {
+		traceID = values[0]
+	}
+
+	event := newHTTPEvent(traceID, 0, request.Body, request.IsBase64Encoded)
+	event.Metadata["httpFormat"] = HTTPFormatALB
+	event.Metadata["httpMethod"] = request.HTTPMethod
+	event.Metadata["path"] = request.Path
+	event.Metadata["headers"] = request.Headers
+	event.Metadata["multiValueHeaders"] = request.MultiValueHeaders
+	event.Metadata["queryStringParameters"] = request.QueryStringParameters
+	event.Metadata["multiValueQueryStringParameters"] = request.MultiValueQueryStringParameters
+	event.Metadata["multiValueHeadersEnabled"] = multiValue
+	event.Metadata["targetGroupArn"] = request.RequestContext.ELB.TargetGroupArn
+	return event
+}
+
+// newHTTPEvent cria o evento de uma requisição HTTP com os campos comuns
+func newHTTPEvent(requestID string, timeEpoch int64, body string, isBase64Encoded bool) coreinterfaces.Event {
+	event := coreinterfaces.Event{
+		ID:        requestID,
+		Source:    "api",
+		Type:      "http.request",
+		RequestID: requestID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      decodeBody(body, isBase64Encoded),
+		Metadata: map[string]interface{}{
+			"body":            body,
+			"isBase64Encoded": isBase64Encoded,
+		},
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if timeEpoch > 0 {
+		event.Timestamp = time.UnixMilli(timeEpoch).UTC().Format(time.RFC3339)
+	}
+	return event
+}
+
+// decodeHTTPEvent reconhece requisições no formato 2.0 (HTTP API e Function
+// URL), pelo campo requestContext.http, e do ALB, pelo campo requestContext.elb
+func decodeHTTPEvent(eventBytes []byte, rawEvent map[string]interface{}) (coreinterfaces.Event, bool) {
+	requestContext, ok := rawEvent["requestContext"].(map[string]interface{})
+	if !ok {
+		return coreinterfaces.Event{}, false
+	}
+
+	if _, ok := requestContext["elb"]; ok {
+		var request events.ALBTargetGroupRequest
+		if err := json.Unmarshal(eventBytes, &request); err != nil {
+			log.Printf("Erro ao decodificar evento ALB: %v", err)
+			return coreinterfaces.Event{}, false
+		}
+		return ConvertALBEvent(request), true
+	}
+
+	if _, ok := requestContext["http"]; !ok {
+		return coreinterfaces.Event{}, false
+	}
+
+	// Function URLs usam o formato 2.0 com um domínio próprio
+	if domainName, _ := requestContext["domainName"].(string); strings.Contains(domainName, ".lambda-url.") {
+		var request events.LambdaFunctionURLRequest
+		if err := json.Unmarshal(eventBytes, &request); err != nil {
+			log.Printf("Erro ao decodificar evento da Function URL: %v", err)
+			return coreinterfaces.Event{}, false
+		}
+		return ConvertFunctionURLEvent(request), true
+	}
+
+	var request events.APIGatewayV2HTTPRequest
+	if err := json.Unmarshal(eventBytes, &request); err != nil {
+		log.Printf("Erro ao decodificar evento HTTP API: %v", err)
+		return coreinterfaces.Event{}, false
+	}
+	return ConvertAPIGatewayV2Event(request), true
+}
+
+// ConvertToHTTPResponse converte o retorno de um handler genérico na
+// resposta esperada pela origem do evento, conforme Metadata["httpFormat"].
+// Eventos sem o formato recebem a resposta do API Gateway REST.
+func ConvertToHTTPResponse(event coreinterfaces.Event, response interface{}) (interface{}, error) {
+	format, _ := event.Metadata["httpFormat"].(string)
+	switch format {
+	case HTTPFormatAPIGatewayV2:
+		return ConvertToAPIGatewayV2Response(response)
+	case HTTPFormatFunctionURL:
+		return ConvertToFunctionURLResponse(response)
+	case HTTPFormatALB:
+		multiValue, _ := event.Metadata["multiValueHeadersEnabled"].(bool)
+		return ConvertToALBResponse(response, multiValue)
+	default:
+		return ConvertToAPIGatewayResponse(response)
+	}
+}
+
+// ConvertToAPIGatewayV2Response converte o retorno de um handler genérico em
+// resposta do API Gateway HTTP API. Os valores de Set-Cookie vão para cookies.
+func ConvertToAPIGatewayV2Response(response interface{}) (events.APIGatewayV2HTTPResponse, error) {
+	switch r := response.(type) {
+	case events.APIGatewayV2HTTPResponse:
+		return r, nil
+	case *events.APIGatewayV2HTTPResponse:
+		if r != nil {
+			return *r, nil
+		}
+	}
+
+	normalized, err := normalizeResponse(response)
+	if err != nil {
+		return events.APIGatewayV2HTTPResponse{}, err
+	}
+	headers, cookies := splitCookies(normalized.headers)
+	body, isBase64Encoded := encodeBody(normalized.body)
+	return events.APIGatewayV2HTTPResponse{
+		StatusCode:      normalized.statusCode,
+		Headers:         headers,
+		Body:            body,
+		IsBase64Encoded: isBase64Encoded,
+		Cookies:         cookies,
+	}, nil
+}
+
+// ConvertToFunctionURLResponse converte o retorno de um handler genérico em
+// resposta de uma Function URL. Os valores de Set-Cookie vão para cookies.
+func ConvertToFunctionURLResponse(response interface{}) (events.LambdaFunctionURLResponse, error) {
+	switch r := response.(type) {
+	case events.LambdaFunctionURLResponse:
+		return r, nil
+	case *events.LambdaFunctionURLResponse:
+		if r != nil {
+			return *r, nil
+		}
+	}
+
+	normalized, err := normalizeResponse(response)
+	if err != nil {
+		return events.LambdaFunctionURLResponse{}, err
+	}
+	headers, cookies := splitCookies(normalized.headers)
+	body, isBase64Encoded := encodeBody(normalized.body)
+	return events.LambdaFunctionURLResponse{
+		StatusCode:      normalized.statusCode,
+		Headers:         headers,
+		Body:            body,
+		IsBase64Encoded: isBase64Encoded,
+		Cookies:         cookies,
+	}, nil
+}
+
+// ConvertToALBResponse converte o retorno de um handler genérico em resposta
+// do ALB, com statusDescription. Com multiValue, todos os cabeçalhos vão em
+// multiValueHeaders, como o ALB exige quando a opção está habilitada.
+func ConvertToALBResponse(response interface{}, multiValue bool) (events.ALBTargetGroupResponse, error) {
+	switch r := response.(type) {
+	case events.ALBTargetGroupResponse:
+		return r, nil
+	case *events.ALBTargetGroupResponse:
+		if r != nil {
+			return *r, nil
+		}
+	}
+
+	normalized, err := normalizeResponse(response)
+	if err != nil {
+		return events.ALBTargetGroupResponse{}, err
+	}
+	body, isBase64Encoded := encodeBody(normalized.body)
+	albResponse := events.ALBTargetGroupResponse{
+		StatusCode:        normalized.statusCode,
+		StatusDescription: fmt.Sprintf("%d %s", normalized.statusCode, http.StatusText(normalized.statusCode)),
+		Body:              body,
+		IsBase64Encoded:   isBase64Encoded,
+	}
+
+	if multiValue {
+		albResponse.MultiValueHeaders = make(map[string][]string, len(normalized.headers))
+		for k, v := range normalized.headers {
+			albResponse.MultiValueHeaders[k] = strings.Split(v, HeaderValueSeparator)
+		}
+	} else {
+		albResponse.Headers = make(map[string]string, len(normalized.headers))
+		for k, v := range normalized.headers {
+			if strings.EqualFold(k, "Set-Cookie") && strings.Contains(v, HeaderValueSeparator) {
+				// Cookies não podem ser unidos por vírgula; só o primeiro é enviado
+				log.Printf("ALB sem cabeçalhos multivalorados: apenas o primeiro Set-Cookie é enviado")
+				v, _, _ = strings.Cut(v, HeaderValueSeparator)
+			}
+			albResponse.Headers[k] = strings.ReplaceAll(v, HeaderValueSeparator, ", ")
+		}
+	}
+	return albResponse, nil
+}
+
+// httpResponse é a resposta normalizada, independente do formato da origem
+type httpResponse struct {
+	statusCode int
+	headers    map[string]string
+	body       []byte
+}
+
+// normalizeResponse converte o retorno de um handler genérico em resposta
+// HTTP. Aceita *Response, Response, []byte e string; outros valores são
+// serializados em JSON e nil resulta em 204. O status padrão é 200.
+func normalizeResponse(response interface{}) (httpResponse, error) {
+	var normalized httpResponse
+	switch r := response.(type) {
+	case *coreinterfaces.Response:
+		if r == nil {
+			return httpResponse{statusCode: http.StatusNoContent, headers: map[string]string{}}, nil
+		}
+		normalized = httpResponse{statusCode: r.StatusCode, headers: r.Headers, body: r.Body}
+	case coreinterfaces.Response:
+		normalized = httpResponse{statusCode: r.StatusCode, headers: r.Headers, body: r.Body}
+	case nil:
+		return httpResponse{statusCode: http.StatusNoContent, headers: map[string]string{}}, nil
+	case []byte:
+		normalized = httpResponse{body: r}
+	case string:
+		normalized = httpResponse{headers: map[string]string{"Content-Type": "text/plain; charset=utf-8"}, body: []byte(r)}
+	default:
+		body, err := json.Marshal(r)
+		if err != nil {
+			return httpResponse{}, fmt.Errorf("erro ao serializar resposta: %w", err)
+		}
+		normalized = httpResponse{headers: map[string]string{"Content-Type": "application/json"}, body: body}
+	}
+
+	if normalized.statusCode == 0 {
+		normalized.statusCode = http.StatusOK
+	}
+	headers := make(map[string]string, len(normalized.headers))
+	for k, v := range normalized.headers {
+		headers[k] = v
+	}
+	normalized.headers = headers
+	return normalized, nil
+}
+
+// splitCookies separa os valores de Set-Cookie dos demais cabeçalhos, que
+// têm os múltiplos valores unidos por vírgula
+func splitCookies(headers map[string]string) (map[string]string, []string) {
+	var cookies []string
+	single := make(map[string]string, len(headers))
+	for k, v := range headers {
+		if strings.EqualFold(k, "Set-Cookie") {
+			cookies = append(cookies, strings.Split(v, HeaderValueSeparator)...)
+			continue
+		}
+		single[k] = strings.ReplaceAll(v, HeaderValueSeparator, ", ")
+	}
+	return single, cookies
+}
+
+// encodeBody retorna o corpo como texto ou, se não for UTF-8 válido, em base64
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
@@ -0,0 +1,198 @@
+package adapter
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+func TestConvertToGenericEventDetectsHTTPFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		format string
+		method string
+	}{
+		{
+			name:   "HTTP API",
+			raw:    `{"version":"2.0","rawPath":"/pedidos","requestContext":{"requestId":"r-1","domainName":"abc.execute-api.us-east-1.amazonaws.com","http":{"method":"POST"}}}`,
+			format: HTTPFormatAPIGatewayV2,
+			method: "POST",
+		},
+		{
+			name:   "Function URL",
+			raw:    `{"version":"2.0","rawPath":"/","requestContext":{"requestId":"r-1","domainName":"abc.lambda-url.us-east-1.on.aws","http":{"method":"GET"}}}`,
+			format: HTTPFormatFunctionURL,
+			method: "GET",
+		},
+		{
+			name:   "ALB",
+			raw:    `{"httpMethod":"DELETE","path":"/","headers":{"x-amzn-trace-id":"r-1"},"requestContext":{"elb":{"targetGroupArn":"arn:aws:elasticloadbalancing:us-east-1:111111111111:targetgroup/tg/1"}}}`,
+			format: HTTPFormatALB,
+			method: "DELETE",
+		},
+		{
+			name:   "REST",
+			raw:    `{"httpMethod":"PUT","path":"/","requestContext":{"requestId":"r-1"}}`,
+			format: HTTPFormatAPIGatewayV1,
+		},
+	}
+
+	for _, tt := range tests {
+		event, err := ConvertToGenericEvent(context.Background(), json.RawMessage(tt.raw))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if event.Type != "http.request" || event.ID != "r-1" || event.Metadata["httpFormat"] != tt.format {
+			t.Fatalf("%s: Type=%s, ID=%s, httpFormat=%v", tt.name, event.Type, event.ID, event.Metadata["httpFormat"])
+		}
+		if tt.method != "" && event.Metadata["httpMethod"] != tt.method {
+			t.Fatalf("%s: httpMethod=%v", tt.name, event.Metadata["httpMethod"])
+		}
+	}
+}
+
+func TestConvertAPIGatewayV2Event(t *testing.T) {
+	request := events.APIGatewayV2HTTPRequest{
+		RouteKey:        "POST /pedidos",
+		RawPath:         "/pedidos",
+		RawQueryString:  "a=1",
+		Cookies:         []string{"sessao=1"},
+		Body:            base64.StdEncoding.EncodeToString([]byte{0xff, 0x00}),
+		IsBase64Encoded: true,
+	}
+	request.RequestContext.RequestID = "r-2"
+	request.RequestContext.TimeEpoch = 1700000000000
+	request.RequestContext.HTTP.Method = "POST"
+	request.RequestContext.HTTP.SourceIP = "10.0.0.1"
+
+	event := ConvertAPIGatewayV2Event(request)
+	if event.ID != "r-2" || event.RequestID != "r-2" || event.Timestamp != "2023-11-14T22:13:20Z" {
+		t.Fatalf("evento: ID=%s, RequestID=%s, Timestamp=%s", event.ID, event.RequestID, event.Timestamp)
+	}
+	if !reflect.DeepEqual(event.Data, []byte{0xff, 0x00}) {
+		t.Fatalf("corpo em base64 não decodificado: %v", event.Data)
+	}
+	if event.Metadata["routeKey"] != "POST /pedidos" || event.Metadata["sourceIP"] != "10.0.0.1" || !reflect.DeepEqual(event.Metadata["cookies"], []string{"sessao=1"}) {
+		t.Fatalf("metadados: obtido %v", event.Metadata)
+	}
+}
+
+func TestConvertALBEventHeaderMode(t *testing.T) {
+	single := ConvertALBEvent(events.ALBTargetGroupRequest{Headers: map[string]string{"x-amzn-trace-id": "Root=1"}})
+	if single.ID != "Root=1" || single.Metadata["multiValueHeadersEnabled"] != false {
+		t.Fatalf("requisição sem cabeçalhos multivalorados: ID=%s, %v", single.ID, single.Metadata["multiValueHeadersEnabled"])
+	}
+
+	multi := ConvertALBEvent(events.ALBTargetGroupRequest{MultiValueHeaders: map[string][]string{"x-amzn-trace-id": {"Root=2"}}})
+	if multi.ID != "Root=2" || multi.Metadata["multiValueHeadersEnabled"] != true {
+		t.Fatalf("requisição com cabeçalhos multivalorados: ID=%s, %v", multi.ID, multi.Metadata["multiValueHeadersEnabled"])
+	}
+
+	if anonymous := ConvertALBEvent(events.ALBTargetGroupRequest{}); anonymous.ID == "" {
+		t.Fatal("requisição sem trace ID deveria receber um ID gerado")
+	}
+}
+
+func TestConvertToHTTPResponseFollowsFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		want   interface{}
+	}{
+		{HTTPFormatAPIGatewayV2, events.APIGatewayV2HTTPResponse{}},
+		{HTTPFormatFunctionURL, events.LambdaFunctionURLResponse{}},
+		{HTTPFormatALB, events.ALBTargetGroupResponse{}},
+		{HTTPFormatAPIGatewayV1, events.APIGatewayProxyResponse{}},
+		{"", events.APIGatewayProxyResponse{}},
+	}
+
+	for _, tt := range tests {
+		event := coreinterfaces.Event{Metadata: map[string]interface{}{"httpFormat": tt.format}}
+		response, err := ConvertToHTTPResponse(event, "ok")
+		if err != nil {
+			t.Fatalf("%q: %v", tt.format, err)
+		}
+		if reflect.TypeOf(response) != reflect.TypeOf(tt.want) {
+			t.Fatalf("%q: resposta do tipo %T", tt.format, response)
+		}
+	}
+}
+
+func TestConvertToAPIGatewayV2ResponseCookies(t *testing.T) {
+	response, err := ConvertToAPIGatewayV2Response(&coreinterfaces.Response{
+		StatusCode: 302,
+		Headers: map[string]string{
+			"Set-Cookie": "a=1" + HeaderValueSeparator + "b=2",
+			"Vary":       "Accept" + HeaderValueSeparator + "Origin",
+		},
+		Body: []byte{0xff},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 302 || !reflect.DeepEqual(response.Cookies, []string{"a=1", "b=2"}) {
+		t.Fatalf("resposta: obtida %+v", response)
+	}
+	if _, ok := response.Headers["Set-Cookie"]; ok || response.Headers["Vary"] != "Accept, Origin" {
+		t.Fatalf("cabeçalhos: obtido %v", response.Headers)
+	}
+	if !response.IsBase64Encoded || response.Body != base64.StdEncoding.EncodeToString([]byte{0xff}) {
+		t.Fatalf("corpo binário: %s (base64=%v)", response.Body, response.IsBase64Encoded)
+	}
+
+	empty, err := ConvertToAPIGatewayV2Response(nil)
+	if err != nil || empty.StatusCode != 204 {
+		t.Fatalf("resposta vazia: %+v, %v", empty, err)
+	}
+}
+
+func TestConvertToFunctionURLResponse(t *testing.T) {
+	response, err := ConvertToFunctionURLResponse(map[string]int{"n": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != 200 || response.Body != `{"n":1}` || response.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("resposta: obtida %+v", response)
+	}
+
+	native := events.LambdaFunctionURLResponse{StatusCode: 418, Body: "chá"}
+	if got, err := ConvertToFunctionURLResponse(&native); err != nil || !reflect.DeepEqual(got, native) {
+		t.Fatalf("resposta nativa: %+v, %v", got, err)
+	}
+}
+
+func TestConvertToALBResponse(t *testing.T) {
+	handlerResponse := coreinterfaces.Response{
+		StatusCode: 503,
+		Headers: map[string]string{
+			"Set-Cookie": "a=1" + HeaderValueSeparator + "b=2",
+			"Vary":       "Accept" + HeaderValueSeparator + "Origin",
+		},
+	}
+
+	single, err := ConvertToALBResponse(handlerResponse, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if single.StatusDescription != "503 Service Unavailable" || single.MultiValueHeaders != nil {
+		t.Fatalf("resposta: obtida %+v", single)
+	}
+	want := map[string]string{"Set-Cookie": "a=1", "Vary": "Accept, Origin"}
+	if !reflect.DeepEqual(single.Headers, want) {
+		t.Fatalf("cabeçalhos sem multiValue: obtido %v", single.Headers)
+	}
+
+	multi, err := ConvertToALBResponse(handlerResponse, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantMulti := map[string][]string{"Set-Cookie": {"a=1", "b=2"}, "Vary": {"Accept", "Origin"}}
+	if multi.Headers != nil || !reflect.DeepEqual(multi.MultiValueHeaders, wantMulti) {
+		t.Fatalf("cabeçalhos com multiValue: Headers=%v, MultiValueHeaders=%v", multi.Headers, multi.MultiValueHeaders)
+	}
+}
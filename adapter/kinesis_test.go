@@ -0,0 +1,90 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/internal/kpl"
+)
+
+// kinesisTestEvent monta um lote com um registro agregado (A/1 e B/1)
+// seguido de um registro simples (C/1)
+func kinesisTestEvent() events.KinesisEvent {
+	aggregate := kpl.NewAggregate()
+	aggregate.Add("A", "", []byte("A/1"))
+	aggregate.Add("B", "", []byte("B/1"))
+
+	record := func(sequence, partitionKey string, data []byte) events.KinesisEventRecord {
+		return events.KinesisEventRecord{
+			EventID:        "shardId-000000000000:" + sequence,
+			EventSource:    "aws:kinesis",
+			EventSourceArn: "arn:aws:kinesis:us-east-1:111111111111:stream/pedidos",
+			AwsRegion:      "us-east-1",
+			Kinesis: events.KinesisRecord{
+				PartitionKey:                partitionKey,
+				SequenceNumber:              sequence,
+				Data:                        data,
+				ApproximateArrivalTimestamp: events.SecondsEpochTime{Time: time.Unix(1700000000, 0)},
+			},
+		}
+	}
+	return events.KinesisEvent{Records: []events.KinesisEventRecord{
+		record("100", "A", aggregate.Bytes()),
+		record("200", "C", []byte("C/1")),
+	}}
+}
+
+func TestConvertKinesisRecords(t *testing.T) {
+	recordEvents := ConvertKinesisRecords(kinesisTestEvent())
+	if len(recordEvents) != 3 {
+		t.Fatalf("esperados 3 eventos, obtidos %d", len(recordEvents))
+	}
+
+	expected := []struct {
+		data, partitionKey, sequenceNumber string
+	}{
+		{"A/1", "A", "100"},
+		{"B/1", "B", "100"},
+		{"C/1", "C", "200"},
+	}
+	for i, want := range expected {
+		event := recordEvents[i]
+		if string(event.Data) != want.data || event.Metadata["partitionKey"] != want.partitionKey || event.Metadata["sequenceNumber"] != want.sequenceNumber {
+			t.Fatalf("evento %d: Data=%s, Metadata=%v", i, event.Data, event.Metadata)
+		}
+		if event.Type != "kinesis.record" || event.Metadata["shardId"] != "shardId-000000000000" {
+			t.Fatalf("evento %d: Type=%s, shardId=%v", i, event.Type, event.Metadata["shardId"])
+		}
+	}
+	if recordEvents[1].Metadata["subSequenceNumber"] != 1 {
+		t.Fatalf("subSequenceNumber do registro agregado: obtido %v", recordEvents[1].Metadata["subSequenceNumber"])
+	}
+	if _, ok := recordEvents[2].Metadata["subSequenceNumber"]; ok {
+		t.Fatal("registro simples não deveria ter subSequenceNumber")
+	}
+}
+
+func TestConvertToGenericEventKinesis(t *testing.T) {
+	raw, err := json.Marshal(kinesisTestEvent())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, err := ConvertToGenericEvent(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "kinesis.record" || string(event.Data) != "A/1" {
+		t.Fatalf("primeiro registro: Type=%s, Data=%s", event.Type, event.Data)
+	}
+	records, ok := event.Metadata["records"].([]map[string]interface{})
+	if !ok || len(records) != 3 {
+		t.Fatalf("records: obtido %#v", event.Metadata["records"])
+	}
+	if data, _ := records[2]["data"].([]byte); string(data) != "C/1" {
+		t.Fatalf("último registro do lote: obtido %v", records[2])
+	}
+}
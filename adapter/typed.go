@@ -0,0 +1,253 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/internal/sqsarn"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// ConvertAPIGatewayEvent converte uma requisição do API Gateway (REST, formato
+// 1.0) em evento genérico. Data recebe o corpo, decodificado se vier em base64,
+// e Metadata recebe cabeçalhos, parâmetros e o contexto da requisição.
+func ConvertAPIGatewayEvent(request events.APIGatewayProxyRequest) coreinterfaces.Event {
+	requestContext := request.RequestContext
+	event := coreinterfaces.Event{
+		ID:        requestContext.RequestID,
+		Source:    "api",
+		Type:      "http.request",
+		RequestID: requestContext.RequestID,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      decodeBody(request.Body, request.IsBase64Encoded),
+		Metadata: map[string]interface{}{
+			"httpFormat":                      HTTPFormatAPIGatewayV1,
+			"httpMethod":                      request.HTTPMethod,
+			"path":                            request.Path,
+			"resource":                        request.Resource,
+			"headers":                         request.Headers,
+			"multiValueHeaders":               request.MultiValueHeaders,
+			"queryStringParameters":           request.QueryStringParameters,
+			"multiValueQueryStringParameters": request.MultiValueQueryStringParameters,
+			"pathParameters":                  request.PathParameters,
+			"stageVariables":                  request.StageVariables,
+			"body":                            request.Body,
+			"isBase64Encoded":                 request.IsBase64Encoded,
+			"stage":                           requestContext.Stage,
+			"apiId":                           requestContext.APIID,
+			"domainName":                      requestContext.DomainName,
+			"accountId":                       requestContext.AccountID,
+			"sourceIP":                        requestContext.Identity.SourceIP,
+			"userAgent":                       requestContext.Identity.UserAgent,
+		},
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if requestContext.RequestTimeEpoch > 0 {
+		event.Timestamp = time.UnixMilli(requestContext.RequestTimeEpoch).UTC().Format(time.RFC3339)
+	}
+	if requestContext.Authorizer != nil {
+		event.Metadata["authorizer"] = requestContext.Authorizer
+	}
+
+	return event
+}
+
+// Origem e tipo dos eventos de mensagens SQS
+const (
+	sqsEventSource = "queue"
+	sqsEventType   = "sqs.message"
+)
+
+// ConvertSQSEvent converte um lote SQS em evento genérico. O primeiro
+// registro define o evento e, havendo mais de um, todos ficam em "records".
+func ConvertSQSEvent(sqsEvent events.SQSEvent) coreinterfaces.Event {
+	if len(sqsEvent.Records) == 0 {
+		return coreinterfaces.Event{
+			ID:        uuid.New().String(),
+			Source:    sqsEventSource,
+			Type:      sqsEventType,
+			Timestamp: time.Now().UTC().Format(time.RFC3339),
+			Metadata:  make(map[string]interface{}),
+		}
+	}
+
+	event := ConvertSQSMessage(sqsEvent.Records[0])
+	if len(sqsEvent.Records) > 1 {
+		records := make([]map[string]interface{}, len(sqsEvent.Records))
+		for i, record := range sqsEvent.Records {
+			records[i] = sqsRecordMetadata(record)
+			records[i]["body"] = record.Body
+		}
+		event.Metadata["records"] = records
+	}
+	return event
+}
+
+// ConvertSQSMessage converte um registro SQS em evento genérico, com o corpo
+// em Data. É a conversão usada também por ConvertToGenericEvent e pelos
+// wrappers SQS do runtime. Os atributos de mensagem do tipo String e Number
+// ficam em Metadata["messageAttributes"].
+func ConvertSQSMessage(message events.SQSMessage) coreinterfaces.Event {
+	event := coreinterfaces.Event{
+		ID:        message.MessageId,
+		Source:    sqsEventSource,
+		Type:      sqsEventType,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Data:      []byte(message.Body),
+		Metadata:  sqsRecordMetadata(message),
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+	if sent, err := strconv.ParseInt(message.Attributes["SentTimestamp"], 10, 64); err == nil {
+		event.Timestamp = time.UnixMilli(sent).UTC().Format(time.RFC3339)
+	}
+
+	return event
+}
+
+// ConvertSQSRecord converte um registro SQS conforme o conteúdo da mensagem:
+// notificações S3, inclusive via SNS, e eventos do EventBridge entregues pela
+// fila viram eventos S3 e EventBridge com Metadata["envelope"]; as demais
+// mensagens são convertidas por ConvertSQSMessage.
+func ConvertSQSRecord(message events.SQSMessage) coreinterfaces.Event {
+	if s3Event, s3Bytes, envelope, ok := unwrapS3Notification(message.Body); ok {
+		event := convertS3Event(s3Event, s3Bytes)
+		event.Metadata["envelope"] = append([]string{"sqs"}, envelope...)
+		event.Metadata["messageId"] = message.MessageId
+		event.Metadata["queueArn"] = message.EventSourceARN
+		return event
+	}
+
+	if cwEvent, ok := unwrapEventBridgeEnvelope(message.Body); ok {
+		event := convertEventBridgeEvent(cwEvent)
+		event.Metadata["envelope"] = []string{"sqs"}
+		event.Metadata["messageId"] = message.MessageId
+		event.Metadata["queueArn"] = message.EventSourceARN
+		return event
+	}
+
+	return ConvertSQSMessage(message)
+}
+
+// sqsRecordMetadata extrai os campos relevantes de um registro SQS. A URL da
+// fila é montada a partir do ARN e omitida se o ARN não for válido.
+func sqsRecordMetadata(message events.SQSMessage) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"messageId":     message.MessageId,
+		"receiptHandle": message.ReceiptHandle,
+		"queueArn":      message.EventSourceARN,
+		"region":        message.AWSRegion,
+		"attributes":    message.Attributes,
+	}
+	if arn, err := sqsarn.Parse(message.EventSourceARN); err == nil {
+		metadata["queueUrl"] = arn.URL()
+	}
+
+	// Atributos de mensagem, usados por exemplo para responder requisições
+	attributes := make(map[string]string)
+	for name, attr := range message.MessageAttributes {
+		if attr.StringValue != nil {
+			attributes[name] = *attr.StringValue
+		}
+	}
+	if len(attributes) > 0 {
+		metadata["messageAttributes"] = attributes
+	}
+	if groupID := message.Attributes["MessageGroupId"]; groupID != "" {
+		metadata["messageGroupId"] = groupID
+	}
+	if count, err := strconv.Atoi(message.Attributes["ApproximateReceiveCount"]); err == nil {
+		metadata["approximateReceiveCount"] = count
+	}
+
+	return metadata
+}
+
+// ConvertS3Event converte uma notificação S3 em evento genérico. Data recebe
+// a notificação serializada.
+func ConvertS3Event(s3Event events.S3Event) coreinterfaces.Event {
+	data, _ := json.Marshal(s3Event)
+	return convertS3Event(s3Event, data)
+}
+
+// ConvertKinesisEvent converte um lote do Kinesis em um único evento
+// genérico. Data recebe apenas o primeiro registro de usuário; o lote inteiro,
+// já desagregado, fica em Metadata["records"]. Para um evento por registro,
+// use ConvertKinesisRecords.
+func ConvertKinesisEvent(kinesisEvent events.KinesisEvent) coreinterfaces.Event {
+	return convertKinesisEvent(kinesisEvent)
+}
+
+// ConvertKinesisRecords converte cada registro de usuário do lote em um
+// evento genérico, na ordem do shard. Registros agregados pela KPL são
+// desagregados e seus eventos compartilham ID e sequenceNumber, com a posição
+// no agregado em Metadata["subSequenceNumber"].
+func ConvertKinesisRecords(kinesisEvent events.KinesisEvent) []coreinterfaces.Event {
+	return convertKinesisRecords(kinesisEvent)
+}
+
+// ConvertEventBridgeEvent converte um evento do EventBridge em evento
+// genérico, com o detail-type em Type e o detail em Data
+func ConvertEventBridgeEvent(cwEvent events.CloudWatchEvent) coreinterfaces.Event {
+	return convertEventBridgeEvent(cwEvent)
+}
+
+// ConvertToAPIGatewayResponse converte o retorno de um handler genérico em
+// resposta do API Gateway REST. Aceita *Response, Response, a própria resposta
+// do API Gateway, []byte e string; outros valores são serializados em JSON e
+// nil resulta em 204. Corpos que não são UTF-8 válido são enviados em base64 e
+// cabeçalhos com HeaderValueSeparator vão para multiValueHeaders.
+func ConvertToAPIGatewayResponse(response interface{}) (events.APIGatewayProxyResponse, error) {
+	switch r := response.(type) {
+	case events.APIGatewayProxyResponse:
+		return r, nil
+	case *events.APIGatewayProxyResponse:
+		if r != nil {
+			return *r, nil
+		}
+	}
+
+	normalized, err := normalizeResponse(response)
+	if err != nil {
+		return events.APIGatewayProxyResponse{}, err
+	}
+	body, isBase64Encoded := encodeBody(normalized.body)
+	apiResponse := events.APIGatewayProxyResponse{
+		StatusCode:      normalized.statusCode,
+		Headers:         make(map[string]string, len(normalized.headers)),
+		Body:            body,
+		IsBase64Encoded: isBase64Encoded,
+	}
+
+	for k, v := range normalized.headers {
+		if !strings.Contains(v, HeaderValueSeparator) {
+			apiResponse.Headers[k] = v
+			continue
+		}
+		if apiResponse.MultiValueHeaders == nil {
+			apiResponse.MultiValueHeaders = make(map[string][]string)
+		}
+		apiResponse.MultiValueHeaders[k] = strings.Split(v, HeaderValueSeparator)
+	}
+	return apiResponse, nil
+}
+
+// decodeBody retorna o corpo da requisição, decodificando-o se vier em base64
+func decodeBody(body string, isBase64Encoded bool) []byte {
+	if isBase64Encoded {
+		if decoded, err := base64.StdEncoding.DecodeString(body); err == nil {
+			return decoded
+		}
+	}
+	return []byte(body)
+}
@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// sqsTestMessage monta um registro SQS com atributos de sistema e de mensagem
+func sqsTestMessage(body string) events.SQSMessage {
+	value := "c-1"
+	return events.SQSMessage{
+		MessageId:         "m-1",
+		ReceiptHandle:     "rh-1",
+		Body:              body,
+		Attributes:        map[string]string{"ApproximateReceiveCount": "1", "SentTimestamp": "1700000000000"},
+		MessageAttributes: map[string]events.SQSMessageAttribute{"correlationId": {StringValue: &value, DataType: "String"}},
+		EventSource:       "aws:sqs",
+		EventSourceARN:    "arn:aws:sqs:us-east-1:111111111111:pedidos",
+		AWSRegion:         "us-east-1",
+	}
+}
+
+func TestConvertSQSMessageMatchesGenericEvent(t *testing.T) {
+	message := sqsTestMessage(`{"n":1}`)
+	typed := ConvertSQSMessage(message)
+
+	raw, err := json.Marshal(events.SQSEvent{Records: []events.SQSMessage{message}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	generic, err := ConvertToGenericEvent(context.Background(), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if typed.Source != "queue" || typed.Type != "sqs.message" {
+		t.Fatalf("ConvertSQSMessage: Source=%s, Type=%s", typed.Source, typed.Type)
+	}
+	if !reflect.DeepEqual(typed, generic) {
+		t.Fatalf("formatos diferentes:\nConvertSQSMessage:     %+v\nConvertToGenericEvent: %+v", typed, generic)
+	}
+	if string(typed.Data) != `{"n":1}` {
+		t.Fatalf("Data deveria ser o corpo: %s", typed.Data)
+	}
+	if got := typed.Metadata["queueUrl"]; got != "https://sqs.us-east-1.amazonaws.com/111111111111/pedidos" {
+		t.Fatalf("queueUrl: obtido %v", got)
+	}
+}
+
+func TestSQSRecordMetadataWithoutARN(t *testing.T) {
+	message := sqsTestMessage("x")
+	message.EventSourceARN = ""
+	if _, ok := ConvertSQSMessage(message).Metadata["queueUrl"]; ok {
+		t.Fatal("queueUrl não deveria ser preenchida sem ARN")
+	}
+}
+
+func TestConvertSQSRecordUnwrapsContent(t *testing.T) {
+	s3Body := `{"Records":[{"eventSource":"aws:s3","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"b"},"object":{"key":"k"}}}]}`
+	event := ConvertSQSRecord(sqsTestMessage(s3Body))
+	if event.Type != "s3.object.created" || !reflect.DeepEqual(event.Metadata["envelope"], []string{"sqs"}) {
+		t.Fatalf("S3 via SQS: Type=%s, envelope=%v", event.Type, event.Metadata["envelope"])
+	}
+
+	ebBody := `{"id":"e-1","detail-type":"PedidoCriado","source":"loja","detail":{"n":1}}`
+	event = ConvertSQSRecord(sqsTestMessage(ebBody))
+	if event.Type != "PedidoCriado" || event.Metadata["queueArn"] != "arn:aws:sqs:us-east-1:111111111111:pedidos" {
+		t.Fatalf("EventBridge via SQS: Type=%s, Metadata=%v", event.Type, event.Metadata)
+	}
+
+	if event := ConvertSQSRecord(sqsTestMessage("texto")); event.Type != "sqs.message" {
+		t.Fatalf("mensagem comum: Type=%s", event.Type)
+	}
+}
@@ -28,7 +28,44 @@ func NewS3Provider(cloudProvider coreinterfaces.CloudProvider) (coreinterfaces.S
 	return storage.NewS3Provider(cloudProvider)
 }
 
+// NewLocalS3Provider cria um provedor de armazenamento S3 sobre um diretório local
+func NewLocalS3Provider(dir string) (coreinterfaces.StorageProvider, error) {
+	return storage.NewLocalS3Provider(dir)
+}
+
+// NewMemoryS3Provider cria um provedor de armazenamento S3 em memória
+func NewMemoryS3Provider() coreinterfaces.StorageProvider {
+	return storage.NewMemoryS3Provider()
+}
+
 // NewSQSProvider cria um novo provedor de mensageria SQS
 func NewSQSProvider(cloudProvider coreinterfaces.CloudProvider) (coreinterfaces.MessagingProvider, error) {
 	return messaging.NewSQSProvider(cloudProvider)
+}
+
+// NewMemorySQSProvider cria um provedor de mensageria SQS em memória
+func NewMemorySQSProvider() coreinterfaces.MessagingProvider {
+	return messaging.NewMemorySQSProvider()
+}
+
+// SharedMemorySQSClient retorna o cliente em memória compartilhado pelos
+// provedores "aws-sqs-memory" da fábrica, para definir filas e controlar o
+// relógio em testes
+func SharedMemorySQSClient() *messaging.MemorySQSClient {
+	return sharedMemorySQS
+}
+
+// NewSNSProvider cria um novo provedor de mensageria SNS
+func NewSNSProvider(cloudProvider coreinterfaces.CloudProvider) (coreinterfaces.MessagingProvider, error) {
+	return messaging.NewSNSProvider(cloudProvider)
+}
+
+// NewEventBridgeProvider cria um novo provedor de eventos EventBridge
+func NewEventBridgeProvider(cloudProvider coreinterfaces.CloudProvider) (coreinterfaces.MessagingProvider, error) {
+	return messaging.NewEventBridgeProvider(cloudProvider)
+}
+
+// NewKinesisProvider cria um novo provedor de streams Kinesis
+func NewKinesisProvider(cloudProvider coreinterfaces.CloudProvider) (coreinterfaces.MessagingProvider, error) {
+	return messaging.NewKinesisProvider(cloudProvider)
 }
\ No newline at end of file
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"log"
+	"os"
 	
 	"github.com/silviomfa/go-cloud-aws/messaging"
 	"github.com/silviomfa/go-cloud-aws/provider"
@@ -11,6 +12,9 @@ import (
 	"github.com/silviomfa/go-cloud-core/pkg/interfaces"
 )
 
+// sharedMemorySQS é o cliente dos provedores "aws-sqs-memory" criados pela fábrica
+var sharedMemorySQS = messaging.NewMemorySQSClient()
+
 // Inicializar registra todos os provedores AWS
 func init() {
 	log.Println("Registrando provedores AWS...")
@@ -27,12 +31,59 @@ func init() {
 		return storage.NewDynamoDBProvider(provider)
 	})
 	
+	// Registrar provedor de armazenamento S3
+	factory.RegisterStorageProvider("aws-s3", func(provider interfaces.CloudProvider) (interfaces.StorageProvider, error) {
+		log.Println("Criando provedor de armazenamento S3")
+		return storage.NewS3Provider(provider)
+	})
+	
+	// Registrar provedor S3 em diretório local (diretório definido por S3_LOCAL_DIR)
+	factory.RegisterStorageProvider("aws-s3-local", func(provider interfaces.CloudProvider) (interfaces.StorageProvider, error) {
+		dir := os.Getenv("S3_LOCAL_DIR")
+		if dir == "" {
+			dir = ".s3local"
+		}
+		log.Printf("Criando provedor de armazenamento S3 local em %s", dir)
+		return storage.NewLocalS3Provider(dir)
+	})
+	
+	// Registrar provedor S3 em memória, compartilhado entre as instâncias do processo
+	memoryS3 := storage.NewMemoryS3Client()
+	factory.RegisterStorageProvider("aws-s3-memory", func(provider interfaces.CloudProvider) (interfaces.StorageProvider, error) {
+		log.Println("Criando provedor de armazenamento S3 em memória")
+		return storage.NewS3ProviderWithClient(memoryS3), nil
+	})
+	
 	// Registrar provedor de mensageria SQS
 	factory.RegisterMessagingProvider("aws", func(provider interfaces.CloudProvider) (interfaces.MessagingProvider, error) {
 		log.Println("Criando provedor de mensageria SQS")
 		return messaging.NewSQSProvider(provider)
 	})
 	
+	// Registrar provedor SQS em memória, compartilhado entre as instâncias do processo
+	factory.RegisterMessagingProvider("aws-sqs-memory", func(provider interfaces.CloudProvider) (interfaces.MessagingProvider, error) {
+		log.Println("Criando provedor de mensageria SQS em memória")
+		return messaging.NewSQSProviderWithClient(sharedMemorySQS, nil), nil
+	})
+	
+	// Registrar provedor de mensageria SNS
+	factory.RegisterMessagingProvider("aws-sns", func(provider interfaces.CloudProvider) (interfaces.MessagingProvider, error) {
+		log.Println("Criando provedor de mensageria SNS")
+		return messaging.NewSNSProvider(provider)
+	})
+	
+	// Registrar provedor de eventos EventBridge
+	factory.RegisterMessagingProvider("aws-eventbridge", func(provider interfaces.CloudProvider) (interfaces.MessagingProvider, error) {
+		log.Println("Criando provedor de eventos EventBridge")
+		return messaging.NewEventBridgeProvider(provider)
+	})
+	
+	// Registrar provedor de streams Kinesis
+	factory.RegisterMessagingProvider("aws-kinesis", func(provider interfaces.CloudProvider) (interfaces.MessagingProvider, error) {
+		log.Println("Criando provedor de streams Kinesis")
+		return messaging.NewKinesisProvider(provider)
+	})
+	
 	// Registrar provedor runtime Lambda
 	factory.RegisterRuntimeProvider("aws", func(provider interfaces.CloudProvider) (interfaces.RuntimeProvider, error) {
 		log.Println("Criando provedor runtime Lambda")
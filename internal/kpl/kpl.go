@@ -0,0 +1,252 @@
+// Package kpl implementa o formato de agregação de registros da Kinesis
+// Producer Library, compartilhado pelo provedor Kinesis e pelo adaptador de
+// eventos Lambda sem que um dependa do outro.
+package kpl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Formato de agregação da Kinesis Producer Library (KPL): bytes mágicos,
+// mensagem protobuf AggregatedRecord e o MD5 da mensagem. É o formato
+// entendido pela KCL e pelas bibliotecas de desagregação para Lambda.
+var magic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+// Campos protobuf de AggregatedRecord e Record
+const (
+	fieldPartitionKeyTable    protowire.Number = 1
+	fieldExplicitHashKeyTable protowire.Number = 2
+	fieldRecords              protowire.Number = 3
+
+	fieldRecordPartitionKeyIndex    protowire.Number = 1
+	fieldRecordExplicitHashKeyIndex protowire.Number = 2
+	fieldRecordData                 protowire.Number = 3
+)
+
+// Record é um registro de usuário extraído de um registro agregado
+type Record struct {
+	PartitionKey    string
+	ExplicitHashKey string
+	Data            []byte
+	// SubSequenceNumber é a posição do registro dentro do agregado
+	SubSequenceNumber int
+}
+
+// IsAggregated informa se os dados estão no formato de agregação da KPL
+func IsAggregated(data []byte) bool {
+	return len(data) > len(magic)+md5.Size && bytes.HasPrefix(data, magic)
+}
+
+// Deaggregate extrai os registros de usuário de um registro agregado pela KPL.
+// Dados que não estão no formato agregado, ou cujo MD5 não confere, são
+// retornados como um único registro com a chave de partição informada, como
+// faz a KCL.
+func Deaggregate(partitionKey string, data []byte) ([]Record, error) {
+	single := []Record{{PartitionKey: partitionKey, Data: data}}
+	if !IsAggregated(data) {
+		return single, nil
+	}
+
+	message := data[len(magic) : len(data)-md5.Size]
+	sum := md5.Sum(message)
+	if !bytes.Equal(sum[:], data[len(data)-md5.Size:]) {
+		return single, nil
+	}
+
+	var partitionKeys, hashKeys []string
+	var encoded [][]byte
+	for len(message) > 0 {
+		num, typ, n := protowire.ConsumeTag(message)
+		if n < 0 {
+			return nil, fmt.Errorf("registro agregado inválido: %w", protowire.ParseError(n))
+		}
+		message = message[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, message)
+			if n < 0 {
+				return nil, fmt.Errorf("registro agregado inválido: %w", protowire.ParseError(n))
+			}
+			message = message[n:]
+			continue
+		}
+
+		value, n := protowire.ConsumeBytes(message)
+		if n < 0 {
+			return nil, fmt.Errorf("registro agregado inválido: %w", protowire.ParseError(n))
+		}
+		message = message[n:]
+
+		switch num {
+		case fieldPartitionKeyTable:
+			partitionKeys = append(partitionKeys, string(value))
+		case fieldExplicitHashKeyTable:
+			hashKeys = append(hashKeys, string(value))
+		case fieldRecords:
+			encoded = append(encoded, value)
+		}
+	}
+
+	records := make([]Record, len(encoded))
+	for i, raw := range encoded {
+		record, err := decodeRecord(raw, partitionKeys, hashKeys)
+		if err != nil {
+			return nil, fmt.Errorf("registro %d do agregado: %w", i, err)
+		}
+		record.SubSequenceNumber = i
+		records[i] = record
+	}
+	return records, nil
+}
+
+// decodeRecord decodifica uma mensagem Record, resolvendo os índices das tabelas de chaves
+func decodeRecord(raw []byte, partitionKeys, hashKeys []string) (Record, error) {
+	var record Record
+	hasPartitionKey := false
+
+	for len(raw) > 0 {
+		num, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return record, protowire.ParseError(n)
+		}
+		raw = raw[n:]
+
+		switch {
+		case num == fieldRecordPartitionKeyIndex && typ == protowire.VarintType,
+			num == fieldRecordExplicitHashKeyIndex && typ == protowire.VarintType:
+			index, n := protowire.ConsumeVarint(raw)
+			if n < 0 {
+				return record, protowire.ParseError(n)
+			}
+			raw = raw[n:]
+
+			table := partitionKeys
+			if num == fieldRecordExplicitHashKeyIndex {
+				table = hashKeys
+			}
+			if index >= uint64(len(table)) {
+				return record, fmt.Errorf("índice de chave fora da tabela: %d", index)
+			}
+			if num == fieldRecordPartitionKeyIndex {
+				record.PartitionKey = table[index]
+				hasPartitionKey = true
+			} else {
+				record.ExplicitHashKey = table[index]
+			}
+		case num == fieldRecordData && typ == protowire.BytesType:
+			data, n := protowire.ConsumeBytes(raw)
+			if n < 0 {
+				return record, protowire.ParseError(n)
+			}
+			raw = raw[n:]
+			record.Data = append([]byte(nil), data...)
+		default:
+			// Tags e campos desconhecidos são ignorados
+			n = protowire.ConsumeFieldValue(num, typ, raw)
+			if n < 0 {
+				return record, protowire.ParseError(n)
+			}
+			raw = raw[n:]
+		}
+	}
+
+	if !hasPartitionKey {
+		return record, fmt.Errorf("registro sem chave de partição")
+	}
+	return record, nil
+}
+
+// Aggregate acumula registros de usuário em um registro agregado
+type Aggregate struct {
+	partitionKeys map[string]uint64
+	hashKeys      map[string]uint64
+	keyTable      []string
+	hashTable     []string
+	records       [][]byte
+	size          int
+}
+
+// NewAggregate cria um agregado vazio
+func NewAggregate() *Aggregate {
+	return &Aggregate{
+		partitionKeys: make(map[string]uint64),
+		hashKeys:      make(map[string]uint64),
+		size:          len(magic) + md5.Size,
+	}
+}
+
+// Len retorna o número de registros de usuário do agregado
+func (a *Aggregate) Len() int {
+	return len(a.records)
+}
+
+// SizeWith estima o tamanho do agregado com mais um registro
+func (a *Aggregate) SizeWith(partitionKey, explicitHashKey string, data []byte) int {
+	size := a.size + len(data) + 3*protowire.SizeVarint(uint64(len(data))) + 8
+	if _, ok := a.partitionKeys[partitionKey]; !ok {
+		size += len(partitionKey) + 3
+	}
+	if explicitHashKey != "" {
+		if _, ok := a.hashKeys[explicitHashKey]; !ok {
+			size += len(explicitHashKey) + 3
+		}
+	}
+	return size
+}
+
+// Add inclui um registro de usuário no agregado
+func (a *Aggregate) Add(partitionKey, explicitHashKey string, data []byte) {
+	a.size = a.SizeWith(partitionKey, explicitHashKey, data)
+
+	keyIndex, ok := a.partitionKeys[partitionKey]
+	if !ok {
+		keyIndex = uint64(len(a.keyTable))
+		a.partitionKeys[partitionKey] = keyIndex
+		a.keyTable = append(a.keyTable, partitionKey)
+	}
+
+	var encoded []byte
+	encoded = protowire.AppendTag(encoded, fieldRecordPartitionKeyIndex, protowire.VarintType)
+	encoded = protowire.AppendVarint(encoded, keyIndex)
+	if explicitHashKey != "" {
+		hashIndex, ok := a.hashKeys[explicitHashKey]
+		if !ok {
+			hashIndex = uint64(len(a.hashTable))
+			a.hashKeys[explicitHashKey] = hashIndex
+			a.hashTable = append(a.hashTable, explicitHashKey)
+		}
+		encoded = protowire.AppendTag(encoded, fieldRecordExplicitHashKeyIndex, protowire.VarintType)
+		encoded = protowire.AppendVarint(encoded, hashIndex)
+	}
+	encoded = protowire.AppendTag(encoded, fieldRecordData, protowire.BytesType)
+	encoded = protowire.AppendBytes(encoded, data)
+
+	a.records = append(a.records, encoded)
+}
+
+// Bytes serializa o agregado no formato da KPL
+func (a *Aggregate) Bytes() []byte {
+	var message []byte
+	for _, key := range a.keyTable {
+		message = protowire.AppendTag(message, fieldPartitionKeyTable, protowire.BytesType)
+		message = protowire.AppendString(message, key)
+	}
+	for _, key := range a.hashTable {
+		message = protowire.AppendTag(message, fieldExplicitHashKeyTable, protowire.BytesType)
+		message = protowire.AppendString(message, key)
+	}
+	for _, record := range a.records {
+		message = protowire.AppendTag(message, fieldRecords, protowire.BytesType)
+		message = protowire.AppendBytes(message, record)
+	}
+
+	sum := md5.Sum(message)
+	data := make([]byte, 0, len(magic)+len(message)+md5.Size)
+	data = append(data, magic...)
+	data = append(data, message...)
+	return append(data, sum[:]...)
+}
@@ -0,0 +1,60 @@
+package kpl
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAggregateRoundTrip(t *testing.T) {
+	aggregate := NewAggregate()
+	aggregate.Add("A", "", []byte("um"))
+	aggregate.Add("B", "123", []byte("dois"))
+	aggregate.Add("A", "", []byte("três"))
+
+	data := aggregate.Bytes()
+	if !IsAggregated(data) {
+		t.Fatal("o agregado deveria ser reconhecido")
+	}
+	if size := aggregate.SizeWith("C", "", nil); size < len(data) {
+		t.Fatalf("SizeWith subestima o agregado: %d < %d", size, len(data))
+	}
+
+	records, err := Deaggregate("externa", data)
+	if err != nil {
+		t.Fatalf("Deaggregate: %v", err)
+	}
+	expected := []Record{
+		{PartitionKey: "A", Data: []byte("um"), SubSequenceNumber: 0},
+		{PartitionKey: "B", ExplicitHashKey: "123", Data: []byte("dois"), SubSequenceNumber: 1},
+		{PartitionKey: "A", Data: []byte("três"), SubSequenceNumber: 2},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("esperados %d registros, obtidos %d", len(expected), len(records))
+	}
+	for i, record := range records {
+		want := expected[i]
+		if record.PartitionKey != want.PartitionKey || record.ExplicitHashKey != want.ExplicitHashKey ||
+			!bytes.Equal(record.Data, want.Data) || record.SubSequenceNumber != want.SubSequenceNumber {
+			t.Fatalf("registro %d: obtido %+v, esperado %+v", i, record, want)
+		}
+	}
+}
+
+func TestDeaggregateNonAggregated(t *testing.T) {
+	records, err := Deaggregate("chave", []byte(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("Deaggregate: %v", err)
+	}
+	if len(records) != 1 || records[0].PartitionKey != "chave" || string(records[0].Data) != `{"a":1}` {
+		t.Fatalf("dados não agregados: obtido %+v", records)
+	}
+
+	// Um MD5 corrompido faz o registro ser tratado como não agregado
+	aggregate := NewAggregate()
+	aggregate.Add("A", "", []byte("x"))
+	data := aggregate.Bytes()
+	data[len(data)-1] ^= 0xFF
+	if records, _ := Deaggregate("chave", data); len(records) != 1 || !bytes.Equal(records[0].Data, data) {
+		t.Fatalf("agregado corrompido: obtido %+v", records)
+	}
+}
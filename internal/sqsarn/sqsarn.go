@@ -0,0 +1,35 @@
+// Package sqsarn converte ARNs de filas SQS em URLs. É compartilhado pelo
+// provedor SQS e pelo adaptador de eventos Lambda.
+package sqsarn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// QueueARN são os componentes de um ARN de fila
+type QueueARN struct {
+	Partition string
+	Region    string
+	Account   string
+	Name      string
+}
+
+// Parse extrai partição, região, conta e nome de um ARN de fila no formato
+// arn:partição:sqs:região:conta:nome
+func Parse(arn string) (QueueARN, error) {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[0] != "arn" || parts[2] != "sqs" || parts[3] == "" || parts[4] == "" || parts[5] == "" {
+		return QueueARN{}, fmt.Errorf("ARN de fila inválido: %s", arn)
+	}
+	return QueueARN{Partition: parts[1], Region: parts[3], Account: parts[4], Name: parts[5]}, nil
+}
+
+// URL monta a URL da fila no endpoint da região do ARN
+func (a QueueARN) URL() string {
+	domain := "amazonaws.com"
+	if a.Partition == "aws-cn" {
+		domain = "amazonaws.com.cn"
+	}
+	return fmt.Sprintf("https://sqs.%s.%s/%s/%s", a.Region, domain, a.Account, a.Name)
+}
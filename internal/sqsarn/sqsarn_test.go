@@ -0,0 +1,26 @@
+package sqsarn
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	cases := map[string]string{
+		"arn:aws:sqs:us-east-1:111111111111:pedidos":      "https://sqs.us-east-1.amazonaws.com/111111111111/pedidos",
+		"arn:aws:sqs:sa-east-1:222222222222:pedidos.fifo": "https://sqs.sa-east-1.amazonaws.com/222222222222/pedidos.fifo",
+		"arn:aws-cn:sqs:cn-north-1:333333333333:pedidos":  "https://sqs.cn-north-1.amazonaws.com.cn/333333333333/pedidos",
+	}
+	for arn, want := range cases {
+		parsed, err := Parse(arn)
+		if err != nil {
+			t.Fatalf("Parse(%s): %v", arn, err)
+		}
+		if got := parsed.URL(); got != want {
+			t.Fatalf("URL(%s): obtido %s, esperado %s", arn, got, want)
+		}
+	}
+
+	for _, arn := range []string{"", "pedidos", "arn:aws:sns:us-east-1:111111111111:pedidos", "arn:aws:sqs:us-east-1::pedidos"} {
+		if _, err := Parse(arn); err == nil {
+			t.Fatalf("Parse(%q) deveria falhar", arn)
+		}
+	}
+}
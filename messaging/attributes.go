@@ -0,0 +1,219 @@
+package messaging
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Tipos de dados de atributos de mensagem. Sufixos personalizados são
+// permitidos pelo SQS, por exemplo "Number.int" ou "String.uuid".
+const (
+	AttributeTypeString = "String"
+	AttributeTypeNumber = "Number"
+	AttributeTypeBinary = "Binary"
+)
+
+// Nomes dos atributos de sistema mais usados
+const (
+	SystemAttributeApproximateReceiveCount = "ApproximateReceiveCount"
+	SystemAttributeSentTimestamp           = "SentTimestamp"
+	SystemAttributeFirstReceiveTimestamp   = "ApproximateFirstReceiveTimestamp"
+	SystemAttributeMessageGroupID          = "MessageGroupId"
+	SystemAttributeMessageDeduplicationID  = "MessageDeduplicationId"
+	SystemAttributeSequenceNumber          = "SequenceNumber"
+	SystemAttributeSenderID                = "SenderId"
+)
+
+// MessageAttribute é um atributo tipado de mensagem
+type MessageAttribute struct {
+	DataType    string
+	StringValue string
+	BinaryValue []byte
+}
+
+// StringAttribute cria um atributo do tipo String
+func StringAttribute(value string) MessageAttribute {
+	return MessageAttribute{DataType: AttributeTypeString, StringValue: value}
+}
+
+// NumberAttribute cria um atributo do tipo Number a partir de qualquer valor numérico
+func NumberAttribute(value interface{}) MessageAttribute {
+	return MessageAttribute{DataType: AttributeTypeNumber, StringValue: fmt.Sprint(value)}
+}
+
+// BinaryAttribute cria um atributo do tipo Binary
+func BinaryAttribute(value []byte) MessageAttribute {
+	return MessageAttribute{DataType: AttributeTypeBinary, BinaryValue: value}
+}
+
+// BaseType retorna o tipo sem o sufixo personalizado ("Number.int" -> "Number")
+func (a MessageAttribute) BaseType() string {
+	base, _, _ := strings.Cut(a.DataType, ".")
+	return base
+}
+
+// Int retorna o valor de um atributo Number como inteiro
+func (a MessageAttribute) Int() (int64, error) {
+	if a.BaseType() != AttributeTypeNumber {
+		return 0, fmt.Errorf("atributo do tipo %s não é numérico", a.DataType)
+	}
+	return strconv.ParseInt(a.StringValue, 10, 64)
+}
+
+// Float retorna o valor de um atributo Number como ponto flutuante
+func (a MessageAttribute) Float() (float64, error) {
+	if a.BaseType() != AttributeTypeNumber {
+		return 0, fmt.Errorf("atributo do tipo %s não é numérico", a.DataType)
+	}
+	return strconv.ParseFloat(a.StringValue, 64)
+}
+
+// SendOptions configura o envio de uma mensagem
+type SendOptions struct {
+	// Attributes são os atributos de mensagem (no máximo 10)
+	Attributes map[string]MessageAttribute
+	// DelaySeconds atrasa a entrega da mensagem (0 a 900 segundos); não
+	// suportado em filas FIFO
+	DelaySeconds int32
+	// MessageGroupID substitui o grupo gerado pela configuração FIFO
+	MessageGroupID string
+	// DeduplicationID substitui o ID de deduplicação gerado pela configuração FIFO
+	DeduplicationID string
+	// ContentType substitui o tipo de conteúdo configurado com SetCodec
+	ContentType string
+	// ContentEncoding substitui a compressão configurada com SetCodec
+	ContentEncoding string
+	// MessageType define o tipo usado na validação por schema; se vazio, é
+	// obtido de MessageTyper ou dos tipos registrados no SchemaRegistry
+	MessageType string
+}
+
+// ReceiveOptions configura a recepção de mensagens
+type ReceiveOptions struct {
+	// MaxMessages é o número máximo de mensagens (1 a 10, padrão 1)
+	MaxMessages int
+	// WaitTimeSeconds ativa long polling (0 a 20 segundos)
+	WaitTimeSeconds int32
+	// VisibilityTimeout substitui o tempo de visibilidade da fila, se maior que zero
+	VisibilityTimeout int32
+	// MessageAttributeNames restringe os atributos de mensagem retornados
+	// (aceita prefixos como "app.*"); vazio retorna todos. Os atributos de
+	// sistema são sempre retornados.
+	MessageAttributeNames []string
+}
+
+// ReceivedMessage é uma mensagem recebida com seus atributos de mensagem e de sistema
+type ReceivedMessage struct {
+	coreinterfaces.Message
+	Attributes       map[string]MessageAttribute
+	SystemAttributes map[string]string
+}
+
+// ReceiveCount retorna quantas vezes a mensagem já foi recebida, incluindo esta
+func (m ReceivedMessage) ReceiveCount() int {
+	count, _ := strconv.Atoi(m.SystemAttributes[SystemAttributeApproximateReceiveCount])
+	return count
+}
+
+// SentTimestamp retorna o momento em que a mensagem foi enviada
+func (m ReceivedMessage) SentTimestamp() time.Time {
+	return parseEpochMillis(m.SystemAttributes[SystemAttributeSentTimestamp])
+}
+
+// FirstReceiveTimestamp retorna o momento da primeira recepção da mensagem
+func (m ReceivedMessage) FirstReceiveTimestamp() time.Time {
+	return parseEpochMillis(m.SystemAttributes[SystemAttributeFirstReceiveTimestamp])
+}
+
+// MessageGroupID retorna o grupo da mensagem em filas FIFO
+func (m ReceivedMessage) MessageGroupID() string {
+	return m.SystemAttributes[SystemAttributeMessageGroupID]
+}
+
+// Metadata reúne atributos de mensagem e de sistema em um único mapa.
+// Atributos de sistema usam o prefixo "sqs." para evitar colisões.
+func (m ReceivedMessage) Metadata() map[string]interface{} {
+	metadata := make(map[string]interface{}, len(m.Attributes)+len(m.SystemAttributes))
+	for name, attr := range m.Attributes {
+		if attr.BaseType() == AttributeTypeBinary {
+			metadata[name] = attr.BinaryValue
+		} else {
+			metadata[name] = attr.StringValue
+		}
+	}
+	for name, value := range m.SystemAttributes {
+		metadata["sqs."+name] = value
+	}
+	return metadata
+}
+
+// parseEpochMillis converte um timestamp em milissegundos para time.Time
+func parseEpochMillis(value string) time.Time {
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(millis).UTC()
+}
+
+// toSQSAttributes converte atributos tipados para o formato do SDK
+func toSQSAttributes(attributes map[string]MessageAttribute) (map[string]types.MessageAttributeValue, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+	if len(attributes) > 10 {
+		return nil, fmt.Errorf("mensagens SQS aceitam no máximo 10 atributos, recebidos %d", len(attributes))
+	}
+
+	result := make(map[string]types.MessageAttributeValue, len(attributes))
+	for name, attr := range attributes {
+		value := types.MessageAttributeValue{DataType: aws.String(attr.DataType)}
+		switch attr.BaseType() {
+		case AttributeTypeBinary:
+			value.BinaryValue = attr.BinaryValue
+		case AttributeTypeString, AttributeTypeNumber:
+			value.StringValue = aws.String(attr.StringValue)
+		default:
+			return nil, fmt.Errorf("tipo de atributo inválido para %s: %s", name, attr.DataType)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
+
+// fromSQSAttributes converte atributos do SDK para o formato tipado
+func fromSQSAttributes(attributes map[string]types.MessageAttributeValue) map[string]MessageAttribute {
+	result := make(map[string]MessageAttribute, len(attributes))
+	for name, value := range attributes {
+		result[name] = MessageAttribute{
+			DataType:    aws.ToString(value.DataType),
+			StringValue: aws.ToString(value.StringValue),
+			BinaryValue: value.BinaryValue,
+		}
+	}
+	return result
+}
+
+// toReceivedMessage converte uma mensagem do SDK
+func toReceivedMessage(msg types.Message) ReceivedMessage {
+	systemAttributes := make(map[string]string, len(msg.Attributes))
+	for name, value := range msg.Attributes {
+		systemAttributes[name] = value
+	}
+
+	return ReceivedMessage{
+		Message: coreinterfaces.Message{
+			ID:            aws.ToString(msg.MessageId),
+			Body:          []byte(aws.ToString(msg.Body)),
+			ReceiptHandle: aws.ToString(msg.ReceiptHandle),
+		},
+		Attributes:       fromSQSAttributes(msg.MessageAttributes),
+		SystemAttributes: systemAttributes,
+	}
+}
@@ -0,0 +1,128 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSendReceiveAttributesRoundTrip(t *testing.T) {
+	client, clock := newTestMemorySQS()
+	client.DefineQueue("pedidos", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	id, err := p.SendMessageWithOptions(ctx, "pedidos", map[string]int{"n": 1}, SendOptions{Attributes: map[string]MessageAttribute{
+		"origem":     StringAttribute("api"),
+		"tentativas": NumberAttribute(3),
+		"valor":      {DataType: "Number.float", StringValue: "9.5"},
+		"assinatura": BinaryAttribute([]byte{1, 2, 3}),
+	}})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	msg := received[0]
+	if msg.ID != id || string(msg.Body) != `{"n":1}` {
+		t.Fatalf("mensagem: ID=%s, Body=%s", msg.ID, msg.Body)
+	}
+	if msg.Attributes["origem"].StringValue != "api" {
+		t.Fatalf("atributo String: obtido %+v", msg.Attributes["origem"])
+	}
+	if n, err := msg.Attributes["tentativas"].Int(); err != nil || n != 3 {
+		t.Fatalf("atributo Number: %d, %v", n, err)
+	}
+	if f, err := msg.Attributes["valor"].Float(); err != nil || f != 9.5 || msg.Attributes["valor"].BaseType() != AttributeTypeNumber {
+		t.Fatalf("atributo Number.float: %v, %v", f, err)
+	}
+	if !bytes.Equal(msg.Attributes["assinatura"].BinaryValue, []byte{1, 2, 3}) {
+		t.Fatalf("atributo Binary: obtido %+v", msg.Attributes["assinatura"])
+	}
+	if _, err := msg.Attributes["origem"].Int(); err == nil {
+		t.Fatal("Int deveria falhar para atributo String")
+	}
+
+	if msg.ReceiveCount() != 1 || !msg.SentTimestamp().Equal(clock.Now()) || !msg.FirstReceiveTimestamp().Equal(clock.Now()) {
+		t.Fatalf("atributos de sistema: obtido %v", msg.SystemAttributes)
+	}
+	metadata := msg.Metadata()
+	if metadata["origem"] != "api" || metadata["sqs."+SystemAttributeApproximateReceiveCount] != "1" {
+		t.Fatalf("Metadata: obtido %v", metadata)
+	}
+}
+
+func TestSendMessageWithDelay(t *testing.T) {
+	client, clock := newTestMemorySQS()
+	client.DefineQueue("pedidos", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	if _, err := p.SendMessageWithOptions(ctx, "pedidos", "x", SendOptions{DelaySeconds: 901}); err == nil {
+		t.Fatal("atraso acima de 900 segundos deveria ser recusado")
+	}
+	if _, err := p.SendMessageWithOptions(ctx, "pedidos", "x", SendOptions{DelaySeconds: 30}); err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+
+	if received, _ := p.ReceiveMessages(ctx, "pedidos", 1); len(received) != 0 {
+		t.Fatalf("mensagem entregue antes do atraso: %v", received)
+	}
+	clock.Advance(30 * time.Second)
+	if received, _ := p.ReceiveMessages(ctx, "pedidos", 1); len(received) != 1 {
+		t.Fatal("mensagem não entregue após o atraso")
+	}
+}
+
+func TestReceiveFIFOSystemAttributes(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	client.DefineQueue("pedidos.fifo", map[string]string{"FifoQueue": "true"})
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	_, err := p.SendMessageWithOptions(ctx, "pedidos.fifo", "x", SendOptions{MessageGroupID: "cliente-1", DeduplicationID: "d-1"})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos.fifo", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	msg := received[0]
+	if msg.MessageGroupID() != "cliente-1" || msg.SystemAttributes[SystemAttributeMessageDeduplicationID] != "d-1" || msg.SystemAttributes[SystemAttributeSequenceNumber] == "" {
+		t.Fatalf("atributos de sistema FIFO: obtido %v", msg.SystemAttributes)
+	}
+}
+
+func TestReceiveFiltersMessageAttributeNames(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	client.DefineQueue("pedidos", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	_, err := p.SendMessageWithOptions(ctx, "pedidos", "x", SendOptions{Attributes: map[string]MessageAttribute{
+		"app.tenant": StringAttribute("acme"),
+		"app.region": StringAttribute("sul"),
+		"origem":     StringAttribute("api"),
+	}})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 1, MessageAttributeNames: []string{"app.*"}})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	msg := received[0]
+	if len(msg.Attributes) != 2 || msg.Attributes["app.tenant"].StringValue != "acme" || msg.Attributes["app.region"].StringValue != "sul" {
+		t.Fatalf("atributos de mensagem: obtido %v", msg.Attributes)
+	}
+	// O filtro não afeta os atributos de sistema
+	if msg.ReceiveCount() != 1 || msg.SentTimestamp().IsZero() {
+		t.Fatalf("atributos de sistema: obtido %v", msg.SystemAttributes)
+	}
+}
@@ -0,0 +1,488 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	// maxBatchPayloadSize é o tamanho máximo somado das mensagens de um lote
+	maxBatchPayloadSize = 256 * 1024
+	// maxBatchRetries é o número de novas tentativas para entradas com falha
+	maxBatchRetries = 3
+	// batchRetryBaseDelay é a espera inicial entre tentativas, dobrada a cada uma
+	batchRetryBaseDelay = 100 * time.Millisecond
+	// batchConcurrency é o número de lotes enviados em paralelo em filas padrão
+	batchConcurrency = 4
+)
+
+// ErrOrderBlocked indica que a entrada não foi enviada porque uma entrada
+// anterior com a mesma chave de ordenação (grupo FIFO ou chave de partição) falhou
+var ErrOrderBlocked = errors.New("mensagem não enviada: falha anterior com a mesma chave de ordenação")
+
+// BatchResult é o resultado de uma entrada de uma operação em lote.
+// Index corresponde à posição da entrada no slice informado pelo chamador.
+type BatchResult struct {
+	Index     int
+	MessageID string
+	Err       error
+}
+
+// batchOutcome é a resposta normalizada de uma chamada em lote, comum a SQS e SNS
+type batchOutcome struct {
+	successful map[string]string
+	failed     []batchFailure
+}
+
+// batchFailure é uma entrada com falha em uma chamada em lote
+type batchFailure struct {
+	id          string
+	code        string
+	message     string
+	senderFault bool
+}
+
+// sqsFailures converte as falhas de uma chamada em lote do SQS
+func sqsFailures(entries []types.BatchResultErrorEntry) []batchFailure {
+	failures := make([]batchFailure, len(entries))
+	for i, e := range entries {
+		failures[i] = batchFailure{
+			id:          aws.ToString(e.Id),
+			code:        aws.ToString(e.Code),
+			message:     aws.ToString(e.Message),
+			senderFault: e.SenderFault,
+		}
+	}
+	return failures
+}
+
+// sendEntry é uma entrada de envio com o tamanho já calculado
+type sendEntry struct {
+	entry types.SendMessageBatchRequestEntry
+	size  int
+}
+
+// SendMessages envia mensagens em lotes de até 10 entradas e 256 KB.
+// Apenas as entradas que falharem por erro do serviço são reenviadas. O
+// resultado tem uma posição por mensagem; o erro, quando presente, resume as
+// falhas. Em filas FIFO os lotes são enviados em sequência e, depois da
+// primeira falha de um grupo, as mensagens seguintes desse grupo não são
+// enviadas e recebem ErrOrderBlocked.
+func (p *SQSProvider) SendMessages(ctx context.Context, queueName string, messages []interface{}) ([]BatchResult, error) {
+	results := newBatchResults(len(messages))
+	if len(messages) == 0 {
+		return results, nil
+	}
+
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	fifo := IsFIFOQueue(queueName)
+	fifoConfig := p.fifo.get(queueName)
+	failedGroups := make(map[string]bool)
+	pending := make([]sendEntry, 0, len(messages))
+	for i, message := range messages {
+		if fifo && failedGroups[fifoConfig.groupID(message)] {
+			results[i].Err = ErrOrderBlocked
+			continue
+		}
+
+		entry, err := p.newSendEntry(ctx, queueName, i, message, SendOptions{})
+		if err == nil && entry.size > maxBatchPayloadSize {
+			err = fmt.Errorf("mensagem %d excede o limite de %d bytes: %d bytes", i, maxBatchPayloadSize, entry.size)
+		}
+		if err != nil {
+			results[i].Err = err
+			if fifo {
+				failedGroups[fifoConfig.groupID(message)] = true
+			}
+			continue
+		}
+		pending = append(pending, entry)
+	}
+
+	send := func(batch []sendEntry) (batchOutcome, error) {
+		entries := make([]types.SendMessageBatchRequestEntry, len(batch))
+		for i, e := range batch {
+			entries[i] = e.entry
+		}
+
+		output, err := p.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: &queueURL,
+			Entries:  entries,
+		})
+		if err != nil {
+			p.checkQueueError(queueName, err)
+			return batchOutcome{}, fmt.Errorf("erro ao enviar lote: %w", err)
+		}
+
+		outcome := batchOutcome{successful: make(map[string]string, len(output.Successful)), failed: sqsFailures(output.Failed)}
+		for _, success := range output.Successful {
+			outcome.successful[aws.ToString(success.Id)] = aws.ToString(success.MessageId)
+		}
+		return outcome, nil
+	}
+	entryID := func(e sendEntry) string { return aws.ToString(e.entry.Id) }
+
+	batches := chunkBySize(pending, maxBatchEntries, maxBatchPayloadSize, func(e sendEntry) int { return e.size })
+	if fifo {
+		groupOf := func(e sendEntry) []string { return []string{aws.ToString(e.entry.MessageGroupId)} }
+		retryOrderedBatches(ctx, batches, entryID, groupOf, send, results)
+	} else {
+		runBatches(ctx, batches, true, func(batch []sendEntry) {
+			retryBatch(ctx, batch, entryID, send, results)
+		})
+	}
+
+	return results, summarizeBatch(results, "enviadas")
+}
+
+// DeleteMessages remove mensagens em lotes de até 10 entradas, reenviando
+// apenas as entradas que falharem por erro do serviço. O resultado tem uma
+// posição por receipt handle. Objetos S3 de mensagens grandes também são removidos.
+func (p *SQSProvider) DeleteMessages(ctx context.Context, queueName string, receiptHandles []string) ([]BatchResult, error) {
+	results := newBatchResults(len(receiptHandles))
+	if len(receiptHandles) == 0 {
+		return results, nil
+	}
+
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]types.DeleteMessageBatchRequestEntry, len(receiptHandles))
+	pointers := make([]*payloadPointer, len(receiptHandles))
+	for i, receiptHandle := range receiptHandles {
+		handle, pointer := splitReceiptHandle(receiptHandle)
+		pointers[i] = pointer
+		pending[i] = types.DeleteMessageBatchRequestEntry{
+			Id:            aws.String(strconv.Itoa(i)),
+			ReceiptHandle: aws.String(handle),
+		}
+	}
+
+	var batches [][]types.DeleteMessageBatchRequestEntry
+	for start := 0; start < len(pending); start += maxBatchEntries {
+		end := start + maxBatchEntries
+		if end > len(pending) {
+			end = len(pending)
+		}
+		batches = append(batches, pending[start:end])
+	}
+
+	send := func(batch []types.DeleteMessageBatchRequestEntry) (batchOutcome, error) {
+		output, err := p.client.DeleteMessageBatch(ctx, &sqs.DeleteMessageBatchInput{
+			QueueUrl: &queueURL,
+			Entries:  batch,
+		})
+		if err != nil {
+			p.checkQueueError(queueName, err)
+			return batchOutcome{}, fmt.Errorf("erro ao remover lote: %w", err)
+		}
+
+		outcome := batchOutcome{successful: make(map[string]string, len(output.Successful)), failed: sqsFailures(output.Failed)}
+		for _, success := range output.Successful {
+			outcome.successful[aws.ToString(success.Id)] = ""
+		}
+		return outcome, nil
+	}
+	entryID := func(e types.DeleteMessageBatchRequestEntry) string { return aws.ToString(e.Id) }
+
+	runBatches(ctx, batches, true, func(batch []types.DeleteMessageBatchRequestEntry) {
+		retryBatch(ctx, batch, entryID, send, results)
+	})
+
+	for i, result := range results {
+		if result.Err == nil {
+			p.releasePayload(ctx, pointers[i])
+		}
+	}
+
+	return results, summarizeBatch(results, "removidas")
+}
+
+// newSendEntry serializa a mensagem com o codec da fila e monta a entrada de lote, usando a
+// posição da mensagem como ID da entrada. Corpos grandes vão para o S3 quando
+// EnableLargePayloads está ativo.
+func (p *SQSProvider) newSendEntry(ctx context.Context, queueName string, index int, message interface{}, opts SendOptions) (sendEntry, error) {
+	body, err := p.encodeMessage(queueName, message, &opts)
+	if err != nil {
+		return sendEntry{}, fmt.Errorf("mensagem %d: %w", index, err)
+	}
+	if err := p.applyFIFO(queueName, message, body, &opts); err != nil {
+		return sendEntry{}, err
+	}
+
+	body, messageAttributes, err := p.offloadPayload(ctx, body, opts.Attributes)
+	if err != nil {
+		return sendEntry{}, err
+	}
+
+	attributes, err := toSQSAttributes(messageAttributes)
+	if err != nil {
+		return sendEntry{}, err
+	}
+
+	entry := types.SendMessageBatchRequestEntry{
+		Id:                aws.String(strconv.Itoa(index)),
+		MessageBody:       aws.String(string(body)),
+		MessageAttributes: attributes,
+		DelaySeconds:      opts.DelaySeconds,
+	}
+	if opts.MessageGroupID != "" {
+		entry.MessageGroupId = aws.String(opts.MessageGroupID)
+	}
+	if opts.DeduplicationID != "" {
+		entry.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+	}
+
+	return sendEntry{entry: entry, size: messageSize(body, attributes)}, nil
+}
+
+// messageSize calcula o tamanho da mensagem como o SQS contabiliza: corpo mais
+// nome, tipo e valor de cada atributo
+func messageSize(body []byte, attributes map[string]types.MessageAttributeValue) int {
+	size := len(body)
+	for name, value := range attributes {
+		size += len(name) + len(aws.ToString(value.DataType)) + len(aws.ToString(value.StringValue)) + len(value.BinaryValue)
+	}
+	return size
+}
+
+// chunkBySize agrupa entradas em lotes respeitando a quantidade máxima de
+// entradas e o tamanho máximo somado, mantendo a ordem original
+func chunkBySize[E any](entries []E, maxEntries int, maxSize int, sizeOf func(E) int) [][]E {
+	var batches [][]E
+	var current []E
+	size := 0
+	for _, e := range entries {
+		entrySize := sizeOf(e)
+		if len(current) == maxEntries || (len(current) > 0 && size+entrySize > maxSize) {
+			batches = append(batches, current)
+			current, size = nil, 0
+		}
+		current = append(current, e)
+		size += entrySize
+	}
+	if len(current) > 0 {
+		batches = append(batches, current)
+	}
+	return batches
+}
+
+// runBatches executa os lotes em paralelo (limitado a batchConcurrency) ou em
+// sequência, quando a ordem precisa ser preservada
+func runBatches[E any](ctx context.Context, batches [][]E, parallel bool, run func([]E)) {
+	if !parallel {
+		for _, batch := range batches {
+			run(batch)
+		}
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, batchConcurrency)
+	for _, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(batch []E) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			run(batch)
+		}(batch)
+	}
+	wg.Wait()
+}
+
+// retryBatch envia um lote e reenvia apenas as entradas com falha do lado do
+// serviço, com espera exponencial. Falhas do chamador (SenderFault) não são
+// repetidas. Os resultados são gravados na posição indicada pelo ID da entrada.
+func retryBatch[E any](ctx context.Context, batch []E, entryID func(E) string, send func([]E) (batchOutcome, error), results []BatchResult) {
+	pending := batch
+	for attempt := 0; ; attempt++ {
+		outcome, err := send(pending)
+		if err != nil {
+			if attempt >= maxBatchRetries || !waitRetry(ctx, attempt) {
+				for _, e := range pending {
+					setBatchResult(results, entryID(e), "", err)
+				}
+				return
+			}
+			continue
+		}
+
+		for id, messageID := range outcome.successful {
+			setBatchResult(results, id, messageID, nil)
+		}
+
+		byID := make(map[string]E, len(pending))
+		for _, e := range pending {
+			byID[entryID(e)] = e
+		}
+
+		var retry []E
+		for _, failed := range outcome.failed {
+			setBatchResult(results, failed.id, "", fmt.Errorf("%s: %s", failed.code, failed.message))
+			if e, ok := byID[failed.id]; ok && !failed.senderFault {
+				retry = append(retry, e)
+			}
+		}
+
+		if len(retry) == 0 || attempt >= maxBatchRetries || !waitRetry(ctx, attempt) {
+			return
+		}
+		pending = retry
+	}
+}
+
+// retryOrderedBatches envia os lotes em sequência preservando a ordem por
+// chave (grupo FIFO ou chave de partição). Uma entrada com falha só é
+// reenviada se nenhuma entrada posterior com a mesma chave tiver sido aceita
+// na mesma chamada; os reenvios seguem a ordem original. Quando uma chave não
+// pode mais ser reenviada, as entradas seguintes com essa chave, neste e nos
+// próximos lotes, não são enviadas e recebem ErrOrderBlocked.
+func retryOrderedBatches[E any](ctx context.Context, batches [][]E, entryID func(E) string, keysOf func(E) []string, send func([]E) (batchOutcome, error), results []BatchResult) {
+	blocked := make(map[string]bool)
+	isBlocked := func(e E) bool {
+		for _, key := range keysOf(e) {
+			if blocked[key] {
+				return true
+			}
+		}
+		return false
+	}
+	block := func(e E) {
+		for _, key := range keysOf(e) {
+			blocked[key] = true
+		}
+	}
+
+	for _, batch := range batches {
+		var pending []E
+		for _, e := range batch {
+			if isBlocked(e) {
+				setBatchResult(results, entryID(e), "", ErrOrderBlocked)
+				continue
+			}
+			pending = append(pending, e)
+		}
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			outcome, err := send(pending)
+			if err != nil {
+				if attempt < maxBatchRetries && waitRetry(ctx, attempt) {
+					continue
+				}
+				for _, e := range pending {
+					setBatchResult(results, entryID(e), "", err)
+					block(e)
+				}
+				break
+			}
+
+			failures := make(map[string]batchFailure, len(outcome.failed))
+			for _, failed := range outcome.failed {
+				failures[failed.id] = failed
+			}
+
+			// Última posição aceita de cada chave nesta chamada
+			lastAccepted := make(map[string]int)
+			for i, e := range pending {
+				if _, failed := failures[entryID(e)]; !failed {
+					for _, key := range keysOf(e) {
+						lastAccepted[key] = i
+					}
+				}
+			}
+
+			var retry []E
+			for i, e := range pending {
+				id := entryID(e)
+				failed, ok := failures[id]
+				if !ok {
+					setBatchResult(results, id, outcome.successful[id], nil)
+					continue
+				}
+				setBatchResult(results, id, "", fmt.Errorf("%s: %s", failed.code, failed.message))
+
+				retryable := !failed.senderFault && attempt < maxBatchRetries && !isBlocked(e)
+				for _, key := range keysOf(e) {
+					if last, accepted := lastAccepted[key]; accepted && last > i {
+						retryable = false
+					}
+				}
+				if retryable {
+					retry = append(retry, e)
+				} else {
+					block(e)
+				}
+			}
+
+			if len(retry) > 0 && !waitRetry(ctx, attempt) {
+				for _, e := range retry {
+					block(e)
+				}
+				break
+			}
+			pending = retry
+		}
+	}
+}
+
+// waitRetry espera antes da próxima tentativa; retorna false se o contexto foi cancelado
+func waitRetry(ctx context.Context, attempt int) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(batchRetryBaseDelay << attempt):
+		return true
+	}
+}
+
+// setBatchResult grava o resultado de uma entrada pelo seu ID (a posição original)
+func setBatchResult(results []BatchResult, id string, messageID string, err error) {
+	i, convErr := strconv.Atoi(id)
+	if convErr != nil || i < 0 || i >= len(results) {
+		return
+	}
+	results[i].MessageID = messageID
+	results[i].Err = err
+}
+
+// newBatchResults cria os resultados já indexados pela posição de entrada
+func newBatchResults(n int) []BatchResult {
+	results := make([]BatchResult, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return results
+}
+
+// summarizeBatch retorna um erro resumindo as entradas com falha, ou nil
+func summarizeBatch(results []BatchResult, action string) error {
+	var first error
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			if first == nil {
+				first = result.Err
+			}
+			failed++
+		}
+	}
+	if failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d de %d mensagens não foram %s: %w", failed, len(results), action, first)
+}
@@ -0,0 +1,197 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// flakySQSClient falha, do lado do serviço, as entradas de lote cujo corpo
+// está em failures, tantas vezes quanto indicado
+type flakySQSClient struct {
+	*MemorySQSClient
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// SendMessageBatch repassa ao cliente em memória apenas as entradas que não devem falhar
+func (c *flakySQSClient) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		c.mu.Lock()
+		fail := c.failures[aws.ToString(entry.MessageBody)] > 0
+		if fail {
+			c.failures[aws.ToString(entry.MessageBody)]--
+		}
+		c.mu.Unlock()
+
+		if fail {
+			output.Failed = append(output.Failed, types.BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    aws.String("InternalError"),
+				Message: aws.String("falha simulada"),
+			})
+			continue
+		}
+
+		single, err := c.MemorySQSClient.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+			QueueUrl: params.QueueUrl,
+			Entries:  []types.SendMessageBatchRequestEntry{entry},
+		})
+		if err != nil {
+			return nil, err
+		}
+		output.Successful = append(output.Successful, single.Successful...)
+		output.Failed = append(output.Failed, single.Failed...)
+	}
+	return output, nil
+}
+
+// groupedMessage é uma mensagem de teste com grupo FIFO
+type groupedMessage struct {
+	Group string `json:"g"`
+	N     int    `json:"n"`
+}
+
+// newFlakyFIFOProvider cria um provedor sobre o cliente que falha as mensagens informadas
+func newFlakyFIFOProvider(t *testing.T, queue string, failures map[groupedMessage]int) (*SQSProvider, *flakySQSClient) {
+	t.Helper()
+	client := &flakySQSClient{MemorySQSClient: NewMemorySQSClient(), failures: make(map[string]int)}
+	client.DefineQueue(queue, nil)
+	for msg, count := range failures {
+		body, _ := json.Marshal(msg)
+		client.failures[string(body)] = count
+	}
+
+	p := NewSQSProviderWithClient(client, nil)
+	p.SetFIFOConfig(queue, FIFOConfig{GroupKey: func(message interface{}) string {
+		return message.(groupedMessage).Group
+	}})
+	return p, client
+}
+
+// arrivedInGroup retorna os números das mensagens de um grupo, na ordem de chegada
+func arrivedInGroup(t *testing.T, client *flakySQSClient, queue, group string) []int {
+	t.Helper()
+	var numbers []int
+	for _, body := range client.ArrivedMessages(queue) {
+		var msg groupedMessage
+		if err := json.Unmarshal([]byte(body), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Group == group {
+			numbers = append(numbers, msg.N)
+		}
+	}
+	return numbers
+}
+
+func TestSendMessagesFIFORetriesInOriginalOrder(t *testing.T) {
+	const queue = "orders.fifo"
+	p, client := newFlakyFIFOProvider(t, queue, map[groupedMessage]int{
+		{"A", 1}: 1,
+		{"A", 2}: 1,
+	})
+
+	messages := []interface{}{groupedMessage{"A", 1}, groupedMessage{"B", 1}, groupedMessage{"A", 2}}
+	results, err := p.SendMessages(context.Background(), queue, messages)
+	if err != nil {
+		t.Fatalf("SendMessages: %v (%+v)", err, results)
+	}
+
+	if got := arrivedInGroup(t, client, queue, "A"); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Fatalf("ordem do grupo A: obtido %v", got)
+	}
+}
+
+func TestSendMessagesFIFOStopsGroupAfterFailure(t *testing.T) {
+	const queue = "orders.fifo"
+	// A/1 falha em todas as tentativas; A/2 está no mesmo lote e é aceita,
+	// então A/1 não pode mais ser reenviada sem inverter a ordem
+	p, client := newFlakyFIFOProvider(t, queue, map[groupedMessage]int{{"A", 1}: 100})
+
+	var messages []interface{}
+	for n := 1; n <= 12; n++ {
+		messages = append(messages, groupedMessage{"A", n}, groupedMessage{"B", n})
+	}
+	results, err := p.SendMessages(context.Background(), queue, messages)
+	if err == nil {
+		t.Fatal("SendMessages deveria reportar a falha")
+	}
+
+	if got := arrivedInGroup(t, client, queue, "A"); !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Fatalf("grupo A: esperado apenas o restante do primeiro lote, obtido %v", got)
+	}
+	var b []int
+	for n := 1; n <= 12; n++ {
+		b = append(b, n)
+	}
+	if got := arrivedInGroup(t, client, queue, "B"); !reflect.DeepEqual(got, b) {
+		t.Fatalf("grupo B não deveria ser afetado: obtido %v", got)
+	}
+
+	if results[0].Err == nil || errors.Is(results[0].Err, ErrOrderBlocked) {
+		t.Fatalf("A/1: esperado o erro do serviço, obtido %v", results[0].Err)
+	}
+	for i := 10; i < len(messages); i += 2 {
+		if !errors.Is(results[i].Err, ErrOrderBlocked) {
+			t.Fatalf("A/%d em lote posterior: esperado ErrOrderBlocked, obtido %v", i/2+1, results[i].Err)
+		}
+	}
+}
+
+func TestSendMessagesFIFOInvalidMessageBlocksGroup(t *testing.T) {
+	const queue = "orders.fifo"
+	p, client := newFlakyFIFOProvider(t, queue, nil)
+
+	messages := []interface{}{
+		groupedMessage{"A", 1},
+		map[string]interface{}{"g": "A", "invalid": func() {}},
+		groupedMessage{"A", 3},
+	}
+	p.SetFIFOConfig(queue, FIFOConfig{GroupKey: func(message interface{}) string {
+		switch m := message.(type) {
+		case groupedMessage:
+			return m.Group
+		case map[string]interface{}:
+			return fmt.Sprint(m["g"])
+		}
+		return ""
+	}})
+
+	results, _ := p.SendMessages(context.Background(), queue, messages)
+	if results[0].Err != nil || results[1].Err == nil || !errors.Is(results[2].Err, ErrOrderBlocked) {
+		t.Fatalf("resultados inesperados: %+v", results)
+	}
+	if got := arrivedInGroup(t, client, queue, "A"); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("grupo A: obtido %v", got)
+	}
+}
+
+func TestSendMessagesStandardRetriesFailures(t *testing.T) {
+	client := &flakySQSClient{MemorySQSClient: NewMemorySQSClient(), failures: map[string]int{`"b"`: 2}}
+	client.DefineQueue("jobs", nil)
+	p := NewSQSProviderWithClient(client, nil)
+
+	results, err := p.SendMessages(context.Background(), "jobs", []interface{}{"a", "b", "c"})
+	if err != nil {
+		t.Fatalf("SendMessages: %v", err)
+	}
+	for _, result := range results {
+		if result.MessageID == "" {
+			t.Fatalf("mensagem %d sem ID: %+v", result.Index, result)
+		}
+	}
+	if got := len(client.ArrivedMessages("jobs")); got != 3 {
+		t.Fatalf("esperadas 3 mensagens, obtidas %d", got)
+	}
+}
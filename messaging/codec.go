@@ -0,0 +1,365 @@
+package messaging
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"google.golang.org/protobuf/proto"
+)
+
+// Atributos de mensagem que descrevem a serialização do corpo. Mensagens sem
+// ContentTypeAttribute são tratadas como JSON, o formato histórico do provedor.
+const (
+	ContentTypeAttribute     = "contentType"
+	ContentEncodingAttribute = "contentEncoding"
+)
+
+// Tipos de conteúdo e compressões embutidos
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+
+	EncodingGzip = "gzip"
+	EncodingZstd = "zstd"
+)
+
+// DefaultMaxDecompressedSize é o tamanho máximo padrão de um corpo
+// descomprimido. Corpos maiores são recusados, para que uma mensagem
+// pequena não esgote a memória ao ser descomprimida.
+const DefaultMaxDecompressedSize = 16 << 20
+
+// ErrUnknownContentType indica um tipo de conteúdo ou compressão sem codec registrado
+var ErrUnknownContentType = errors.New("tipo de conteúdo sem codec registrado")
+
+// ErrDecompressedTooLarge indica um corpo que excede o tamanho máximo depois
+// de descomprimido. Em Receive a mensagem vai para o PoisonHandler.
+var ErrDecompressedTooLarge = errors.New("corpo descomprimido excede o tamanho máximo")
+
+// Codec serializa mensagens em um tipo de conteúdo
+type Codec interface {
+	// ContentType é o valor do atributo ContentTypeAttribute
+	ContentType() string
+	// Binary indica que a saída não é texto e precisa de base64 no corpo da mensagem
+	Binary() bool
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Compressor comprime o corpo já serializado
+type Compressor interface {
+	// Encoding é o valor do atributo ContentEncodingAttribute
+	Encoding() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// CodecConfig escolhe o codec e a compressão de uma fila
+type CodecConfig struct {
+	// ContentType é o tipo de conteúdo; vazio usa JSON
+	ContentType string
+	// ContentEncoding é a compressão; vazio não comprime
+	ContentEncoding string
+}
+
+// CodecRegistry guarda os codecs e compressores disponíveis. Os corpos binários
+// ou comprimidos são codificados em base64, pois o SQS só aceita texto.
+type CodecRegistry struct {
+	mu          sync.RWMutex
+	codecs      map[string]Codec
+	compressors map[string]Compressor
+}
+
+// DefaultCodecs é o registro usado pelos provedores que não têm um próprio
+var DefaultCodecs = NewCodecRegistry()
+
+// NewCodecRegistry cria um registro com JSON, Protobuf, gzip e zstd
+func NewCodecRegistry() *CodecRegistry {
+	r := &CodecRegistry{
+		codecs:      make(map[string]Codec),
+		compressors: make(map[string]Compressor),
+	}
+	r.RegisterCodec(JSONCodec{})
+	r.RegisterCodec(ProtobufCodec{})
+	r.RegisterCompressor(GzipCompressor{})
+	r.RegisterCompressor(ZstdCompressor{})
+	return r
+}
+
+// RegisterCodec registra um codec, substituindo o anterior do mesmo tipo
+func (r *CodecRegistry) RegisterCodec(codec Codec) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.codecs[mediaType(codec.ContentType())] = codec
+}
+
+// RegisterCompressor registra um compressor, substituindo o anterior da mesma compressão
+func (r *CodecRegistry) RegisterCompressor(compressor Compressor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.compressors[strings.ToLower(compressor.Encoding())] = compressor
+}
+
+// Codec retorna o codec do tipo de conteúdo, ignorando parâmetros como charset
+func (r *CodecRegistry) Codec(contentType string) (Codec, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codec, ok := r.codecs[mediaType(contentType)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownContentType, contentType)
+	}
+	return codec, nil
+}
+
+// Compressor retorna o compressor da compressão informada
+func (r *CodecRegistry) Compressor(encoding string) (Compressor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	compressor, ok := r.compressors[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return nil, fmt.Errorf("%w: compressão %s", ErrUnknownContentType, encoding)
+	}
+	return compressor, nil
+}
+
+// Encode serializa e comprime a mensagem, retornando o corpo e os atributos
+// que o descrevem
+func (r *CodecRegistry) Encode(message interface{}, config CodecConfig) ([]byte, map[string]MessageAttribute, error) {
+	contentType := config.ContentType
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	codec, err := r.Codec(contentType)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	body, err := codec.Marshal(message)
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar mensagem como %s: %w", contentType, err)
+	}
+
+	attributes := map[string]MessageAttribute{
+		ContentTypeAttribute: StringAttribute(codec.ContentType()),
+	}
+	binary := codec.Binary()
+	if config.ContentEncoding != "" {
+		compressor, err := r.Compressor(config.ContentEncoding)
+		if err != nil {
+			return nil, nil, err
+		}
+		if body, err = compressor.Compress(body); err != nil {
+			return nil, nil, fmt.Errorf("erro ao comprimir mensagem com %s: %w", compressor.Encoding(), err)
+		}
+		attributes[ContentEncodingAttribute] = StringAttribute(compressor.Encoding())
+		binary = true
+	}
+
+	if binary {
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(body)))
+		base64.StdEncoding.Encode(encoded, body)
+		body = encoded
+	}
+	return body, attributes, nil
+}
+
+// Decode descomprime e desserializa o corpo em v conforme os atributos da
+// mensagem. Sem ContentTypeAttribute o corpo é tratado como JSON.
+func (r *CodecRegistry) Decode(body []byte, attributes map[string]MessageAttribute, v interface{}) error {
+	contentType := ContentTypeJSON
+	if attr, ok := attributes[ContentTypeAttribute]; ok && attr.StringValue != "" {
+		contentType = attr.StringValue
+	}
+	codec, err := r.Codec(contentType)
+	if err != nil {
+		return err
+	}
+
+	var compressor Compressor
+	if attr, ok := attributes[ContentEncodingAttribute]; ok && attr.StringValue != "" {
+		if compressor, err = r.Compressor(attr.StringValue); err != nil {
+			return err
+		}
+	}
+
+	if codec.Binary() || compressor != nil {
+		decoded := make([]byte, base64.StdEncoding.DecodedLen(len(body)))
+		n, err := base64.StdEncoding.Decode(decoded, bytes.TrimSpace(body))
+		if err != nil {
+			return fmt.Errorf("corpo não está em base64: %w", err)
+		}
+		body = decoded[:n]
+	}
+	if compressor != nil {
+		if body, err = compressor.Decompress(body); err != nil {
+			return fmt.Errorf("erro ao descomprimir mensagem com %s: %w", compressor.Encoding(), err)
+		}
+	}
+
+	if err := codec.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("erro ao desserializar mensagem %s: %w", codec.ContentType(), err)
+	}
+	return nil
+}
+
+// mediaType normaliza o tipo de conteúdo, removendo parâmetros
+func mediaType(contentType string) string {
+	base, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(base))
+}
+
+// JSONCodec serializa mensagens em JSON
+type JSONCodec struct{}
+
+// ContentType retorna application/json
+func (JSONCodec) ContentType() string { return ContentTypeJSON }
+
+// Binary retorna false: JSON é texto
+func (JSONCodec) Binary() bool { return false }
+
+// Marshal serializa em JSON
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+// Unmarshal desserializa JSON
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// ProtobufCodec serializa mensagens Protocol Buffers; os valores devem
+// implementar proto.Message
+type ProtobufCodec struct{}
+
+// ContentType retorna application/x-protobuf
+func (ProtobufCodec) ContentType() string { return ContentTypeProtobuf }
+
+// Binary retorna true: o formato binário vai em base64
+func (ProtobufCodec) Binary() bool { return true }
+
+// Marshal serializa a mensagem protobuf
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("tipo %T não é uma mensagem protobuf", v)
+	}
+	return proto.Marshal(msg)
+}
+
+// Unmarshal desserializa na mensagem protobuf
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("tipo %T não é uma mensagem protobuf", v)
+	}
+	return proto.Unmarshal(data, msg)
+}
+
+// maxDecompressedSize retorna o limite informado ou o padrão, se zero
+func maxDecompressedSize(maxSize int64) int64 {
+	if maxSize <= 0 {
+		return DefaultMaxDecompressedSize
+	}
+	return maxSize
+}
+
+// GzipCompressor comprime com gzip
+type GzipCompressor struct {
+	// MaxSize é o tamanho máximo do corpo descomprimido; zero usa
+	// DefaultMaxDecompressedSize
+	MaxSize int64
+}
+
+// Encoding retorna gzip
+func (GzipCompressor) Encoding() string { return EncodingGzip }
+
+// Compress comprime com gzip
+func (GzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress descomprime gzip, retornando ErrDecompressedTooLarge se o
+// resultado exceder MaxSize
+func (c GzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	maxSize := maxDecompressedSize(c.MaxSize)
+	body, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("%w: limite de %d bytes", ErrDecompressedTooLarge, maxSize)
+	}
+	return body, nil
+}
+
+// ZstdCompressor comprime com Zstandard
+type ZstdCompressor struct {
+	// MaxSize é o tamanho máximo do corpo descomprimido; zero usa
+	// DefaultMaxDecompressedSize
+	MaxSize int64
+}
+
+// zstdEncoder e os decodificadores são compartilhados; EncodeAll e DecodeAll
+// são seguros para uso concorrente. Há um decodificador por tamanho máximo.
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoders   sync.Map
+)
+
+// zstdDecoder retorna o decodificador limitado a maxSize bytes
+func zstdDecoder(maxSize int64) (*zstd.Decoder, error) {
+	if decoder, ok := zstdDecoders.Load(maxSize); ok {
+		return decoder.(*zstd.Decoder), nil
+	}
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(max(maxSize, zstd.MinWindowSize))))
+	if err != nil {
+		return nil, err
+	}
+	actual, loaded := zstdDecoders.LoadOrStore(maxSize, decoder)
+	if loaded {
+		decoder.Close()
+	}
+	return actual.(*zstd.Decoder), nil
+}
+
+// Encoding retorna zstd
+func (ZstdCompressor) Encoding() string { return EncodingZstd }
+
+// Compress comprime com zstd
+func (ZstdCompressor) Compress(data []byte) ([]byte, error) {
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+// Decompress descomprime zstd, retornando ErrDecompressedTooLarge se o
+// resultado exceder MaxSize
+func (c ZstdCompressor) Decompress(data []byte) ([]byte, error) {
+	maxSize := maxDecompressedSize(c.MaxSize)
+	decoder, err := zstdDecoder(maxSize)
+	if err != nil {
+		return nil, err
+	}
+	body, err := decoder.DecodeAll(data, nil)
+	// A janela do quadro também é limitada, e limites menores que a janela
+	// mínima do zstd são verificados no resultado
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) || int64(len(body)) > maxSize {
+		return nil, fmt.Errorf("%w: limite de %d bytes", ErrDecompressedTooLarge, maxSize)
+	}
+	return body, err
+}
@@ -0,0 +1,197 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// poisonRecorder registra as mensagens entregues ao PoisonHandler
+type poisonRecorder struct {
+	ids  []string
+	errs []error
+}
+
+func (r *poisonRecorder) handle(ctx context.Context, queueName string, msg ReceivedMessage, err error) {
+	r.ids = append(r.ids, msg.ID)
+	r.errs = append(r.errs, err)
+}
+
+func TestCodecRegistryLookup(t *testing.T) {
+	registry := NewCodecRegistry()
+
+	codec, err := registry.Codec("Application/JSON; charset=utf-8")
+	if err != nil || codec.ContentType() != ContentTypeJSON {
+		t.Fatalf("Codec com parâmetros: %v, %v", codec, err)
+	}
+	if _, err := registry.Codec("text/csv"); !errors.Is(err, ErrUnknownContentType) {
+		t.Fatalf("tipo desconhecido: esperado ErrUnknownContentType, obtido %v", err)
+	}
+	if _, err := registry.Compressor(" GZIP "); err != nil {
+		t.Fatalf("Compressor: %v", err)
+	}
+	if _, err := registry.Compressor("br"); !errors.Is(err, ErrUnknownContentType) {
+		t.Fatalf("compressão desconhecida: esperado ErrUnknownContentType, obtido %v", err)
+	}
+
+	registry.RegisterCompressor(GzipCompressor{MaxSize: 10})
+	compressor, _ := registry.Compressor(EncodingGzip)
+	if compressor.(GzipCompressor).MaxSize != 10 {
+		t.Fatalf("RegisterCompressor deveria substituir o compressor gzip: %#v", compressor)
+	}
+}
+
+func TestCodecRegistryRoundTrip(t *testing.T) {
+	registry := NewCodecRegistry()
+	configs := []CodecConfig{
+		{},
+		{ContentEncoding: EncodingGzip},
+		{ContentEncoding: EncodingZstd},
+	}
+	for _, config := range configs {
+		body, attributes, err := registry.Encode(testOrder{Key: "A", Total: 3}, config)
+		if err != nil {
+			t.Fatalf("Encode(%+v): %v", config, err)
+		}
+		if got := attributes[ContentEncodingAttribute].StringValue; got != config.ContentEncoding {
+			t.Fatalf("Encode(%+v): atributo %s = %q", config, ContentEncodingAttribute, got)
+		}
+
+		var order testOrder
+		if err := registry.Decode(body, attributes, &order); err != nil {
+			t.Fatalf("Decode(%+v): %v", config, err)
+		}
+		if order != (testOrder{Key: "A", Total: 3}) {
+			t.Fatalf("Decode(%+v): obtido %+v", config, order)
+		}
+	}
+
+	// Corpos sem atributos de conteúdo são JSON
+	var order testOrder
+	if err := registry.Decode([]byte(`{"key":"B"}`), nil, &order); err != nil || order.Key != "B" {
+		t.Fatalf("Decode sem atributos: %+v, %v", order, err)
+	}
+}
+
+func TestCodecRegistryProtobufRoundTrip(t *testing.T) {
+	registry := NewCodecRegistry()
+	for _, encoding := range []string{"", EncodingGzip, EncodingZstd} {
+		config := CodecConfig{ContentType: ContentTypeProtobuf, ContentEncoding: encoding}
+		body, attributes, err := registry.Encode(wrapperspb.String("pedido"), config)
+		if err != nil {
+			t.Fatalf("Encode(%+v): %v", config, err)
+		}
+
+		decoded := &wrapperspb.StringValue{}
+		if err := registry.Decode(body, attributes, decoded); err != nil {
+			t.Fatalf("Decode(%+v): %v", config, err)
+		}
+		if !proto.Equal(decoded, wrapperspb.String("pedido")) {
+			t.Fatalf("Decode(%+v): obtido %v", config, decoded)
+		}
+	}
+
+	if _, _, err := registry.Encode(testOrder{}, CodecConfig{ContentType: ContentTypeProtobuf}); err == nil {
+		t.Fatal("Encode deveria recusar valor que não é protobuf")
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 100)
+	compressors := []struct {
+		limited   Compressor
+		unlimited Compressor
+	}{
+		{GzipCompressor{MaxSize: 99}, GzipCompressor{MaxSize: 100}},
+		{ZstdCompressor{MaxSize: 99}, ZstdCompressor{MaxSize: 100}},
+	}
+	for _, c := range compressors {
+		compressed, err := c.limited.Compress(data)
+		if err != nil {
+			t.Fatalf("%s Compress: %v", c.limited.Encoding(), err)
+		}
+		if _, err := c.limited.Decompress(compressed); !errors.Is(err, ErrDecompressedTooLarge) {
+			t.Fatalf("%s acima do limite: esperado ErrDecompressedTooLarge, obtido %v", c.limited.Encoding(), err)
+		}
+		body, err := c.unlimited.Decompress(compressed)
+		if err != nil || !bytes.Equal(body, data) {
+			t.Fatalf("%s no limite: %d bytes, %v", c.unlimited.Encoding(), len(body), err)
+		}
+	}
+}
+
+func TestDecompressDefaultLimit(t *testing.T) {
+	bomb := make([]byte, DefaultMaxDecompressedSize+1)
+	for _, compressor := range []Compressor{GzipCompressor{}, ZstdCompressor{}} {
+		compressed, err := compressor.Compress(bomb)
+		if err != nil {
+			t.Fatalf("%s Compress: %v", compressor.Encoding(), err)
+		}
+		if _, err := compressor.Decompress(compressed); !errors.Is(err, ErrDecompressedTooLarge) {
+			t.Fatalf("%s: esperado ErrDecompressedTooLarge, obtido %v", compressor.Encoding(), err)
+		}
+	}
+}
+
+func TestReceiveSendsUndecodableToPoisonHandler(t *testing.T) {
+	client := NewMemorySQSClient()
+	queueURL := client.DefineQueue("pedidos", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	registry := NewCodecRegistry()
+	registry.RegisterCompressor(GzipCompressor{MaxSize: 1024})
+	p.SetCodecRegistry(registry)
+	poison := &poisonRecorder{}
+	p.SetPoisonHandler(poison.handle)
+	ctx := context.Background()
+
+	valid, err := p.SendMessageWithOptions(ctx, "pedidos", testOrder{Key: "A", Total: 1}, SendOptions{ContentEncoding: EncodingGzip})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+	large, err := p.SendMessageWithOptions(ctx, "pedidos", testOrder{Key: strings.Repeat("a", 2048)}, SendOptions{ContentEncoding: EncodingGzip})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+	sendTestMessage(t, client, queueURL, `{"total":"x"}`, "")
+
+	messages, err := Receive[testOrder](ctx, p, "pedidos", ReceiveOptions{MaxMessages: 10})
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if len(messages) != 1 || messages[0].ID != valid || messages[0].Value.Key != "A" {
+		t.Fatalf("mensagens desserializadas: %+v", messages)
+	}
+
+	if len(poison.ids) != 2 {
+		t.Fatalf("PoisonHandler deveria receber 2 mensagens: %v", poison.ids)
+	}
+	for i, id := range poison.ids {
+		if id == large && !errors.Is(poison.errs[i], ErrDecompressedTooLarge) {
+			t.Fatalf("mensagem acima do limite: esperado ErrDecompressedTooLarge, obtido %v", poison.errs[i])
+		}
+	}
+}
+
+func TestReceiveProtobuf(t *testing.T) {
+	client := NewMemorySQSClient()
+	client.DefineQueue("eventos", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	p.SetCodec("eventos", CodecConfig{ContentType: ContentTypeProtobuf, ContentEncoding: EncodingZstd})
+	ctx := context.Background()
+
+	if _, err := p.SendMessageWithOptions(ctx, "eventos", wrapperspb.Int64(42), SendOptions{}); err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+	messages, err := Receive[*wrapperspb.Int64Value](ctx, p, "eventos", ReceiveOptions{MaxMessages: 1})
+	if err != nil {
+		t.Fatalf("Receive: %v", err)
+	}
+	if len(messages) != 1 || messages[0].Value.GetValue() != 42 {
+		t.Fatalf("mensagens desserializadas: %+v", messages)
+	}
+}
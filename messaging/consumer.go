@@ -0,0 +1,284 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// MessageHandler processa uma mensagem. Retornar nil confirma o processamento
+// e a mensagem é removida da fila; retornar erro mantém a mensagem na fila para
+// nova entrega após o tempo de visibilidade. A mesma função pode ser usada no
+// consumidor (ECS, EC2) e em handlers Lambda acionados por SQS.
+type MessageHandler func(ctx context.Context, msg coreinterfaces.Message) error
+
+// receivedMessageKey é a chave de contexto da mensagem em processamento
+type receivedMessageKey struct{}
+
+// withReceivedMessage guarda a mensagem completa no contexto do handler
+func withReceivedMessage(ctx context.Context, msg ReceivedMessage) context.Context {
+	return context.WithValue(ctx, receivedMessageKey{}, msg)
+}
+
+// ReceivedMessageFromContext retorna, dentro de um MessageHandler executado
+// pelo Consumer, a mensagem com atributos de mensagem e de sistema
+func ReceivedMessageFromContext(ctx context.Context) (ReceivedMessage, bool) {
+	msg, ok := ctx.Value(receivedMessageKey{}).(ReceivedMessage)
+	return msg, ok
+}
+
+// Valores padrão do consumidor
+const (
+	DefaultConsumerWaitTime    int32 = 20
+	DefaultConsumerMaxMessages       = 10
+	DefaultConsumerPollers           = 1
+	DefaultConsumerWorkers           = 10
+
+	consumerMaxBackoff = 30 * time.Second
+)
+
+// ConsumerOptions configura um consumidor de fila
+type ConsumerOptions struct {
+	// WaitTimeSeconds é o tempo de long polling (padrão 20, máximo 20)
+	WaitTimeSeconds int32
+	// MaxMessages é o número de mensagens por chamada (padrão 10, máximo 10)
+	MaxMessages int
+	// VisibilityTimeout substitui o tempo de visibilidade da fila, se maior que zero
+	VisibilityTimeout int32
+	// Pollers é o número de chamadas de recepção concorrentes (padrão 1)
+	Pollers int
+	// Workers é o número máximo de mensagens processadas ao mesmo tempo (padrão 10)
+	Workers int
+	// DrainTimeout limita a espera pelas mensagens em processamento no
+	// encerramento; depois dele o contexto dos handlers é cancelado. Zero espera
+	// indefinidamente.
+	DrainTimeout time.Duration
+	// ErrorHandler é chamado quando um handler falha ou entra em pânico.
+	// Se nil, o erro é registrado no log.
+	ErrorHandler func(msg coreinterfaces.Message, err error)
+	// Heartbeat, se informado, estende a visibilidade das mensagens enquanto
+	// o handler executa
+	Heartbeat *HeartbeatOptions
+}
+
+// Consumer consome uma fila SQS com long polling e um pool de workers limitado
+type Consumer struct {
+	provider  *SQSProvider
+	queueName string
+	handler   MessageHandler
+	options   ConsumerOptions
+	fifo      bool
+}
+
+// NewConsumer cria um consumidor para a fila. queueName pode ser o nome, o ARN
+// ou a URL da fila. Em filas FIFO as mensagens de um mesmo grupo são
+// processadas em sequência e, após uma falha, as seguintes do grupo são
+// devolvidas à fila sem processamento.
+func (p *SQSProvider) NewConsumer(queueName string, handler MessageHandler, options ConsumerOptions) *Consumer {
+	if options.WaitTimeSeconds <= 0 || options.WaitTimeSeconds > 20 {
+		options.WaitTimeSeconds = DefaultConsumerWaitTime
+	}
+	if options.MaxMessages <= 0 || options.MaxMessages > 10 {
+		options.MaxMessages = DefaultConsumerMaxMessages
+	}
+	if options.Pollers <= 0 {
+		options.Pollers = DefaultConsumerPollers
+	}
+	if options.Workers <= 0 {
+		options.Workers = DefaultConsumerWorkers
+	}
+	if options.Heartbeat != nil && handler != nil {
+		handler = p.HeartbeatHandler(queueName, *options.Heartbeat, handler)
+	}
+
+	return &Consumer{
+		provider:  p,
+		queueName: queueName,
+		handler:   handler,
+		options:   options,
+		fifo:      IsFIFOQueue(queueName),
+	}
+}
+
+// Run consome a fila até o contexto ser cancelado. No encerramento as
+// recepções param, as mensagens já recebidas são processadas e Run retorna
+// depois que todo o trabalho em andamento termina.
+func (c *Consumer) Run(ctx context.Context) error {
+	if c.handler == nil {
+		return fmt.Errorf("handler do consumidor não informado")
+	}
+	if _, err := c.provider.QueueURL(ctx, c.queueName); err != nil {
+		return err
+	}
+
+	// O trabalho em andamento não é cancelado junto com ctx, para que possa
+	// terminar; DrainTimeout limita essa espera
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	stopDrain := make(chan struct{})
+	defer close(stopDrain)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopDrain:
+			return
+		}
+		if c.options.DrainTimeout <= 0 {
+			return
+		}
+		select {
+		case <-time.After(c.options.DrainTimeout):
+			log.Printf("Tempo de encerramento esgotado para a fila %s, cancelando handlers", c.queueName)
+			cancelWork()
+		case <-stopDrain:
+		}
+	}()
+
+	jobs := make(chan []ReceivedMessage)
+
+	var workers sync.WaitGroup
+	for i := 0; i < c.options.Workers; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for job := range jobs {
+				c.process(workCtx, job)
+			}
+		}()
+	}
+
+	var pollers sync.WaitGroup
+	for i := 0; i < c.options.Pollers; i++ {
+		pollers.Add(1)
+		go func() {
+			defer pollers.Done()
+			c.poll(ctx, jobs)
+		}()
+	}
+
+	log.Printf("Consumidor iniciado para a fila %s (%d pollers, %d workers)", c.queueName, c.options.Pollers, c.options.Workers)
+
+	pollers.Wait()
+	close(jobs)
+	workers.Wait()
+
+	log.Printf("Consumidor da fila %s encerrado", c.queueName)
+	return nil
+}
+
+// poll recebe mensagens até o contexto ser cancelado e as envia aos workers.
+// Mensagens já recebidas são sempre entregues, mesmo durante o encerramento.
+func (c *Consumer) poll(ctx context.Context, jobs chan<- []ReceivedMessage) {
+	backoff := time.Duration(0)
+	for ctx.Err() == nil {
+		messages, err := c.provider.ReceiveMessagesWithOptions(ctx, c.queueName, ReceiveOptions{
+			MaxMessages:       c.options.MaxMessages,
+			WaitTimeSeconds:   c.options.WaitTimeSeconds,
+			VisibilityTimeout: c.options.VisibilityTimeout,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			backoff = nextBackoff(backoff)
+			log.Printf("Erro ao receber mensagens da fila %s, nova tentativa em %s: %v", c.queueName, backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		for _, job := range c.jobs(messages) {
+			jobs <- job
+		}
+	}
+}
+
+// jobs divide as mensagens recebidas em unidades de trabalho: uma por mensagem
+// em filas padrão e uma por grupo em filas FIFO
+func (c *Consumer) jobs(messages []ReceivedMessage) [][]ReceivedMessage {
+	if !c.fifo {
+		jobs := make([][]ReceivedMessage, len(messages))
+		for i := range messages {
+			jobs[i] = messages[i : i+1]
+		}
+		return jobs
+	}
+
+	var jobs [][]ReceivedMessage
+	for _, indexes := range groupIndexes(messages) {
+		job := make([]ReceivedMessage, len(indexes))
+		for i, index := range indexes {
+			job[i] = messages[index]
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// process executa o handler para cada mensagem do trabalho, em ordem,
+// removendo as que forem processadas com sucesso. Após uma falha, as
+// mensagens restantes do trabalho (mesmo grupo FIFO) não são processadas.
+func (c *Consumer) process(ctx context.Context, job []ReceivedMessage) {
+	for _, msg := range job {
+		if err := c.handle(withReceivedMessage(ctx, msg), msg.Message); err != nil {
+			c.reportError(msg.Message, err)
+			return
+		}
+
+		if err := c.provider.DeleteMessage(ctx, c.queueName, msg.ReceiptHandle); err != nil {
+			log.Printf("Erro ao remover mensagem %s da fila %s: %v", msg.ID, c.queueName, err)
+		}
+	}
+}
+
+// handle executa o handler convertendo pânicos em erro
+func (c *Consumer) handle(ctx context.Context, msg coreinterfaces.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pânico no handler: %v", r)
+		}
+	}()
+	return c.handler(ctx, msg)
+}
+
+// reportError encaminha a falha ao ErrorHandler ou ao log
+func (c *Consumer) reportError(msg coreinterfaces.Message, err error) {
+	if c.options.ErrorHandler != nil {
+		c.options.ErrorHandler(msg, err)
+		return
+	}
+	log.Printf("Erro ao processar mensagem %s da fila %s: %v", msg.ID, c.queueName, err)
+}
+
+// nextBackoff dobra a espera entre tentativas, de 1 segundo até consumerMaxBackoff
+func nextBackoff(current time.Duration) time.Duration {
+	if current <= 0 {
+		return time.Second
+	}
+	if current*2 > consumerMaxBackoff {
+		return consumerMaxBackoff
+	}
+	return current * 2
+}
+
+// retryBackoff é a espera antes da próxima tentativa após attempts falhas:
+// base na primeira, dobrada a cada falha seguinte, limitada a max
+func retryBackoff(base time.Duration, attempts int, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		return max
+	}
+	return wait
+}
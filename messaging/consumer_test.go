@@ -0,0 +1,236 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// runTestConsumer executa o consumidor em segundo plano e retorna a função
+// que o encerra e espera o retorno de Run
+func runTestConsumer(t *testing.T, consumer *Consumer) (stop func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+	return func() {
+		t.Helper()
+		cancel()
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run não retornou após o cancelamento")
+		}
+	}
+}
+
+// errorRecorder registra as falhas reportadas ao ErrorHandler
+type errorRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+	errs   []error
+	failed chan struct{}
+}
+
+func newErrorRecorder() *errorRecorder {
+	return &errorRecorder{failed: make(chan struct{}, 10)}
+}
+
+func (r *errorRecorder) handle(msg coreinterfaces.Message, err error) {
+	r.mu.Lock()
+	r.bodies = append(r.bodies, string(msg.Body))
+	r.errs = append(r.errs, err)
+	r.mu.Unlock()
+	r.failed <- struct{}{}
+}
+
+// waitFor espera um sinal no canal ou falha o teste
+func waitFor(t *testing.T, ch <-chan struct{}, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("tempo esgotado esperando %s", what)
+	}
+}
+
+func TestConsumerDeletesOnlyProcessedMessages(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("pedidos", nil)
+	sendTestMessage(t, client, queueURL, "ok", "")
+	sendTestMessage(t, client, queueURL, "falha", "")
+	p := NewSQSProviderWithClient(client, nil)
+
+	processed := make(chan struct{}, 10)
+	errs := newErrorRecorder()
+	consumer := p.NewConsumer("pedidos", func(ctx context.Context, msg coreinterfaces.Message) error {
+		if _, ok := ReceivedMessageFromContext(ctx); !ok {
+			t.Error("mensagem completa ausente do contexto do handler")
+		}
+		if string(msg.Body) == "falha" {
+			return errors.New("falha simulada")
+		}
+		processed <- struct{}{}
+		return nil
+	}, ConsumerOptions{WaitTimeSeconds: 1, ErrorHandler: errs.handle})
+
+	stop := runTestConsumer(t, consumer)
+	waitFor(t, processed, "a mensagem processada")
+	waitFor(t, errs.failed, "a falha reportada")
+	stop()
+
+	if got := client.ArrivedMessages("pedidos"); !reflect.DeepEqual(got, []string{"falha"}) {
+		t.Fatalf("apenas a mensagem com falha deveria permanecer na fila: %v", got)
+	}
+}
+
+func TestConsumerRecoversHandlerPanic(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("pedidos", nil)
+	sendTestMessage(t, client, queueURL, "pânico", "")
+	sendTestMessage(t, client, queueURL, "ok", "")
+	p := NewSQSProviderWithClient(client, nil)
+
+	processed := make(chan struct{}, 10)
+	errs := newErrorRecorder()
+	consumer := p.NewConsumer("pedidos", func(ctx context.Context, msg coreinterfaces.Message) error {
+		if string(msg.Body) == "pânico" {
+			panic("falha inesperada")
+		}
+		processed <- struct{}{}
+		return nil
+	}, ConsumerOptions{WaitTimeSeconds: 1, Workers: 1, ErrorHandler: errs.handle})
+
+	stop := runTestConsumer(t, consumer)
+	waitFor(t, errs.failed, "o pânico reportado")
+	waitFor(t, processed, "a mensagem seguinte")
+	stop()
+
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	if len(errs.errs) != 1 || !strings.Contains(errs.errs[0].Error(), "falha inesperada") {
+		t.Fatalf("erro do pânico: obtido %v", errs.errs)
+	}
+	if got := client.ArrivedMessages("pedidos"); !reflect.DeepEqual(got, []string{"pânico"}) {
+		t.Fatalf("a mensagem do pânico deveria permanecer na fila: %v", got)
+	}
+}
+
+func TestConsumerDrainsInFlightMessages(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("pedidos", nil)
+	sendTestMessage(t, client, queueURL, "lento", "")
+	p := NewSQSProviderWithClient(client, nil)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var handlerErr error
+	consumer := p.NewConsumer("pedidos", func(ctx context.Context, msg coreinterfaces.Message) error {
+		close(started)
+		<-release
+		handlerErr = ctx.Err()
+		return nil
+	}, ConsumerOptions{WaitTimeSeconds: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- consumer.Run(ctx) }()
+	waitFor(t, started, "o início do handler")
+
+	// Run espera a mensagem em processamento, cujo contexto não é cancelado
+	cancel()
+	select {
+	case <-done:
+		t.Fatal("Run retornou com mensagem em processamento")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run não retornou após o fim do processamento")
+	}
+
+	if handlerErr != nil {
+		t.Fatalf("contexto do handler cancelado durante o encerramento: %v", handlerErr)
+	}
+	if got := client.ArrivedMessages("pedidos"); len(got) != 0 {
+		t.Fatalf("mensagem processada no encerramento não foi removida: %v", got)
+	}
+}
+
+func TestConsumerDrainTimeoutCancelsHandlers(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("pedidos", nil)
+	sendTestMessage(t, client, queueURL, "travado", "")
+	p := NewSQSProviderWithClient(client, nil)
+
+	started := make(chan struct{})
+	errs := newErrorRecorder()
+	consumer := p.NewConsumer("pedidos", func(ctx context.Context, msg coreinterfaces.Message) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}, ConsumerOptions{WaitTimeSeconds: 1, DrainTimeout: 50 * time.Millisecond, ErrorHandler: errs.handle})
+
+	stop := runTestConsumer(t, consumer)
+	waitFor(t, started, "o início do handler")
+	stop()
+
+	errs.mu.Lock()
+	defer errs.mu.Unlock()
+	if len(errs.errs) != 1 || !errors.Is(errs.errs[0], context.Canceled) {
+		t.Fatalf("handler deveria ser cancelado após DrainTimeout: %v", errs.errs)
+	}
+	if got := client.ArrivedMessages("pedidos"); !reflect.DeepEqual(got, []string{"travado"}) {
+		t.Fatalf("mensagem cancelada deveria permanecer na fila: %v", got)
+	}
+}
+
+func TestConsumerProcessesFIFOGroupsInOrder(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("pedidos.fifo", map[string]string{"FifoQueue": "true", "ContentBasedDeduplication": "true"})
+	for _, body := range []string{"a1", "a2", "a3"} {
+		sendTestMessage(t, client, queueURL, body, "A")
+	}
+	p := NewSQSProviderWithClient(client, nil)
+
+	var mu sync.Mutex
+	var order []string
+	errs := newErrorRecorder()
+	consumer := p.NewConsumer("pedidos.fifo", func(ctx context.Context, msg coreinterfaces.Message) error {
+		mu.Lock()
+		order = append(order, string(msg.Body))
+		mu.Unlock()
+		if string(msg.Body) == "a2" {
+			return errors.New("falha simulada")
+		}
+		return nil
+	}, ConsumerOptions{WaitTimeSeconds: 1, VisibilityTimeout: 60, ErrorHandler: errs.handle})
+
+	stop := runTestConsumer(t, consumer)
+	waitFor(t, errs.failed, "a falha do grupo")
+	stop()
+
+	// Após a falha de a2, a3 não é processada e o grupo segue na fila
+	mu.Lock()
+	defer mu.Unlock()
+	if !reflect.DeepEqual(order, []string{"a1", "a2"}) {
+		t.Fatalf("ordem de processamento: %v", order)
+	}
+	if got := client.ArrivedMessages("pedidos.fifo"); !reflect.DeepEqual(got, []string{"a2", "a3"}) {
+		t.Fatalf("mensagens restantes do grupo: %v", got)
+	}
+}
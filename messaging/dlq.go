@@ -0,0 +1,456 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Valores padrão das ferramentas de DLQ
+const (
+	// DefaultScanVisibilityTimeout é o tempo em que as mensagens examinadas
+	// ficam invisíveis durante uma varredura
+	DefaultScanVisibilityTimeout int32 = 60
+	// scanWaitTime é o long polling de cada recepção da varredura; uma
+	// recepção vazia encerra a varredura
+	scanWaitTime int32 = 1
+)
+
+// RedrivePolicy é a política de redirecionamento de uma fila para a sua DLQ
+type RedrivePolicy struct {
+	DeadLetterTargetArn string
+	MaxReceiveCount     int
+}
+
+// MessageFilter seleciona mensagens nas operações de DLQ
+type MessageFilter func(msg ReceivedMessage) bool
+
+// AttributeFilter seleciona mensagens com o atributo de mensagem igual ao valor
+func AttributeFilter(name string, value string) MessageFilter {
+	return func(msg ReceivedMessage) bool {
+		attr, ok := msg.Attributes[name]
+		return ok && attr.StringValue == value
+	}
+}
+
+// BodyFilter seleciona mensagens pelo corpo
+func BodyFilter(predicate func(body []byte) bool) MessageFilter {
+	return func(msg ReceivedMessage) bool {
+		return predicate(msg.Body)
+	}
+}
+
+// ScanOptions configura a leitura de mensagens de uma fila sem consumi-las
+type ScanOptions struct {
+	// MaxMessages limita as mensagens selecionadas (0 = todas)
+	MaxMessages int
+	// Filter seleciona as mensagens; se nil, todas são selecionadas
+	Filter MessageFilter
+	// VisibilityTimeout é o tempo, em segundos, em que as mensagens lidas ficam
+	// invisíveis durante a operação (padrão 60). Deve cobrir toda a operação,
+	// para que a mesma mensagem não seja lida duas vezes.
+	VisibilityTimeout int32
+}
+
+// RedriveOptions configura a devolução de mensagens de uma DLQ
+type RedriveOptions struct {
+	ScanOptions
+	// Destination é a fila que recebe as mensagens (padrão: a fila de origem)
+	Destination string
+	// RatePerSecond limita as mensagens reenviadas por segundo (0 = sem limite)
+	RatePerSecond float64
+}
+
+// ArchiveOptions configura o arquivamento de mensagens no S3
+type ArchiveOptions struct {
+	ScanOptions
+	// Storage grava o arquivo; normalmente um *storage.S3Provider
+	Storage coreinterfaces.StorageProvider
+	// Bucket recebe o arquivo
+	Bucket string
+	// Key é a chave do arquivo (padrão: dlq/<fila>/<data e hora>.jsonl)
+	Key string
+}
+
+// DLQResult resume uma operação sobre a DLQ
+type DLQResult struct {
+	// Processed é o número de mensagens reenviadas ou arquivadas e removidas
+	Processed int
+	// Skipped é o número de mensagens lidas que não passaram no filtro
+	Skipped int
+	// Failed é o número de mensagens selecionadas que permaneceram na DLQ
+	Failed int
+	// Key é a chave do arquivo gravado no arquivamento
+	Key string
+}
+
+// archivedMessage é uma linha do arquivo JSON Lines
+type archivedMessage struct {
+	MessageID        string                      `json:"messageId"`
+	Body             string                      `json:"body"`
+	Attributes       map[string]MessageAttribute `json:"attributes,omitempty"`
+	SystemAttributes map[string]string           `json:"systemAttributes,omitempty"`
+	ArchivedAt       string                      `json:"archivedAt"`
+}
+
+// RedrivePolicy lê a política de redirecionamento da fila. Retorna nil se a
+// fila não tem DLQ configurada.
+func (p *SQSProvider) RedrivePolicy(ctx context.Context, queueName string) (*RedrivePolicy, error) {
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := p.client.GetQueueAttributes(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       &queueURL,
+		AttributeNames: []types.QueueAttributeName{types.QueueAttributeNameRedrivePolicy},
+	})
+	if err != nil {
+		p.checkQueueError(queueName, err)
+		return nil, fmt.Errorf("erro ao obter atributos da fila %s: %w", queueName, err)
+	}
+
+	raw, ok := output.Attributes[string(types.QueueAttributeNameRedrivePolicy)]
+	if !ok || raw == "" {
+		return nil, nil
+	}
+
+	policy, err := parseRedrivePolicy(raw)
+	if err != nil {
+		return nil, fmt.Errorf("RedrivePolicy inválida na fila %s: %w", queueName, err)
+	}
+	return policy, nil
+}
+
+// parseRedrivePolicy decodifica o atributo RedrivePolicy. maxReceiveCount pode
+// vir como número ou como texto.
+func parseRedrivePolicy(raw string) (*RedrivePolicy, error) {
+	var policy struct {
+		DeadLetterTargetArn string          `json:"deadLetterTargetArn"`
+		MaxReceiveCount     json.RawMessage `json:"maxReceiveCount"`
+	}
+	if err := json.Unmarshal([]byte(raw), &policy); err != nil {
+		return nil, err
+	}
+	maxReceiveCount, err := strconv.Atoi(strings.Trim(string(policy.MaxReceiveCount), `"`))
+	if err != nil {
+		return nil, fmt.Errorf("maxReceiveCount inválido: %s", policy.MaxReceiveCount)
+	}
+
+	return &RedrivePolicy{DeadLetterTargetArn: policy.DeadLetterTargetArn, MaxReceiveCount: maxReceiveCount}, nil
+}
+
+// DeadLetterQueue retorna o ARN da DLQ da fila, que pode ser usado como nome
+// de fila nas demais operações
+func (p *SQSProvider) DeadLetterQueue(ctx context.Context, queueName string) (string, error) {
+	policy, err := p.RedrivePolicy(ctx, queueName)
+	if err != nil {
+		return "", err
+	}
+	if policy == nil || policy.DeadLetterTargetArn == "" {
+		return "", fmt.Errorf("fila %s não tem DLQ configurada", queueName)
+	}
+	return policy.DeadLetterTargetArn, nil
+}
+
+// PeekMessages lê mensagens da fila sem removê-las. As mensagens ficam
+// invisíveis durante a leitura e são liberadas ao final. O SQS não permite
+// leitura sem recepção: o ApproximateReceiveCount de cada mensagem lida é
+// incrementado, o que pode movê-la para a DLQ se a fila tiver uma.
+func (p *SQSProvider) PeekMessages(ctx context.Context, queueName string, opts ScanOptions) ([]ReceivedMessage, error) {
+	var peeked []ReceivedMessage
+	var handles []string
+	_, err := p.scanMessages(ctx, queueName, opts, func(msg ReceivedMessage) error {
+		peeked = append(peeked, msg)
+		handles = append(handles, msg.ReceiptHandle)
+		return nil
+	})
+	p.releaseMessages(ctx, queueName, handles)
+	return peeked, err
+}
+
+// RedriveDeadLetters devolve as mensagens da DLQ de sourceQueue para a fila
+// de origem ou para opts.Destination. Cada mensagem selecionada é reenviada
+// com corpo e atributos originais e depois removida da DLQ; em destinos FIFO
+// o grupo original é mantido e o ID da mensagem é usado na deduplicação.
+func (p *SQSProvider) RedriveDeadLetters(ctx context.Context, sourceQueue string, opts RedriveOptions) (DLQResult, error) {
+	dlq, err := p.DeadLetterQueue(ctx, sourceQueue)
+	if err != nil {
+		return DLQResult{}, err
+	}
+
+	destination := opts.Destination
+	if destination == "" {
+		destination = sourceQueue
+	}
+	return p.RedriveMessages(ctx, dlq, destination, opts)
+}
+
+// RedriveMessages move as mensagens selecionadas de queueName para
+// destination, respeitando opts.RatePerSecond
+func (p *SQSProvider) RedriveMessages(ctx context.Context, queueName string, destination string, opts RedriveOptions) (DLQResult, error) {
+	limiter := newRateLimiter(opts.RatePerSecond)
+
+	var result DLQResult
+	var failed []string
+	var firstErr error
+	skipped, err := p.scanMessages(ctx, queueName, opts.ScanOptions, func(msg ReceivedMessage) error {
+		if !limiter.wait(ctx) {
+			failed = append(failed, msg.ReceiptHandle)
+			return ctx.Err()
+		}
+
+		if err := p.redrive(ctx, destination, msg); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			result.Failed++
+			failed = append(failed, msg.ReceiptHandle)
+			return nil
+		}
+
+		if err := p.DeleteMessage(ctx, queueName, msg.ReceiptHandle); err != nil {
+			// A mensagem já está no destino; a remoção é apenas registrada
+			log.Printf("Mensagem %s reenviada para %s, mas não removida de %s: %v", msg.ID, destination, queueName, err)
+		}
+		result.Processed++
+		return nil
+	})
+	result.Skipped = skipped
+
+	// Mensagens que falharam voltam a ficar visíveis na DLQ
+	p.releaseMessages(ctx, queueName, failed)
+
+	if err != nil {
+		return result, err
+	}
+	if firstErr != nil {
+		return result, fmt.Errorf("%d mensagens não foram reenviadas: %w", result.Failed, firstErr)
+	}
+	return result, nil
+}
+
+// redrive reenvia a mensagem com corpo e atributos originais
+func (p *SQSProvider) redrive(ctx context.Context, destination string, msg ReceivedMessage) error {
+	opts := SendOptions{Attributes: msg.Attributes}
+	if IsFIFOQueue(destination) {
+		opts.MessageGroupID = msg.MessageGroupID()
+		if opts.MessageGroupID == "" {
+			opts.MessageGroupID = DefaultMessageGroupID
+		}
+		opts.DeduplicationID = msg.ID
+	}
+
+	if _, err := p.sendBody(ctx, destination, nil, msg.Body, opts); err != nil {
+		return fmt.Errorf("erro ao reenviar mensagem %s para %s: %w", msg.ID, destination, err)
+	}
+	return nil
+}
+
+// ArchiveMessages grava as mensagens selecionadas no S3 como JSON Lines, uma
+// mensagem por linha, e as remove da fila depois que o arquivo foi gravado.
+// Se a gravação falhar, nenhuma mensagem é removida.
+func (p *SQSProvider) ArchiveMessages(ctx context.Context, queueName string, opts ArchiveOptions) (DLQResult, error) {
+	if opts.Storage == nil || opts.Bucket == "" {
+		return DLQResult{}, fmt.Errorf("armazenamento e bucket do arquivo não informados")
+	}
+
+	now := time.Now().UTC()
+	key := opts.Key
+	if key == "" {
+		key = fmt.Sprintf("dlq/%s/%s.jsonl", queueBaseName(queueName), now.Format("20060102T150405Z"))
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	var handles []string
+	skipped, scanErr := p.scanMessages(ctx, queueName, opts.ScanOptions, func(msg ReceivedMessage) error {
+		line := archivedMessage{
+			MessageID:        msg.ID,
+			Body:             string(msg.Body),
+			Attributes:       msg.Attributes,
+			SystemAttributes: msg.SystemAttributes,
+			ArchivedAt:       now.Format(time.RFC3339),
+		}
+		if err := encoder.Encode(line); err != nil {
+			return fmt.Errorf("erro ao serializar mensagem %s: %w", msg.ID, err)
+		}
+		handles = append(handles, msg.ReceiptHandle)
+		return nil
+	})
+
+	result := DLQResult{Skipped: skipped, Key: key}
+	if scanErr != nil || len(handles) == 0 {
+		p.releaseMessages(ctx, queueName, handles)
+		return result, scanErr
+	}
+
+	err := opts.Storage.PutItem(ctx, opts.Bucket, map[string]interface{}{
+		"Key":         key,
+		"Content":     buf.Bytes(),
+		"ContentType": "application/x-ndjson",
+	})
+	if err != nil {
+		p.releaseMessages(ctx, queueName, handles)
+		return result, fmt.Errorf("erro ao gravar arquivo s3://%s/%s: %w", opts.Bucket, key, err)
+	}
+
+	results, err := p.DeleteMessages(ctx, queueName, handles)
+	for _, r := range results {
+		if r.Err == nil {
+			result.Processed++
+		} else {
+			result.Failed++
+		}
+	}
+	return result, err
+}
+
+// scanMessages recebe mensagens até a fila não retornar mais nenhuma ou o
+// limite ser atingido, chamando fn para cada mensagem selecionada. As
+// mensagens não selecionadas são liberadas ao final; as selecionadas ficam a
+// cargo de fn. Retorna o número de mensagens não selecionadas.
+func (p *SQSProvider) scanMessages(ctx context.Context, queueName string, opts ScanOptions, fn func(ReceivedMessage) error) (int, error) {
+	visibility := opts.VisibilityTimeout
+	if visibility <= 0 {
+		visibility = DefaultScanVisibilityTimeout
+	}
+
+	var skipped []string
+	defer func() { p.releaseMessages(ctx, queueName, skipped) }()
+
+	seen := make(map[string]bool)
+	selected := 0
+	for opts.MaxMessages <= 0 || selected < opts.MaxMessages {
+		messages, err := p.ReceiveMessagesWithOptions(ctx, queueName, ReceiveOptions{
+			MaxMessages:       maxBatchEntries,
+			WaitTimeSeconds:   scanWaitTime,
+			VisibilityTimeout: visibility,
+		})
+		if err != nil {
+			return len(skipped), err
+		}
+
+		fresh := 0
+		for i, msg := range messages {
+			// Uma mensagem já vista voltou a ficar visível: a varredura deu a volta na fila
+			if seen[msg.ID] {
+				continue
+			}
+			seen[msg.ID] = true
+			fresh++
+
+			if (opts.MaxMessages > 0 && selected >= opts.MaxMessages) || (opts.Filter != nil && !opts.Filter(msg)) {
+				skipped = append(skipped, msg.ReceiptHandle)
+				continue
+			}
+			selected++
+
+			if err := fn(msg); err != nil {
+				// As mensagens restantes do lote voltam para a fila
+				for _, rest := range messages[i+1:] {
+					if !seen[rest.ID] {
+						skipped = append(skipped, rest.ReceiptHandle)
+					}
+				}
+				return len(skipped), err
+			}
+		}
+		if fresh == 0 {
+			break
+		}
+	}
+	return len(skipped), nil
+}
+
+// releaseMessages torna as mensagens visíveis novamente, em lotes de até 10
+func (p *SQSProvider) releaseMessages(ctx context.Context, queueName string, receiptHandles []string) {
+	if len(receiptHandles) == 0 {
+		return
+	}
+
+	// A liberação deve ocorrer mesmo se o contexto foi cancelado
+	ctx = context.WithoutCancel(ctx)
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		log.Printf("Erro ao liberar mensagens da fila %s: %v", queueName, err)
+		return
+	}
+
+	for start := 0; start < len(receiptHandles); start += maxBatchEntries {
+		end := start + maxBatchEntries
+		if end > len(receiptHandles) {
+			end = len(receiptHandles)
+		}
+
+		entries := make([]types.ChangeMessageVisibilityBatchRequestEntry, 0, end-start)
+		for i, receiptHandle := range receiptHandles[start:end] {
+			handle, _ := splitReceiptHandle(receiptHandle)
+			entries = append(entries, types.ChangeMessageVisibilityBatchRequestEntry{
+				Id:                aws.String(strconv.Itoa(i)),
+				ReceiptHandle:     aws.String(handle),
+				VisibilityTimeout: 0,
+			})
+		}
+
+		output, err := p.client.ChangeMessageVisibilityBatch(ctx, &sqs.ChangeMessageVisibilityBatchInput{
+			QueueUrl: &queueURL,
+			Entries:  entries,
+		})
+		if err != nil {
+			log.Printf("Erro ao liberar mensagens da fila %s: %v", queueName, err)
+			continue
+		}
+		for _, failed := range output.Failed {
+			log.Printf("Erro ao liberar mensagem da fila %s: %s: %s", queueName, aws.ToString(failed.Code), aws.ToString(failed.Message))
+		}
+	}
+}
+
+// rateLimiter espaça operações para não ultrapassar uma taxa por segundo
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+// newRateLimiter cria um limitador; taxa zero ou negativa não limita
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait espera a vez da próxima operação; retorna false se o contexto foi cancelado
+func (l *rateLimiter) wait(ctx context.Context) bool {
+	if l.interval <= 0 {
+		return ctx.Err() == nil
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(delay):
+		return true
+	}
+}
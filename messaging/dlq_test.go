@@ -0,0 +1,205 @@
+package messaging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/silviomfa/go-cloud-aws/storage"
+)
+
+// newDLQTest cria a fila "pedidos" com a DLQ "pedidos-dlq" e envia as
+// mensagens para a DLQ com o atributo "motivo"
+func newDLQTest(t *testing.T, reasons map[string]string) (*SQSProvider, *MemorySQSClient) {
+	t.Helper()
+	client, _ := newTestMemorySQS()
+	client.DefineQueue("pedidos-dlq", nil)
+	client.DefineQueue("pedidos", redrivePolicy("pedidos-dlq", 3))
+	p := NewSQSProviderWithClient(client, nil)
+
+	for _, body := range []string{"a", "b", "c"} {
+		reason, ok := reasons[body]
+		if !ok {
+			continue
+		}
+		_, err := p.SendMessageWithOptions(context.Background(), "pedidos-dlq", body, SendOptions{Attributes: map[string]MessageAttribute{
+			"motivo": StringAttribute(reason),
+		}})
+		if err != nil {
+			t.Fatalf("SendMessageWithOptions: %v", err)
+		}
+	}
+	return p, client
+}
+
+// failingStorage recusa a gravação de objetos
+type failingStorage struct {
+	*storage.S3Provider
+}
+
+func (s failingStorage) PutItem(ctx context.Context, bucketName string, item interface{}) error {
+	return errors.New("falha simulada")
+}
+
+func TestDeadLetterQueue(t *testing.T) {
+	p, _ := newDLQTest(t, nil)
+	ctx := context.Background()
+
+	dlq, err := p.DeadLetterQueue(ctx, "pedidos")
+	if err != nil || dlq != memoryQueueARN("pedidos-dlq") {
+		t.Fatalf("DeadLetterQueue: %s, %v", dlq, err)
+	}
+	if _, err := p.DeadLetterQueue(ctx, "pedidos-dlq"); err == nil {
+		t.Fatal("fila sem RedrivePolicy deveria retornar erro")
+	}
+}
+
+func TestParseRedrivePolicy(t *testing.T) {
+	for _, raw := range []string{
+		`{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:000000000000:dlq","maxReceiveCount":5}`,
+		`{"deadLetterTargetArn":"arn:aws:sqs:us-east-1:000000000000:dlq","maxReceiveCount":"5"}`,
+	} {
+		policy, err := parseRedrivePolicy(raw)
+		if err != nil || policy.MaxReceiveCount != 5 || policy.DeadLetterTargetArn != "arn:aws:sqs:us-east-1:000000000000:dlq" {
+			t.Fatalf("parseRedrivePolicy(%s): %+v, %v", raw, policy, err)
+		}
+	}
+	if _, err := parseRedrivePolicy(`{"maxReceiveCount":"cinco"}`); err == nil {
+		t.Fatal("maxReceiveCount não numérico deveria ser recusado")
+	}
+}
+
+func TestRedriveDeadLettersWithFilter(t *testing.T) {
+	p, client := newDLQTest(t, map[string]string{"a": "timeout", "b": "validacao", "c": "timeout"})
+	ctx := context.Background()
+
+	result, err := p.RedriveDeadLetters(ctx, "pedidos", RedriveOptions{
+		ScanOptions: ScanOptions{Filter: AttributeFilter("motivo", "timeout")},
+	})
+	if err != nil {
+		t.Fatalf("RedriveDeadLetters: %v", err)
+	}
+	if result.Processed != 2 || result.Skipped != 1 || result.Failed != 0 {
+		t.Fatalf("resultado: obtido %+v", result)
+	}
+
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 10})
+	if err != nil {
+		t.Fatalf("ReceiveMessagesWithOptions: %v", err)
+	}
+	var bodies []string
+	for _, msg := range received {
+		bodies = append(bodies, string(msg.Body))
+		if msg.Attributes["motivo"].StringValue != "timeout" {
+			t.Fatalf("atributos não preservados no reenvio: %v", msg.Attributes)
+		}
+	}
+	if !reflect.DeepEqual(bodies, []string{`"a"`, `"c"`}) {
+		t.Fatalf("mensagens reenviadas: %v", bodies)
+	}
+
+	// A mensagem não selecionada permanece na DLQ, visível novamente
+	if got := client.ArrivedMessages("pedidos-dlq"); !reflect.DeepEqual(got, []string{`"b"`}) {
+		t.Fatalf("mensagens restantes na DLQ: %v", got)
+	}
+	if received, _ := p.ReceiveMessages(ctx, "pedidos-dlq", 10); len(received) != 1 {
+		t.Fatal("mensagem não selecionada deveria ser liberada")
+	}
+}
+
+func TestPeekMessagesKeepsMessages(t *testing.T) {
+	p, client := newDLQTest(t, map[string]string{"a": "timeout", "b": "validacao"})
+	ctx := context.Background()
+
+	peeked, err := p.PeekMessages(ctx, "pedidos-dlq", ScanOptions{MaxMessages: 1})
+	if err != nil || len(peeked) != 1 || string(peeked[0].Body) != `"a"` {
+		t.Fatalf("PeekMessages: %v (%d mensagens)", err, len(peeked))
+	}
+
+	if got := client.ArrivedMessages("pedidos-dlq"); len(got) != 2 {
+		t.Fatalf("PeekMessages não deveria remover mensagens: %v", got)
+	}
+	if received, _ := p.ReceiveMessages(ctx, "pedidos-dlq", 10); len(received) != 2 {
+		t.Fatalf("mensagens lidas deveriam ficar visíveis novamente: %d", len(received))
+	}
+}
+
+func TestArchiveMessages(t *testing.T) {
+	p, client := newDLQTest(t, map[string]string{"a": "timeout", "b": "validacao"})
+	s3 := storage.NewMemoryS3Provider()
+	ctx := context.Background()
+
+	result, err := p.ArchiveMessages(ctx, "pedidos-dlq", ArchiveOptions{Storage: s3, Bucket: "arquivo"})
+	if err != nil {
+		t.Fatalf("ArchiveMessages: %v", err)
+	}
+	if result.Processed != 2 || result.Failed != 0 || !strings.HasPrefix(result.Key, "dlq/pedidos-dlq/") {
+		t.Fatalf("resultado: obtido %+v", result)
+	}
+	if got := client.ArrivedMessages("pedidos-dlq"); len(got) != 0 {
+		t.Fatalf("mensagens arquivadas deveriam ser removidas: %v", got)
+	}
+
+	var content []byte
+	if err := s3.GetItem(ctx, "arquivo", map[string]interface{}{"Key": result.Key}, &content); err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	var lines []archivedMessage
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		var line archivedMessage
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("linha inválida %s: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != 2 || lines[0].Body != `"a"` || lines[1].Attributes["motivo"].StringValue != "validacao" {
+		t.Fatalf("arquivo: obtido %+v", lines)
+	}
+	if lines[0].MessageID == "" || lines[0].SystemAttributes[SystemAttributeApproximateReceiveCount] == "" {
+		t.Fatalf("identificação da mensagem ausente do arquivo: %+v", lines[0])
+	}
+}
+
+func TestArchiveMessagesKeepsMessagesOnStorageFailure(t *testing.T) {
+	p, client := newDLQTest(t, map[string]string{"a": "timeout", "b": "validacao"})
+	ctx := context.Background()
+
+	result, err := p.ArchiveMessages(ctx, "pedidos-dlq", ArchiveOptions{
+		Storage: failingStorage{storage.NewMemoryS3Provider()},
+		Bucket:  "arquivo",
+		Key:     "dlq/manual.jsonl",
+	})
+	if err == nil || result.Processed != 0 || result.Key != "dlq/manual.jsonl" {
+		t.Fatalf("falha na gravação: %+v, %v", result, err)
+	}
+	if received, _ := p.ReceiveMessages(ctx, "pedidos-dlq", 10); len(received) != 2 {
+		t.Fatalf("nenhuma mensagem deveria ser removida: %d visíveis, %v na fila", len(received), client.ArrivedMessages("pedidos-dlq"))
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := newRateLimiter(100)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if !limiter.wait(ctx) {
+			t.Fatal("wait retornou false sem cancelamento")
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("3 operações a 100/s deveriam levar ao menos 20ms, levaram %v", elapsed)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if newRateLimiter(0).wait(canceled) || limiter.wait(canceled) {
+		t.Fatal("wait deveria retornar false com o contexto cancelado")
+	}
+}
@@ -0,0 +1,407 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	"github.com/silviomfa/go-cloud-aws/provider"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Valores padrão do EventBridge
+const (
+	// DefaultEventBus é o barramento padrão da conta
+	DefaultEventBus = "default"
+	// DefaultEventSource é a origem usada quando nenhuma é configurada
+	DefaultEventSource = "go-cloud-aws"
+)
+
+// ErrUnknownDetailType indica um detail-type sem tipo registrado
+var ErrUnknownDetailType = errors.New("detail-type não registrado")
+
+// EventBridgeAPI define as operações do cliente EventBridge utilizadas pelo provedor.
+// É satisfeita por *eventbridge.Client e permite substituir o cliente em testes.
+type EventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// DetailTyper é implementado por structs de detalhe que definem o próprio detail-type
+type DetailTyper interface {
+	DetailType() string
+}
+
+// EventEntry é um evento a ser publicado
+type EventEntry struct {
+	// Detail é serializado em JSON e enviado como detail do evento
+	Detail interface{}
+	// DetailType substitui o detail-type resolvido pelo registro
+	DetailType string
+	// Source substitui a origem configurada no provedor
+	Source string
+	// EventBusName substitui o barramento informado na publicação
+	EventBusName string
+	// Resources são os ARNs relacionados ao evento
+	Resources []string
+	// Time é o momento do evento; se zero, o EventBridge usa o horário da publicação
+	Time time.Time
+	// TraceHeader propaga o cabeçalho de rastreamento do X-Ray
+	TraceHeader string
+	// MessageType define o tipo usado na validação por schema; se vazio, é
+	// obtido de MessageTyper ou dos tipos registrados no SchemaRegistry
+	MessageType string
+}
+
+// DetailTypeRegistry associa structs de detalhe a valores de detail-type,
+// nos dois sentidos: na publicação e na decodificação de eventos recebidos
+type DetailTypeRegistry struct {
+	mu     sync.RWMutex
+	byType map[reflect.Type]string
+	byName map[string]reflect.Type
+}
+
+// NewDetailTypeRegistry cria um registro vazio
+func NewDetailTypeRegistry() *DetailTypeRegistry {
+	return &DetailTypeRegistry{
+		byType: make(map[reflect.Type]string),
+		byName: make(map[string]reflect.Type),
+	}
+}
+
+// Register associa o tipo de example (struct ou ponteiro para struct) ao detail-type
+func (r *DetailTypeRegistry) Register(detailType string, example interface{}) {
+	t := reflect.TypeOf(example)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byType[t] = detailType
+	r.byName[detailType] = t
+}
+
+// DetailTypeOf retorna o detail-type registrado para o tipo do detalhe
+func (r *DetailTypeRegistry) DetailTypeOf(detail interface{}) (string, bool) {
+	t := reflect.TypeOf(detail)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	detailType, ok := r.byType[t]
+	return detailType, ok
+}
+
+// Decode decodifica o detalhe em uma nova instância do tipo registrado,
+// retornando um ponteiro para ela
+func (r *DetailTypeRegistry) Decode(detailType string, data []byte) (interface{}, error) {
+	r.mu.RLock()
+	t, ok := r.byName[detailType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDetailType, detailType)
+	}
+
+	value := reflect.New(t)
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return nil, fmt.Errorf("erro ao decodificar detalhe %s: %w", detailType, err)
+	}
+	return value.Interface(), nil
+}
+
+// DecodeEvent decodifica o detalhe de um evento EventBridge convertido pelo
+// adaptador de eventos, em que Type é o detail-type e Data é o detail
+func (r *DetailTypeRegistry) DecodeEvent(event coreinterfaces.Event) (interface{}, error) {
+	return r.Decode(event.Type, event.Data)
+}
+
+// EventBridgeProvider implementa a interface coreinterfaces.MessagingProvider
+// para barramentos do EventBridge. Apenas a publicação é suportada.
+type EventBridgeProvider struct {
+	client   EventBridgeAPI
+	provider *provider.Provider
+	source   string
+	registry *DetailTypeRegistry
+	schemas  schemaHook
+}
+
+// NewEventBridgeProvider cria um novo provedor de eventos EventBridge
+func NewEventBridgeProvider(cloudProvider coreinterfaces.CloudProvider) (*EventBridgeProvider, error) {
+	awsProvider, ok := cloudProvider.(*provider.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provedor não é do tipo AWS")
+	}
+
+	awsConfig, ok := awsProvider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+
+	return NewEventBridgeProviderWithClient(eventbridge.NewFromConfig(awsConfig), awsProvider), nil
+}
+
+// NewEventBridgeProviderWithClient cria um provedor EventBridge a partir de um
+// cliente já configurado
+func NewEventBridgeProviderWithClient(client EventBridgeAPI, cloudProvider *provider.Provider) *EventBridgeProvider {
+	return &EventBridgeProvider{
+		client:   client,
+		provider: cloudProvider,
+		source:   DefaultEventSource,
+		registry: NewDetailTypeRegistry(),
+	}
+}
+
+// GetName retorna o nome do provedor
+func (p *EventBridgeProvider) GetName() string {
+	return "AWS-EventBridge"
+}
+
+// SetSource define a origem usada nos eventos sem origem própria
+func (p *EventBridgeProvider) SetSource(source string) {
+	p.source = source
+}
+
+// Registry retorna o registro de detail-types do provedor
+func (p *EventBridgeProvider) Registry() *DetailTypeRegistry {
+	return p.registry
+}
+
+// RegisterDetailType associa uma struct de detalhe a um detail-type
+func (p *EventBridgeProvider) RegisterDetailType(detailType string, example interface{}) {
+	p.registry.Register(detailType, example)
+}
+
+// SetSchemaRegistry ativa a validação por JSON Schema na publicação. Detalhes
+// com tipo conhecido (EventEntry.MessageType, MessageTyper ou RegisterType)
+// são validados contra a versão atual; os inválidos não são publicados e
+// recebem SchemaValidationError no resultado. O tipo e a versão vão no
+// detalhe, na chave reservada adapter.EventBridgeSchemaKey.
+func (p *EventBridgeProvider) SetSchemaRegistry(schemas *SchemaRegistry) {
+	p.schemas.set(schemas)
+}
+
+// SendMessage publica a mensagem como detalhe de um evento. busName é o nome
+// ou ARN do barramento; vazio usa o barramento padrão.
+func (p *EventBridgeProvider) SendMessage(ctx context.Context, busName string, message interface{}) error {
+	_, err := p.PutEvents(ctx, busName, []EventEntry{{Detail: message}})
+	return err
+}
+
+// ReceiveMessages não é suportado pelo EventBridge
+func (p *EventBridgeProvider) ReceiveMessages(ctx context.Context, busName string, maxMessages int) ([]coreinterfaces.Message, error) {
+	return nil, fmt.Errorf("EventBridge não suporta recepção de mensagens; use uma regra com destino SQS ou Lambda no barramento %s", busName)
+}
+
+// DeleteMessage não é suportado pelo EventBridge
+func (p *EventBridgeProvider) DeleteMessage(ctx context.Context, busName string, receiptHandle string) error {
+	return fmt.Errorf("EventBridge não suporta remoção de mensagens; use uma regra com destino SQS ou Lambda no barramento %s", busName)
+}
+
+// eventEntry é uma entrada de PutEvents com a posição original e o tamanho calculado
+type eventEntry struct {
+	id    string
+	entry ebtypes.PutEventsRequestEntry
+	size  int
+}
+
+// PutEvents publica eventos em lotes de até 10 entradas e 256 KB, reenviando
+// apenas as entradas que falharem por erro transitório. O resultado tem uma
+// posição por evento, com o ID atribuído pelo EventBridge.
+func (p *EventBridgeProvider) PutEvents(ctx context.Context, busName string, events []EventEntry) ([]BatchResult, error) {
+	results := newBatchResults(len(events))
+	if len(events) == 0 {
+		return results, nil
+	}
+	if busName == "" {
+		busName = DefaultEventBus
+	}
+
+	pending := make([]eventEntry, 0, len(events))
+	for i, e := range events {
+		entry, err := p.newEventEntry(busName, i, e)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		if entry.size > maxBatchPayloadSize {
+			results[i].Err = fmt.Errorf("evento %d excede o limite de %d bytes: %d bytes", i, maxBatchPayloadSize, entry.size)
+			continue
+		}
+		pending = append(pending, entry)
+	}
+
+	send := func(batch []eventEntry) (batchOutcome, error) {
+		entries := make([]ebtypes.PutEventsRequestEntry, len(batch))
+		for i, e := range batch {
+			entries[i] = e.entry
+		}
+
+		output, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+		if err != nil {
+			return batchOutcome{}, fmt.Errorf("erro ao publicar eventos: %w", err)
+		}
+
+		// As entradas da resposta seguem a ordem da requisição
+		outcome := batchOutcome{successful: make(map[string]string, len(batch))}
+		for i, result := range output.Entries {
+			if i >= len(batch) {
+				break
+			}
+			code := aws.ToString(result.ErrorCode)
+			if code == "" {
+				outcome.successful[batch[i].id] = aws.ToString(result.EventId)
+				continue
+			}
+			outcome.failed = append(outcome.failed, batchFailure{
+				id:          batch[i].id,
+				code:        code,
+				message:     aws.ToString(result.ErrorMessage),
+				senderFault: !isRetryableEventError(code),
+			})
+		}
+		return outcome, nil
+	}
+	entryID := func(e eventEntry) string { return e.id }
+
+	batches := chunkBySize(pending, maxBatchEntries, maxBatchPayloadSize, func(e eventEntry) int { return e.size })
+	runBatches(ctx, batches, true, func(batch []eventEntry) {
+		retryBatch(ctx, batch, entryID, send, results)
+	})
+
+	return results, summarizeBatch(results, "publicadas")
+}
+
+// withSchemaAttributes acrescenta ao detalhe, na chave reservada
+// adapter.EventBridgeSchemaKey, os atributos de tipo e versão do schema. O
+// EventBridge não tem atributos de mensagem; o adaptador de eventos os devolve
+// em Metadata["messageAttributes"] e remove a chave do detalhe.
+func withSchemaAttributes(detail []byte, attributes map[string]MessageAttribute) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(detail, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields[adapter.EventBridgeSchemaKey]; ok {
+		return nil, fmt.Errorf("a chave %s é reservada", adapter.EventBridgeSchemaKey)
+	}
+
+	values := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		values[k] = v.StringValue
+	}
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	// A chave é acrescentada ao fim, preservando a ordem dos demais campos
+	extended := append([]byte(nil), detail[:len(detail)-1]...)
+	if len(fields) > 0 {
+		extended = append(extended, ',')
+	}
+	extended = append(extended, `"`+adapter.EventBridgeSchemaKey+`":`...)
+	extended = append(extended, encoded...)
+	return append(extended, '}'), nil
+}
+
+// newEventEntry valida o detalhe e monta a entrada de PutEvents, resolvendo
+// detail-type, origem e barramento
+func (p *EventBridgeProvider) newEventEntry(busName string, index int, e EventEntry) (eventEntry, error) {
+	schemaAttributes, err := p.schemas.validate(e.Detail, e.MessageType, "")
+	if err != nil {
+		return eventEntry{}, err
+	}
+
+	detail, err := json.Marshal(e.Detail)
+	if err != nil {
+		return eventEntry{}, fmt.Errorf("erro ao serializar detalhe do evento %d: %w", index, err)
+	}
+	if len(detail) == 0 || detail[0] != '{' {
+		return eventEntry{}, fmt.Errorf("detalhe do evento %d deve ser um objeto JSON", index)
+	}
+	if len(schemaAttributes) > 0 {
+		if detail, err = withSchemaAttributes(detail, schemaAttributes); err != nil {
+			return eventEntry{}, fmt.Errorf("detalhe do evento %d: %w", index, err)
+		}
+	}
+
+	detailType := p.detailType(e)
+	if detailType == "" {
+		return eventEntry{}, fmt.Errorf("detail-type do evento %d não pôde ser determinado", index)
+	}
+
+	source := e.Source
+	if source == "" {
+		source = p.source
+	}
+	bus := e.EventBusName
+	if bus == "" {
+		bus = busName
+	}
+
+	entry := ebtypes.PutEventsRequestEntry{
+		Detail:       aws.String(string(detail)),
+		DetailType:   aws.String(detailType),
+		Source:       aws.String(source),
+		EventBusName: aws.String(bus),
+		Resources:    e.Resources,
+	}
+	if e.TraceHeader != "" {
+		entry.TraceHeader = aws.String(e.TraceHeader)
+	}
+
+	// Cálculo de tamanho documentado pelo EventBridge
+	size := len(detail) + len(detailType) + len(source)
+	if !e.Time.IsZero() {
+		entry.Time = aws.Time(e.Time)
+		size += 14
+	}
+	for _, resource := range e.Resources {
+		size += len(resource)
+	}
+
+	return eventEntry{id: strconv.Itoa(index), entry: entry, size: size}, nil
+}
+
+// detailType resolve o detail-type: valor explícito, método DetailType do
+// detalhe, registro e, por fim, o nome do tipo Go
+func (p *EventBridgeProvider) detailType(e EventEntry) string {
+	if e.DetailType != "" {
+		return e.DetailType
+	}
+	if typer, ok := e.Detail.(DetailTyper); ok {
+		return typer.DetailType()
+	}
+	if detailType, ok := p.registry.DetailTypeOf(e.Detail); ok {
+		return detailType
+	}
+
+	t := reflect.TypeOf(e.Detail)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.Name()
+}
+
+// isRetryableEventError informa se o código de erro de uma entrada é transitório
+func isRetryableEventError(code string) bool {
+	switch code {
+	case "InternalFailure", "InternalException", "ThrottlingException":
+		return true
+	default:
+		return false
+	}
+}
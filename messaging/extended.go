@@ -0,0 +1,209 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Constantes do formato do Amazon SQS Extended Client Library (Java), usadas
+// para que mensagens grandes sejam compartilhadas entre serviços em linguagens
+// diferentes
+const (
+	// ExtendedPayloadSizeAttribute guarda o tamanho original da mensagem
+	ExtendedPayloadSizeAttribute = "ExtendedPayloadSize"
+	// legacyPayloadSizeAttribute é o nome usado pelas versões 1.x do cliente Java
+	legacyPayloadSizeAttribute = "SQSLargePayloadSize"
+
+	payloadPointerClass = "software.amazon.payloadoffloading.PayloadS3Pointer"
+	bucketNameMarker    = "-..s3BucketName..-"
+	objectKeyMarker     = "-..s3Key..-"
+
+	// DefaultPayloadThreshold é o tamanho a partir do qual a mensagem vai para o S3
+	DefaultPayloadThreshold = maxBatchPayloadSize
+)
+
+// LargePayloadConfig configura o envio de mensagens grandes via S3
+type LargePayloadConfig struct {
+	// Storage grava e lê os corpos; normalmente um *storage.S3Provider
+	Storage coreinterfaces.StorageProvider
+	// Bucket recebe os corpos das mensagens grandes
+	Bucket string
+	// KeyPrefix é prefixado às chaves geradas
+	KeyPrefix string
+	// Threshold é o tamanho, em bytes, a partir do qual o corpo vai para o S3
+	// (padrão 256 KB)
+	Threshold int
+	// AlwaysThroughS3 envia todos os corpos para o S3, independente do tamanho
+	AlwaysThroughS3 bool
+	// KeepPayloadOnDelete mantém o objeto no S3 quando a mensagem é removida.
+	// Por padrão, como no cliente Java, o objeto é removido junto com a mensagem.
+	KeepPayloadOnDelete bool
+}
+
+// payloadPointer aponta para o corpo de uma mensagem armazenado no S3
+type payloadPointer struct {
+	Bucket string `json:"s3BucketName"`
+	Key    string `json:"s3Key"`
+}
+
+// EnableLargePayloads ativa o envio de mensagens grandes via S3. Corpos acima
+// do limite são gravados no bucket e a fila recebe um ponteiro no formato do
+// cliente Java. Na recepção os ponteiros são resolvidos automaticamente. Deve
+// ser chamado antes de o provedor ser usado.
+func (p *SQSProvider) EnableLargePayloads(config LargePayloadConfig) error {
+	if config.Storage == nil {
+		return fmt.Errorf("armazenamento para mensagens grandes não informado")
+	}
+	if config.Bucket == "" {
+		return fmt.Errorf("bucket para mensagens grandes não informado")
+	}
+	if config.Threshold <= 0 || config.Threshold > maxBatchPayloadSize {
+		config.Threshold = DefaultPayloadThreshold
+	}
+
+	p.largePayload = &config
+	return nil
+}
+
+// offloadPayload grava o corpo no S3 quando necessário, retornando o ponteiro
+// a ser enviado no lugar do corpo e os atributos acrescidos do tamanho original
+func (p *SQSProvider) offloadPayload(ctx context.Context, body []byte, attributes map[string]MessageAttribute) ([]byte, map[string]MessageAttribute, error) {
+	config := p.largePayload
+	if config == nil {
+		return body, attributes, nil
+	}
+	if _, ok := attributes[ExtendedPayloadSizeAttribute]; ok {
+		return nil, nil, fmt.Errorf("atributo %s é reservado", ExtendedPayloadSizeAttribute)
+	}
+	if !config.AlwaysThroughS3 && len(body)+attributesSize(attributes) <= config.Threshold {
+		return body, attributes, nil
+	}
+
+	key := config.KeyPrefix + uuid.New().String()
+	err := config.Storage.PutItem(ctx, config.Bucket, map[string]interface{}{
+		"Key":     key,
+		"Content": body,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao gravar mensagem grande no S3: %w", err)
+	}
+
+	pointer, err := json.Marshal([]interface{}{payloadPointerClass, payloadPointer{Bucket: config.Bucket, Key: key}})
+	if err != nil {
+		return nil, nil, fmt.Errorf("erro ao serializar ponteiro S3: %w", err)
+	}
+
+	withSize := make(map[string]MessageAttribute, len(attributes)+1)
+	for name, attr := range attributes {
+		withSize[name] = attr
+	}
+	withSize[ExtendedPayloadSizeAttribute] = NumberAttribute(len(body))
+
+	return pointer, withSize, nil
+}
+
+// resolvePayload substitui o ponteiro pelo corpo armazenado no S3 e embute o
+// ponteiro no receipt handle, para que a remoção encontre o objeto
+func (p *SQSProvider) resolvePayload(ctx context.Context, msg *ReceivedMessage) error {
+	_, hasSize := msg.Attributes[ExtendedPayloadSizeAttribute]
+	_, hasLegacySize := msg.Attributes[legacyPayloadSizeAttribute]
+	if !hasSize && !hasLegacySize {
+		return nil
+	}
+
+	pointer, err := parsePayloadPointer(msg.Body)
+	if err != nil {
+		return err
+	}
+	if p.largePayload == nil {
+		return fmt.Errorf("mensagem %s aponta para s3://%s/%s, mas mensagens grandes não estão ativadas", msg.ID, pointer.Bucket, pointer.Key)
+	}
+
+	var body []byte
+	if err := p.largePayload.Storage.GetItem(ctx, pointer.Bucket, map[string]interface{}{"Key": pointer.Key}, &body); err != nil {
+		return fmt.Errorf("erro ao obter mensagem grande s3://%s/%s: %w", pointer.Bucket, pointer.Key, err)
+	}
+
+	msg.Body = body
+	msg.ReceiptHandle = embedPointer(msg.ReceiptHandle, pointer)
+	delete(msg.Attributes, ExtendedPayloadSizeAttribute)
+	delete(msg.Attributes, legacyPayloadSizeAttribute)
+	return nil
+}
+
+// releasePayload remove o objeto S3 de uma mensagem removida da fila
+func (p *SQSProvider) releasePayload(ctx context.Context, pointer *payloadPointer) {
+	if pointer == nil || p.largePayload == nil || p.largePayload.KeepPayloadOnDelete {
+		return
+	}
+	if err := p.largePayload.Storage.DeleteItem(ctx, pointer.Bucket, map[string]interface{}{"Key": pointer.Key}); err != nil {
+		log.Printf("Erro ao remover mensagem grande s3://%s/%s: %v", pointer.Bucket, pointer.Key, err)
+	}
+}
+
+// parsePayloadPointer aceita o formato atual, ["classe", {ponteiro}], e o
+// formato das versões 1.x do cliente Java, {ponteiro}
+func parsePayloadPointer(body []byte) (*payloadPointer, error) {
+	var pointer payloadPointer
+
+	var typed []json.RawMessage
+	if err := json.Unmarshal(body, &typed); err == nil {
+		if len(typed) != 2 {
+			return nil, fmt.Errorf("ponteiro S3 inválido: %s", body)
+		}
+		if err := json.Unmarshal(typed[1], &pointer); err != nil {
+			return nil, fmt.Errorf("ponteiro S3 inválido: %w", err)
+		}
+	} else if err := json.Unmarshal(body, &pointer); err != nil {
+		return nil, fmt.Errorf("ponteiro S3 inválido: %w", err)
+	}
+
+	if pointer.Bucket == "" || pointer.Key == "" {
+		return nil, fmt.Errorf("ponteiro S3 sem bucket ou chave: %s", body)
+	}
+	return &pointer, nil
+}
+
+// embedPointer inclui bucket e chave no receipt handle, no formato do cliente Java
+func embedPointer(receiptHandle string, pointer *payloadPointer) string {
+	return bucketNameMarker + pointer.Bucket + bucketNameMarker +
+		objectKeyMarker + pointer.Key + objectKeyMarker + receiptHandle
+}
+
+// splitReceiptHandle separa o receipt handle original do ponteiro S3 embutido,
+// se houver
+func splitReceiptHandle(receiptHandle string) (string, *payloadPointer) {
+	bucket, rest, ok := cutMarked(receiptHandle, bucketNameMarker)
+	if !ok {
+		return receiptHandle, nil
+	}
+	key, original, ok := cutMarked(rest, objectKeyMarker)
+	if !ok {
+		return receiptHandle, nil
+	}
+	return original, &payloadPointer{Bucket: bucket, Key: key}
+}
+
+// cutMarked extrai o valor entre dois marcadores no início de s
+func cutMarked(s, marker string) (value string, rest string, ok bool) {
+	if !strings.HasPrefix(s, marker) {
+		return "", s, false
+	}
+	value, rest, ok = strings.Cut(s[len(marker):], marker)
+	return value, rest, ok
+}
+
+// attributesSize calcula o tamanho dos atributos como o SQS contabiliza
+func attributesSize(attributes map[string]MessageAttribute) int {
+	size := 0
+	for name, attr := range attributes {
+		size += len(name) + len(attr.DataType) + len(attr.StringValue) + len(attr.BinaryValue)
+	}
+	return size
+}
@@ -0,0 +1,168 @@
+package messaging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/silviomfa/go-cloud-aws/storage"
+)
+
+// newLargePayloadTest cria um provedor com mensagens grandes ativadas sobre
+// um S3 em memória, com limite de 100 bytes
+func newLargePayloadTest(t *testing.T, keep bool) (*SQSProvider, *MemorySQSClient, *storage.S3Provider) {
+	t.Helper()
+	client, _ := newTestMemorySQS()
+	client.DefineQueue("pedidos", nil)
+	s3 := storage.NewMemoryS3Provider()
+	p := NewSQSProviderWithClient(client, nil)
+	err := p.EnableLargePayloads(LargePayloadConfig{
+		Storage:             s3,
+		Bucket:              "payloads",
+		KeyPrefix:           "sqs/",
+		Threshold:           100,
+		KeepPayloadOnDelete: keep,
+	})
+	if err != nil {
+		t.Fatalf("EnableLargePayloads: %v", err)
+	}
+	return p, client, s3
+}
+
+// payloadExists informa se o objeto do ponteiro está no S3
+func payloadExists(s3 *storage.S3Provider, pointer *payloadPointer) bool {
+	var body []byte
+	return s3.GetItem(context.Background(), pointer.Bucket, map[string]interface{}{"Key": pointer.Key}, &body) == nil
+}
+
+func TestLargePayloadPointerRoundTrip(t *testing.T) {
+	p, client, s3 := newLargePayloadTest(t, false)
+	ctx := context.Background()
+	large := strings.Repeat("x", 200)
+
+	if err := p.SendMessage(ctx, "pedidos", large); err != nil {
+		t.Fatalf("SendMessage grande: %v", err)
+	}
+	if err := p.SendMessage(ctx, "pedidos", "pequena"); err != nil {
+		t.Fatalf("SendMessage pequena: %v", err)
+	}
+
+	// Na fila fica o ponteiro no formato do cliente Java
+	arrived := client.ArrivedMessages("pedidos")
+	if len(arrived) != 2 || arrived[1] != `"pequena"` {
+		t.Fatalf("mensagens na fila: %v", arrived)
+	}
+	if !strings.HasPrefix(arrived[0], `["`+payloadPointerClass+`",{"s3BucketName":"payloads","s3Key":"sqs/`) {
+		t.Fatalf("ponteiro fora do formato do cliente Java: %s", arrived[0])
+	}
+	pointer, err := parsePayloadPointer([]byte(arrived[0]))
+	if err != nil || !payloadExists(s3, pointer) {
+		t.Fatalf("objeto do ponteiro ausente: %v", err)
+	}
+
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 10})
+	if err != nil || len(received) != 2 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	msg := received[0]
+	if string(msg.Body) != `"`+large+`"` {
+		t.Fatalf("corpo não resolvido: %.60s", msg.Body)
+	}
+	if _, ok := msg.Attributes[ExtendedPayloadSizeAttribute]; ok {
+		t.Fatalf("atributo %s deveria ser removido na recepção", ExtendedPayloadSizeAttribute)
+	}
+	if _, embedded := splitReceiptHandle(msg.ReceiptHandle); embedded == nil || *embedded != *pointer {
+		t.Fatalf("ponteiro não embutido no receipt handle: %s", msg.ReceiptHandle)
+	}
+
+	// O receipt handle com ponteiro funciona nas demais operações
+	if err := p.ChangeVisibility(ctx, "pedidos", msg.ReceiptHandle, 60); err != nil {
+		t.Fatalf("ChangeVisibility: %v", err)
+	}
+	if err := p.DeleteMessage(ctx, "pedidos", msg.ReceiptHandle); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if payloadExists(s3, pointer) {
+		t.Fatal("objeto S3 deveria ser removido com a mensagem")
+	}
+	if got := client.ArrivedMessages("pedidos"); len(got) != 1 {
+		t.Fatalf("mensagem grande não removida da fila: %v", got)
+	}
+}
+
+func TestLargePayloadKeepOnDelete(t *testing.T) {
+	p, client, s3 := newLargePayloadTest(t, true)
+	ctx := context.Background()
+
+	if err := p.SendMessage(ctx, "pedidos", strings.Repeat("x", 200)); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	pointer, err := parsePayloadPointer([]byte(client.ArrivedMessages("pedidos")[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	if err := p.DeleteMessage(ctx, "pedidos", received[0].ReceiptHandle); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if !payloadExists(s3, pointer) {
+		t.Fatal("KeepPayloadOnDelete deveria manter o objeto S3")
+	}
+}
+
+func TestLargePayloadLegacyPointer(t *testing.T) {
+	p, client, s3 := newLargePayloadTest(t, false)
+	ctx := context.Background()
+
+	// Mensagem enviada por uma versão 1.x do cliente Java
+	err := s3.PutItem(ctx, "payloads", map[string]interface{}{"Key": "legado", "Content": []byte("corpo legado")})
+	if err != nil {
+		t.Fatalf("PutItem: %v", err)
+	}
+	_, err = client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(memoryQueueURL("pedidos")),
+		MessageBody: aws.String(`{"s3BucketName":"payloads","s3Key":"legado"}`),
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			legacyPayloadSizeAttribute: {DataType: aws.String(AttributeTypeNumber), StringValue: aws.String("12")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	received, err := p.ReceiveMessagesWithOptions(ctx, "pedidos", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+	if !bytes.Equal(received[0].Body, []byte("corpo legado")) {
+		t.Fatalf("corpo legado não resolvido: %s", received[0].Body)
+	}
+}
+
+func TestLargePayloadRejectsReservedAttribute(t *testing.T) {
+	p, _, _ := newLargePayloadTest(t, false)
+	_, err := p.SendMessageWithOptions(context.Background(), "pedidos", "x", SendOptions{Attributes: map[string]MessageAttribute{
+		ExtendedPayloadSizeAttribute: NumberAttribute(1),
+	}})
+	if err == nil {
+		t.Fatalf("atributo %s deveria ser recusado", ExtendedPayloadSizeAttribute)
+	}
+}
+
+func TestSplitReceiptHandle(t *testing.T) {
+	pointer := &payloadPointer{Bucket: "b", Key: "k/1"}
+	handle, got := splitReceiptHandle(embedPointer("rh-1", pointer))
+	if handle != "rh-1" || got == nil || *got != *pointer {
+		t.Fatalf("splitReceiptHandle: %s, %+v", handle, got)
+	}
+	if handle, got := splitReceiptHandle("rh-2"); handle != "rh-2" || got != nil {
+		t.Fatalf("receipt handle sem ponteiro: %s, %+v", handle, got)
+	}
+}
@@ -0,0 +1,220 @@
+package messaging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// DefaultMessageGroupID é o grupo usado quando nenhum extrator está configurado
+const DefaultMessageGroupID = "default"
+
+// maxBatchEntries é o número máximo de entradas em uma chamada em lote do SQS
+const maxBatchEntries = 10
+
+// ErrGroupBlocked indica que a mensagem não foi processada porque uma mensagem
+// anterior do mesmo grupo falhou. Ela volta à fila após o tempo de visibilidade.
+var ErrGroupBlocked = errors.New("mensagem não processada: falha anterior no mesmo grupo")
+
+// GroupKeyFunc extrai o MessageGroupId de uma mensagem
+type GroupKeyFunc func(message interface{}) string
+
+// DeduplicationFunc gera o MessageDeduplicationId de uma mensagem a partir do
+// valor original e do corpo já serializado
+type DeduplicationFunc func(message interface{}, body []byte) string
+
+// FIFOConfig configura o envio para uma fila FIFO
+type FIFOConfig struct {
+	// GroupKey extrai o grupo da mensagem; se nil ou vazio, usa DefaultGroupID
+	GroupKey GroupKeyFunc
+	// DefaultGroupID é o grupo padrão (DefaultMessageGroupID se vazio)
+	DefaultGroupID string
+	// Deduplication gera o ID de deduplicação; se nil, usa o hash SHA-256 do corpo
+	Deduplication DeduplicationFunc
+	// ContentBasedDeduplication indica que a fila calcula a deduplicação pelo
+	// conteúdo; nesse caso nenhum ID é enviado, a menos que informado em SendOptions
+	ContentBasedDeduplication bool
+}
+
+// ContentHashDeduplication gera o ID de deduplicação a partir do hash SHA-256 do corpo
+func ContentHashDeduplication(_ interface{}, body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// fifoRegistry guarda as configurações FIFO por nome de fila
+type fifoRegistry struct {
+	mu      sync.RWMutex
+	configs map[string]FIFOConfig
+}
+
+// get retorna a configuração da fila ou a configuração padrão
+func (r *fifoRegistry) get(queue string) FIFOConfig {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.configs[queueBaseName(queue)]
+}
+
+// set registra a configuração de uma fila
+func (r *fifoRegistry) set(queue string, config FIFOConfig) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.configs == nil {
+		r.configs = make(map[string]FIFOConfig)
+	}
+	r.configs[queueBaseName(queue)] = config
+}
+
+// groupID resolve o grupo de uma mensagem
+func (c FIFOConfig) groupID(message interface{}) string {
+	if c.GroupKey != nil {
+		if group := c.GroupKey(message); group != "" {
+			return group
+		}
+	}
+	if c.DefaultGroupID != "" {
+		return c.DefaultGroupID
+	}
+	return DefaultMessageGroupID
+}
+
+// deduplicationID resolve o ID de deduplicação de uma mensagem
+func (c FIFOConfig) deduplicationID(message interface{}, body []byte) string {
+	if c.Deduplication != nil {
+		return c.Deduplication(message, body)
+	}
+	if c.ContentBasedDeduplication {
+		return ""
+	}
+	return ContentHashDeduplication(message, body)
+}
+
+// IsFIFOQueue informa se o nome, ARN ou URL da fila corresponde a uma fila
+// FIFO. Também reconhece nomes e ARNs de tópicos SNS FIFO.
+func IsFIFOQueue(queue string) bool {
+	return strings.HasSuffix(queueBaseName(queue), ".fifo")
+}
+
+// queueBaseName normaliza nome, ARN ou URL para o nome simples da fila ou tópico
+func queueBaseName(queue string) string {
+	switch {
+	case strings.HasPrefix(queue, "https://") || strings.HasPrefix(queue, "http://"):
+		return QueueNameFromURL(queue)
+	case strings.HasPrefix(queue, "arn:"):
+		return queue[strings.LastIndex(queue, ":")+1:]
+	}
+	return queue
+}
+
+// SetFIFOConfig registra como grupos e IDs de deduplicação são gerados para
+// uma fila FIFO. Filas FIFO sem configuração usam o grupo padrão e o hash do corpo.
+func (p *SQSProvider) SetFIFOConfig(queueName string, config FIFOConfig) {
+	p.fifo.set(queueName, config)
+}
+
+// applyFIFO preenche grupo e deduplicação quando a fila é FIFO
+func (p *SQSProvider) applyFIFO(queueName string, message interface{}, body []byte, opts *SendOptions) error {
+	if IsFIFOQueue(queueName) && opts.DelaySeconds != 0 {
+		return fmt.Errorf("filas FIFO não aceitam atraso por mensagem: %s", queueName)
+	}
+	return p.fifo.apply(queueName, message, body, &opts.MessageGroupID, &opts.DeduplicationID)
+}
+
+// apply preenche grupo e deduplicação não informados pelo chamador quando o
+// destino (fila ou tópico) é FIFO, e rejeita esses campos em destinos padrão
+func (r *fifoRegistry) apply(target string, message interface{}, body []byte, groupID, deduplicationID *string) error {
+	if !IsFIFOQueue(target) {
+		if *groupID != "" || *deduplicationID != "" {
+			return fmt.Errorf("grupo e deduplicação só são aceitos em destinos FIFO: %s", target)
+		}
+		return nil
+	}
+
+	config := r.get(target)
+	if *groupID == "" {
+		*groupID = config.groupID(message)
+	}
+	if *deduplicationID == "" {
+		*deduplicationID = config.deduplicationID(message, body)
+	}
+	return nil
+}
+
+// SendFIFOMessages envia mensagens para uma fila FIFO com SendMessages,
+// que divide os lotes por número de entradas e tamanho e preserva a ordem
+// dentro de cada grupo. Retorna os IDs das mensagens enviadas, na mesma
+// posição da entrada (vazio se não enviada); use SendMessages para obter o
+// erro de cada mensagem.
+func (p *SQSProvider) SendFIFOMessages(ctx context.Context, queueName string, messages []interface{}) ([]string, error) {
+	if !IsFIFOQueue(queueName) {
+		return nil, fmt.Errorf("fila não é FIFO: %s", queueName)
+	}
+
+	results, err := p.SendMessages(ctx, queueName, messages)
+	if results == nil {
+		return nil, err
+	}
+	ids := make([]string, len(results))
+	for i, result := range results {
+		ids[i] = result.MessageID
+	}
+	return ids, err
+}
+
+// ProcessInGroupOrder processa mensagens de uma fila FIFO respeitando a ordem
+// dos grupos: grupos distintos rodam em paralelo e as mensagens de um mesmo
+// grupo rodam em sequência. Após uma falha, as mensagens seguintes do grupo não
+// são processadas e recebem ErrGroupBlocked. Retorna um erro por mensagem, na
+// mesma posição da entrada (nil em caso de sucesso).
+func ProcessInGroupOrder(ctx context.Context, messages []ReceivedMessage, handler func(context.Context, ReceivedMessage) error) []error {
+	errs := make([]error, len(messages))
+	var wg sync.WaitGroup
+	for _, indexes := range groupIndexes(messages) {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			var blocked bool
+			for _, i := range indexes {
+				if blocked {
+					errs[i] = ErrGroupBlocked
+					continue
+				}
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					blocked = true
+					continue
+				}
+				if err := handler(ctx, messages[i]); err != nil {
+					errs[i] = err
+					blocked = true
+				}
+			}
+		}(indexes)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// groupIndexes agrupa as posições das mensagens por MessageGroupId, mantendo a
+// ordem de recebimento dentro de cada grupo e a ordem de primeira ocorrência
+// entre grupos
+func groupIndexes(messages []ReceivedMessage) [][]int {
+	index := make(map[string]int)
+	var groups [][]int
+	for i, msg := range messages {
+		group := msg.MessageGroupID()
+		g, ok := index[group]
+		if !ok {
+			g = len(groups)
+			index[group] = g
+			groups = append(groups, nil)
+		}
+		groups[g] = append(groups[g], i)
+	}
+	return groups
+}
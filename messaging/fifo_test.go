@@ -0,0 +1,79 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// sizeLimitedSQSClient rejeita lotes acima de 256 KB, como o SQS
+type sizeLimitedSQSClient struct {
+	*MemorySQSClient
+}
+
+func (c *sizeLimitedSQSClient) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	size := 0
+	for _, entry := range params.Entries {
+		size += len(aws.ToString(entry.MessageBody))
+	}
+	if size > maxBatchPayloadSize {
+		return nil, &types.BatchRequestTooLong{Message: aws.String("lote excede 256 KB")}
+	}
+	return c.MemorySQSClient.SendMessageBatch(ctx, params, optFns...)
+}
+
+func TestSendFIFOMessagesSplitsBySize(t *testing.T) {
+	client := &sizeLimitedSQSClient{MemorySQSClient: NewMemorySQSClient()}
+	client.DefineQueue("uploads.fifo", map[string]string{string(types.QueueAttributeNameContentBasedDeduplication): "true"})
+	p := NewSQSProviderWithClient(client, nil)
+
+	// Dez corpos de 60 KB não cabem em um único lote
+	var messages []interface{}
+	var expected []string
+	for i := 0; i < 10; i++ {
+		body := strings.Repeat(string(rune('a'+i)), 60*1024)
+		messages = append(messages, body)
+		expected = append(expected, `"`+body+`"`)
+	}
+
+	ids, err := p.SendFIFOMessages(context.Background(), "uploads.fifo", messages)
+	if err != nil {
+		t.Fatalf("SendFIFOMessages: %v", err)
+	}
+	for i, id := range ids {
+		if id == "" {
+			t.Fatalf("mensagem %d sem ID", i)
+		}
+	}
+	if got := client.ArrivedMessages("uploads.fifo"); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("mensagens fora de ordem ou ausentes: %d recebidas", len(got))
+	}
+}
+
+func TestSendFIFOMessagesBlocksGroupAfterFailure(t *testing.T) {
+	const queue = "orders.fifo"
+	p, client := newFlakyFIFOProvider(t, queue, map[groupedMessage]int{{"A", 1}: 100})
+
+	ids, err := p.SendFIFOMessages(context.Background(), queue, []interface{}{
+		groupedMessage{"A", 1}, groupedMessage{"B", 1},
+	})
+	if err == nil {
+		t.Fatal("SendFIFOMessages deveria reportar a falha")
+	}
+	if ids[0] != "" || ids[1] == "" {
+		t.Fatalf("IDs: obtido %q", ids)
+	}
+	if got := arrivedInGroup(t, client, queue, "B"); !reflect.DeepEqual(got, []int{1}) {
+		t.Fatalf("grupo B: obtido %v", got)
+	}
+
+	if _, err := p.SendFIFOMessages(context.Background(), "orders", nil); err == nil || errors.Is(err, ErrOrderBlocked) {
+		t.Fatalf("fila padrão: esperado erro de fila não FIFO, obtido %v", err)
+	}
+}
@@ -0,0 +1,515 @@
+package messaging
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/provider"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Limites do PutRecords
+const (
+	maxKinesisBatchEntries = 500
+	maxKinesisBatchSize    = 5 * 1024 * 1024
+	maxKinesisRecordSize   = 1024 * 1024
+	maxPartitionKeyLength  = 256
+
+	// DefaultAggregationSize é o tamanho máximo de um registro agregado, o
+	// mesmo padrão da KPL
+	DefaultAggregationSize = 50 * 1024
+
+	// shardMapTTL é o tempo em que o mapa de shards usado na agregação é reaproveitado
+	shardMapTTL = time.Minute
+)
+
+// KinesisAPI define as operações do cliente Kinesis utilizadas pelo provedor.
+// É satisfeita por *kinesis.Client e permite substituir o cliente em testes.
+type KinesisAPI interface {
+	PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error)
+	ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+	GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error)
+}
+
+// PartitionKeyFunc extrai a chave de partição de uma mensagem
+type PartitionKeyFunc func(message interface{}) string
+
+// KinesisRecord é um registro a ser gravado em um stream
+type KinesisRecord struct {
+	Data []byte
+	// PartitionKey define o shard de destino; registros com a mesma chave
+	// mantêm a ordem
+	PartitionKey string
+	// ExplicitHashKey, se informado, substitui o hash da chave de partição
+	ExplicitHashKey string
+	// MessageType, com um SchemaRegistry, valida Data contra o schema da
+	// versão atual do tipo; vazio não valida
+	MessageType string
+}
+
+// AggregationConfig configura a agregação de registros no formato da KPL
+type AggregationConfig struct {
+	// MaxSize é o tamanho máximo de um registro agregado (padrão 50 KB, máximo 1 MB)
+	MaxSize int
+}
+
+// KinesisProvider implementa a interface coreinterfaces.MessagingProvider
+// para streams do Kinesis Data Streams. A recepção é feita pelo KinesisConsumer.
+type KinesisProvider struct {
+	client       KinesisAPI
+	provider     *provider.Provider
+	partitionKey PartitionKeyFunc
+	aggregation  *AggregationConfig
+	schemas      schemaHook
+
+	mu        sync.Mutex
+	shardMaps map[string]*shardMap
+}
+
+// shardMap guarda as faixas de hash dos shards abertos de um stream
+type shardMap struct {
+	expires time.Time
+	shards  []shardRange
+}
+
+// shardRange é a faixa de hash de um shard
+type shardRange struct {
+	id    string
+	start *big.Int
+	end   *big.Int
+}
+
+// NewKinesisProvider cria um novo provedor Kinesis
+func NewKinesisProvider(cloudProvider coreinterfaces.CloudProvider) (*KinesisProvider, error) {
+	awsProvider, ok := cloudProvider.(*provider.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provedor não é do tipo AWS")
+	}
+
+	awsConfig, ok := awsProvider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+
+	return NewKinesisProviderWithClient(kinesis.NewFromConfig(awsConfig), awsProvider), nil
+}
+
+// NewKinesisProviderWithClient cria um provedor Kinesis a partir de um
+// cliente já configurado
+func NewKinesisProviderWithClient(client KinesisAPI, cloudProvider *provider.Provider) *KinesisProvider {
+	return &KinesisProvider{
+		client:    client,
+		provider:  cloudProvider,
+		shardMaps: make(map[string]*shardMap),
+	}
+}
+
+// GetName retorna o nome do provedor
+func (p *KinesisProvider) GetName() string {
+	return "AWS-Kinesis"
+}
+
+// SetPartitionKeyFunc define como a chave de partição é extraída das
+// mensagens. Sem extrator, ou quando ele retorna vazio, é usada uma chave
+// aleatória e os registros são distribuídos entre os shards.
+func (p *KinesisProvider) SetPartitionKeyFunc(fn PartitionKeyFunc) {
+	p.partitionKey = fn
+}
+
+// EnableAggregation ativa a agregação de registros pequenos no formato da
+// KPL, reduzindo o número de registros gravados. Os registros são agrupados
+// por shard de destino, preservando o roteamento das chaves de partição. O
+// KinesisConsumer, a KCL e o adaptador de eventos Lambda desagregam os registros.
+func (p *KinesisProvider) EnableAggregation(config AggregationConfig) {
+	if config.MaxSize <= 0 || config.MaxSize > maxKinesisRecordSize {
+		config.MaxSize = DefaultAggregationSize
+	}
+	p.aggregation = &config
+}
+
+// SetSchemaRegistry ativa a validação por JSON Schema na gravação. Em
+// SendMessages o tipo vem de MessageTyper ou RegisterType; em PutRecords, de
+// KinesisRecord.MessageType. Registros inválidos não são gravados e recebem
+// SchemaValidationError, bloqueando os seguintes da mesma chave de partição.
+func (p *KinesisProvider) SetSchemaRegistry(schemas *SchemaRegistry) {
+	p.schemas.set(schemas)
+}
+
+// SendMessage serializa a mensagem em JSON e a grava no stream. streamName
+// pode ser o nome ou o ARN do stream.
+func (p *KinesisProvider) SendMessage(ctx context.Context, streamName string, message interface{}) error {
+	_, err := p.SendMessages(ctx, streamName, []interface{}{message})
+	return err
+}
+
+// ReceiveMessages não é suportado diretamente; use NewConsumer
+func (p *KinesisProvider) ReceiveMessages(ctx context.Context, streamName string, maxMessages int) ([]coreinterfaces.Message, error) {
+	return nil, fmt.Errorf("Kinesis não suporta recepção avulsa de mensagens; use NewConsumer para o stream %s", streamName)
+}
+
+// DeleteMessage não é suportado pelo Kinesis
+func (p *KinesisProvider) DeleteMessage(ctx context.Context, streamName string, receiptHandle string) error {
+	return fmt.Errorf("Kinesis não suporta remoção de mensagens; o progresso é registrado por checkpoint no stream %s", streamName)
+}
+
+// SendMessages serializa as mensagens em JSON e as grava com PutRecords,
+// usando o extrator de chave de partição configurado
+func (p *KinesisProvider) SendMessages(ctx context.Context, streamName string, messages []interface{}) ([]BatchResult, error) {
+	results := newBatchResults(len(messages))
+	records := make([]KinesisRecord, 0, len(messages))
+	positions := make([]int, 0, len(messages))
+	for i, message := range messages {
+		data, err := json.Marshal(message)
+		if err != nil {
+			results[i].Err = fmt.Errorf("erro ao serializar mensagem %d: %w", i, err)
+			continue
+		}
+		records = append(records, KinesisRecord{
+			Data:         data,
+			PartitionKey: p.partitionKeyOf(message),
+			MessageType:  p.schemas.messageTypeOf(message),
+		})
+		positions = append(positions, i)
+	}
+
+	written, err := p.PutRecords(ctx, streamName, records)
+	if written == nil {
+		return nil, err
+	}
+	for i, result := range written {
+		results[positions[i]].MessageID = result.MessageID
+		results[positions[i]].Err = result.Err
+	}
+	return results, summarizeBatch(results, "gravadas")
+}
+
+// PutRecords grava registros em lotes de até 500 registros e 5 MB, reenviando
+// apenas os registros que falharem (por exemplo, por limite de vazão do
+// shard). O resultado tem uma posição por registro, com o número de sequência
+// atribuído. Com a agregação ativa, registros de um mesmo agregado
+// compartilham o número de sequência. Os lotes são enviados em sequência para
+// preservar a ordem por chave de partição: um registro com falha só é
+// reenviado se nenhum registro posterior com a mesma chave tiver sido aceito,
+// e depois que uma chave desiste os registros seguintes dela não são gravados
+// e recebem ErrOrderBlocked.
+func (p *KinesisProvider) PutRecords(ctx context.Context, streamName string, records []KinesisRecord) ([]BatchResult, error) {
+	results := newBatchResults(len(records))
+	if len(records) == 0 {
+		return results, nil
+	}
+
+	// Os registros são copiados para não alterar o slice do chamador
+	records = append([]KinesisRecord(nil), records...)
+	valid := make([]int, 0, len(records))
+	failedKeys := make(map[string]bool)
+	for i := range records {
+		if records[i].PartitionKey == "" {
+			records[i].PartitionKey = uuid.New().String()
+		}
+		if failedKeys[records[i].PartitionKey] {
+			results[i].Err = ErrOrderBlocked
+			continue
+		}
+		if err := validateKinesisRecord(i, records[i]); err != nil {
+			results[i].Err = err
+			failedKeys[records[i].PartitionKey] = true
+			continue
+		}
+		if err := p.schemas.validateData(records[i].MessageType, records[i].Data); err != nil {
+			results[i].Err = err
+			failedKeys[records[i].PartitionKey] = true
+			continue
+		}
+		valid = append(valid, i)
+	}
+
+	// Cada unidade é um registro gravado: avulso ou agregado
+	units := p.buildUnits(ctx, streamName, records, valid)
+
+	unitResults := newBatchResults(len(units))
+	send := func(batch []kinesisUnit) (batchOutcome, error) {
+		entries := make([]kinesistypes.PutRecordsRequestEntry, len(batch))
+		for i, unit := range batch {
+			entries[i] = unit.entry
+		}
+
+		input := &kinesis.PutRecordsInput{Records: entries}
+		input.StreamName, input.StreamARN = streamRef(streamName)
+		output, err := p.client.PutRecords(ctx, input)
+		if err != nil {
+			return batchOutcome{}, fmt.Errorf("erro ao gravar registros no stream %s: %w", streamName, err)
+		}
+
+		// Os resultados seguem a ordem da requisição
+		outcome := batchOutcome{successful: make(map[string]string, len(batch))}
+		for i, result := range output.Records {
+			if i >= len(batch) {
+				break
+			}
+			code := aws.ToString(result.ErrorCode)
+			if code == "" {
+				outcome.successful[batch[i].id] = aws.ToString(result.SequenceNumber)
+				continue
+			}
+			// ProvisionedThroughputExceededException e InternalFailure são transitórios
+			outcome.failed = append(outcome.failed, batchFailure{
+				id:      batch[i].id,
+				code:    code,
+				message: aws.ToString(result.ErrorMessage),
+			})
+		}
+		return outcome, nil
+	}
+	entryID := func(unit kinesisUnit) string { return unit.id }
+	// Um agregado carrega as chaves de todos os registros que contém
+	partitionKeys := func(unit kinesisUnit) []string {
+		keys := make([]string, len(unit.indexes))
+		for i, index := range unit.indexes {
+			keys[i] = records[index].PartitionKey
+		}
+		return keys
+	}
+
+	batches := chunkBySize(units, maxKinesisBatchEntries, maxKinesisBatchSize, func(unit kinesisUnit) int { return unit.size })
+	retryOrderedBatches(ctx, batches, entryID, partitionKeys, send, unitResults)
+
+	for i, unit := range units {
+		for _, index := range unit.indexes {
+			results[index].MessageID = unitResults[i].MessageID
+			results[index].Err = unitResults[i].Err
+		}
+	}
+
+	return results, summarizeBatch(results, "gravadas")
+}
+
+// kinesisUnit é um registro a ser gravado, com as posições dos registros de
+// usuário que ele contém
+type kinesisUnit struct {
+	id      string
+	entry   kinesistypes.PutRecordsRequestEntry
+	size    int
+	indexes []int
+}
+
+// buildUnits monta os registros a gravar. Sem agregação, cada registro é uma
+// unidade; com agregação, registros destinados ao mesmo shard são agregados
+// até o tamanho máximo.
+func (p *KinesisProvider) buildUnits(ctx context.Context, streamName string, records []KinesisRecord, valid []int) []kinesisUnit {
+	var units []kinesisUnit
+	single := func(index int) {
+		units = append(units, newKinesisUnit(len(units), records[index], []int{index}))
+	}
+
+	if p.aggregation == nil {
+		for _, index := range valid {
+			single(index)
+		}
+		return units
+	}
+
+	// Sem o mapa de shards, os registros são agrupados pela chave de partição
+	shards, err := p.shardRanges(ctx, streamName)
+	if err != nil {
+		log.Printf("Mapa de shards indisponível para agregação no stream %s, agrupando por chave de partição: %v", streamName, err)
+	}
+
+	aggregates := make(map[string]*kplAggregate)
+	var order []string
+	flush := func(key string) {
+		aggregate := aggregates[key]
+		delete(aggregates, key)
+		if len(aggregate.indexes) == 1 {
+			single(aggregate.indexes[0])
+			return
+		}
+		first := records[aggregate.indexes[0]]
+		units = append(units, newKinesisUnit(len(units), KinesisRecord{
+			Data:            aggregate.bytes(),
+			PartitionKey:    first.PartitionKey,
+			ExplicitHashKey: first.ExplicitHashKey,
+		}, aggregate.indexes))
+	}
+
+	for _, index := range valid {
+		record := records[index]
+		key := record.PartitionKey
+		if shards != nil {
+			if shardID, ok := shardFor(shards, record); ok {
+				key = shardID
+			}
+		}
+
+		aggregate, ok := aggregates[key]
+		if ok && aggregate.sizeWith(record) > p.aggregation.MaxSize {
+			flush(key)
+			ok = false
+		}
+		if !ok {
+			aggregate = newKPLAggregate()
+			aggregates[key] = aggregate
+			order = append(order, key)
+		}
+		aggregate.add(index, record)
+	}
+
+	for _, key := range order {
+		if _, ok := aggregates[key]; ok {
+			flush(key)
+		}
+	}
+	return units
+}
+
+// newKinesisUnit monta a entrada de PutRecords de uma unidade
+func newKinesisUnit(id int, record KinesisRecord, indexes []int) kinesisUnit {
+	entry := kinesistypes.PutRecordsRequestEntry{
+		Data:         record.Data,
+		PartitionKey: aws.String(record.PartitionKey),
+	}
+	if record.ExplicitHashKey != "" {
+		entry.ExplicitHashKey = aws.String(record.ExplicitHashKey)
+	}
+	return kinesisUnit{
+		id:      strconv.Itoa(id),
+		entry:   entry,
+		size:    len(record.Data) + len(record.PartitionKey),
+		indexes: indexes,
+	}
+}
+
+// validateKinesisRecord verifica os limites de chave e tamanho de um registro
+func validateKinesisRecord(index int, record KinesisRecord) error {
+	if len(record.PartitionKey) > maxPartitionKeyLength {
+		return fmt.Errorf("chave de partição do registro %d excede %d caracteres", index, maxPartitionKeyLength)
+	}
+	if size := len(record.Data) + len(record.PartitionKey); size > maxKinesisRecordSize {
+		return fmt.Errorf("registro %d excede o limite de %d bytes: %d bytes", index, maxKinesisRecordSize, size)
+	}
+	if record.ExplicitHashKey != "" {
+		if _, ok := new(big.Int).SetString(record.ExplicitHashKey, 10); !ok {
+			return fmt.Errorf("explicit hash key inválida no registro %d: %s", index, record.ExplicitHashKey)
+		}
+	}
+	return nil
+}
+
+// partitionKeyOf extrai a chave de partição da mensagem, se houver extrator
+func (p *KinesisProvider) partitionKeyOf(message interface{}) string {
+	if p.partitionKey == nil {
+		return ""
+	}
+	return p.partitionKey(message)
+}
+
+// shardRanges retorna as faixas de hash dos shards abertos do stream, usando
+// o cache enquanto ele for válido
+func (p *KinesisProvider) shardRanges(ctx context.Context, streamName string) ([]shardRange, error) {
+	p.mu.Lock()
+	cached, ok := p.shardMaps[streamName]
+	p.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.shards, nil
+	}
+
+	shards, err := p.ListShards(ctx, streamName)
+	if err != nil {
+		return nil, err
+	}
+
+	var ranges []shardRange
+	for _, shard := range shards {
+		if shard.SequenceNumberRange != nil && shard.SequenceNumberRange.EndingSequenceNumber != nil {
+			continue
+		}
+		if shard.HashKeyRange == nil {
+			continue
+		}
+		start, okStart := new(big.Int).SetString(aws.ToString(shard.HashKeyRange.StartingHashKey), 10)
+		end, okEnd := new(big.Int).SetString(aws.ToString(shard.HashKeyRange.EndingHashKey), 10)
+		if !okStart || !okEnd {
+			continue
+		}
+		ranges = append(ranges, shardRange{id: aws.ToString(shard.ShardId), start: start, end: end})
+	}
+
+	p.mu.Lock()
+	p.shardMaps[streamName] = &shardMap{expires: time.Now().Add(shardMapTTL), shards: ranges}
+	p.mu.Unlock()
+	return ranges, nil
+}
+
+// ListShards lista todos os shards do stream, inclusive os fechados por
+// resharding que ainda estão dentro do período de retenção
+func (p *KinesisProvider) ListShards(ctx context.Context, streamName string) ([]kinesistypes.Shard, error) {
+	var shards []kinesistypes.Shard
+	input := &kinesis.ListShardsInput{}
+	input.StreamName, input.StreamARN = streamRef(streamName)
+
+	for {
+		output, err := p.client.ListShards(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar shards do stream %s: %w", streamName, err)
+		}
+		shards = append(shards, output.Shards...)
+		if output.NextToken == nil {
+			return shards, nil
+		}
+		// NextToken não pode ser combinado com o nome do stream
+		input = &kinesis.ListShardsInput{NextToken: output.NextToken}
+	}
+}
+
+// shardFor encontra o shard aberto que recebe o registro
+func shardFor(shards []shardRange, record KinesisRecord) (string, bool) {
+	hash, ok := recordHashKey(record)
+	if !ok {
+		return "", false
+	}
+	for _, shard := range shards {
+		if hash.Cmp(shard.start) >= 0 && hash.Cmp(shard.end) <= 0 {
+			return shard.id, true
+		}
+	}
+	return "", false
+}
+
+// recordHashKey calcula a hash key do registro: a explicit hash key ou o MD5
+// da chave de partição como inteiro de 128 bits, como faz o Kinesis
+func recordHashKey(record KinesisRecord) (*big.Int, bool) {
+	if record.ExplicitHashKey != "" {
+		return new(big.Int).SetString(record.ExplicitHashKey, 10)
+	}
+	sum := md5.Sum([]byte(record.PartitionKey))
+	return new(big.Int).SetBytes(sum[:]), true
+}
+
+// streamRef separa o identificador do stream em nome ou ARN, como esperado
+// pelas operações do Kinesis
+func streamRef(stream string) (name *string, arn *string) {
+	if strings.HasPrefix(stream, "arn:") {
+		return nil, aws.String(stream)
+	}
+	return aws.String(stream), nil
+}
+
+// isKinesisThrottled informa se o erro indica limite de vazão excedido
+func isKinesisThrottled(err error) bool {
+	var throughput *kinesistypes.ProvisionedThroughputExceededException
+	var limit *kinesistypes.LimitExceededException
+	return errors.As(err, &throughput) || errors.As(err, &limit)
+}
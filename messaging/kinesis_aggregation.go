@@ -0,0 +1,49 @@
+package messaging
+
+import (
+	"github.com/silviomfa/go-cloud-aws/internal/kpl"
+)
+
+// DeaggregatedRecord é um registro de usuário extraído de um registro agregado
+type DeaggregatedRecord = kpl.Record
+
+// IsAggregatedRecord informa se os dados estão no formato de agregação da KPL
+func IsAggregatedRecord(data []byte) bool {
+	return kpl.IsAggregated(data)
+}
+
+// Deaggregate extrai os registros de usuário de um registro agregado pela KPL.
+// Dados que não estão no formato agregado, ou cujo MD5 não confere, são
+// retornados como um único registro com a chave de partição informada, como
+// faz a KCL.
+func Deaggregate(partitionKey string, data []byte) ([]DeaggregatedRecord, error) {
+	return kpl.Deaggregate(partitionKey, data)
+}
+
+// kplAggregate acumula registros de usuário em um registro agregado,
+// guardando a posição de cada um na entrada do chamador
+type kplAggregate struct {
+	aggregate *kpl.Aggregate
+	indexes   []int
+}
+
+// newKPLAggregate cria um agregado vazio
+func newKPLAggregate() *kplAggregate {
+	return &kplAggregate{aggregate: kpl.NewAggregate()}
+}
+
+// sizeWith estima o tamanho do agregado com mais um registro
+func (a *kplAggregate) sizeWith(record KinesisRecord) int {
+	return a.aggregate.SizeWith(record.PartitionKey, record.ExplicitHashKey, record.Data)
+}
+
+// add inclui um registro de usuário; index é sua posição na entrada do chamador
+func (a *kplAggregate) add(index int, record KinesisRecord) {
+	a.aggregate.Add(record.PartitionKey, record.ExplicitHashKey, record.Data)
+	a.indexes = append(a.indexes, index)
+}
+
+// bytes serializa o agregado no formato da KPL
+func (a *kplAggregate) bytes() []byte {
+	return a.aggregate.Bytes()
+}
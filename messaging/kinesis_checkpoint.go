@@ -0,0 +1,226 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ErrLeaseLost indica que outro consumidor assumiu o shard
+var ErrLeaseLost = errors.New("posse do shard perdida")
+
+// Atributos da tabela de checkpoints. A chave de partição, do tipo string,
+// deve se chamar "shardKey".
+const (
+	checkpointKeyAttribute         = "shardKey"
+	checkpointSequenceAttribute    = "sequenceNumber"
+	checkpointSubSequenceAttribute = "subSequenceNumber"
+	checkpointFinishedAttribute    = "finished"
+	checkpointOwnerAttribute       = "leaseOwner"
+	checkpointExpiresAttribute     = "leaseExpires"
+)
+
+// ShardCheckpoint é o progresso registrado de um shard
+type ShardCheckpoint struct {
+	// SequenceNumber é o último registro processado; vazio se nenhum foi
+	SequenceNumber string
+	// SubSequenceNumber é a posição dentro de um registro agregado
+	SubSequenceNumber int64
+	// Finished indica que o shard foi fechado e todos os registros processados
+	Finished bool
+}
+
+// Checkpointer registra o progresso dos shards e controla a posse (lease)
+// de cada shard, para que vários consumidores da mesma aplicação dividam o
+// stream sem processar o mesmo shard ao mesmo tempo
+type Checkpointer interface {
+	// Claim tenta obter a posse do shard por leaseDuration. Retorna false se
+	// outro consumidor tem a posse válida.
+	Claim(ctx context.Context, shardID, owner string, leaseDuration time.Duration) (ShardCheckpoint, bool, error)
+	// Checkpoint grava o progresso e renova a posse. Retorna ErrLeaseLost se a
+	// posse passou para outro consumidor.
+	Checkpoint(ctx context.Context, shardID, owner string, checkpoint ShardCheckpoint, leaseDuration time.Duration) error
+	// Release libera a posse sem alterar o progresso
+	Release(ctx context.Context, shardID, owner string) error
+	// Get lê o progresso de um shard sem obter a posse
+	Get(ctx context.Context, shardID string) (ShardCheckpoint, error)
+}
+
+// DynamoDBCheckpointAPI define as operações do cliente DynamoDB utilizadas
+// pelo DynamoDBCheckpointer. É satisfeita por *dynamodb.Client.
+type DynamoDBCheckpointAPI interface {
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// DynamoDBCheckpointer guarda checkpoints e leases em uma tabela DynamoDB,
+// com escritas condicionais para garantir um único dono por shard
+type DynamoDBCheckpointer struct {
+	client    DynamoDBCheckpointAPI
+	tableName string
+	namespace string
+}
+
+// NewDynamoDBCheckpointer cria um checkpointer na tabela informada. namespace
+// separa os checkpoints de cada aplicação e stream na mesma tabela, por
+// exemplo "faturamento/pedidos".
+func NewDynamoDBCheckpointer(client DynamoDBCheckpointAPI, tableName string, namespace string) *DynamoDBCheckpointer {
+	return &DynamoDBCheckpointer{
+		client:    client,
+		tableName: tableName,
+		namespace: namespace,
+	}
+}
+
+// NewDynamoDBCheckpointer cria um checkpointer DynamoDB com a configuração
+// AWS do provedor
+func (p *KinesisProvider) NewDynamoDBCheckpointer(tableName string, namespace string) (*DynamoDBCheckpointer, error) {
+	if p.provider == nil {
+		return nil, fmt.Errorf("provedor AWS não configurado")
+	}
+	awsConfig, ok := p.provider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+	return NewDynamoDBCheckpointer(dynamodb.NewFromConfig(awsConfig), tableName, namespace), nil
+}
+
+// Claim obtém a posse se o shard não tem dono, se o dono é o mesmo ou se a
+// posse anterior expirou
+func (c *DynamoDBCheckpointer) Claim(ctx context.Context, shardID, owner string, leaseDuration time.Duration) (ShardCheckpoint, bool, error) {
+	now := time.Now()
+	output, err := c.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(c.tableName),
+		Key:                 c.key(shardID),
+		UpdateExpression:    aws.String("SET #owner = :owner, #expires = :expires"),
+		ConditionExpression: aws.String("attribute_not_exists(#owner) OR #owner = :owner OR #expires < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner":   checkpointOwnerAttribute,
+			"#expires": checkpointExpiresAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":owner":   &ddbtypes.AttributeValueMemberS{Value: owner},
+			":expires": epochMillisValue(now.Add(leaseDuration)),
+			":now":     epochMillisValue(now),
+		},
+		ReturnValues: ddbtypes.ReturnValueAllNew,
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return ShardCheckpoint{}, false, nil
+		}
+		return ShardCheckpoint{}, false, fmt.Errorf("erro ao obter posse do shard %s: %w", shardID, err)
+	}
+	return checkpointFromItem(output.Attributes), true, nil
+}
+
+// Checkpoint grava o progresso, desde que owner ainda tenha a posse
+func (c *DynamoDBCheckpointer) Checkpoint(ctx context.Context, shardID, owner string, checkpoint ShardCheckpoint, leaseDuration time.Duration) error {
+	update := "SET #finished = :finished, #expires = :expires"
+	values := map[string]ddbtypes.AttributeValue{
+		":owner":    &ddbtypes.AttributeValueMemberS{Value: owner},
+		":finished": &ddbtypes.AttributeValueMemberBOOL{Value: checkpoint.Finished},
+		":expires":  epochMillisValue(time.Now().Add(leaseDuration)),
+	}
+	names := map[string]string{
+		"#owner":    checkpointOwnerAttribute,
+		"#finished": checkpointFinishedAttribute,
+		"#expires":  checkpointExpiresAttribute,
+	}
+	if checkpoint.SequenceNumber != "" {
+		update += ", #sequence = :sequence, #sub = :sub"
+		names["#sequence"] = checkpointSequenceAttribute
+		names["#sub"] = checkpointSubSequenceAttribute
+		values[":sequence"] = &ddbtypes.AttributeValueMemberS{Value: checkpoint.SequenceNumber}
+		values[":sub"] = &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(checkpoint.SubSequenceNumber, 10)}
+	}
+
+	_, err := c.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(c.tableName),
+		Key:                       c.key(shardID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return fmt.Errorf("%w: %s", ErrLeaseLost, shardID)
+		}
+		return fmt.Errorf("erro ao gravar checkpoint do shard %s: %w", shardID, err)
+	}
+	return nil
+}
+
+// Release remove a posse, desde que owner ainda a tenha
+func (c *DynamoDBCheckpointer) Release(ctx context.Context, shardID, owner string) error {
+	_, err := c.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(c.tableName),
+		Key:                 c.key(shardID),
+		UpdateExpression:    aws.String("REMOVE #owner, #expires"),
+		ConditionExpression: aws.String("#owner = :owner"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner":   checkpointOwnerAttribute,
+			"#expires": checkpointExpiresAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":owner": &ddbtypes.AttributeValueMemberS{Value: owner},
+		},
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return fmt.Errorf("erro ao liberar shard %s: %w", shardID, err)
+	}
+	return nil
+}
+
+// Get lê o progresso do shard com leitura consistente
+func (c *DynamoDBCheckpointer) Get(ctx context.Context, shardID string) (ShardCheckpoint, error) {
+	output, err := c.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(c.tableName),
+		Key:            c.key(shardID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return ShardCheckpoint{}, fmt.Errorf("erro ao ler checkpoint do shard %s: %w", shardID, err)
+	}
+	return checkpointFromItem(output.Item), nil
+}
+
+// key monta a chave do item do shard
+func (c *DynamoDBCheckpointer) key(shardID string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		checkpointKeyAttribute: &ddbtypes.AttributeValueMemberS{Value: c.namespace + "/" + shardID},
+	}
+}
+
+// checkpointFromItem converte o item da tabela em checkpoint
+func checkpointFromItem(item map[string]ddbtypes.AttributeValue) ShardCheckpoint {
+	var checkpoint ShardCheckpoint
+	if v, ok := item[checkpointSequenceAttribute].(*ddbtypes.AttributeValueMemberS); ok {
+		checkpoint.SequenceNumber = v.Value
+	}
+	if v, ok := item[checkpointSubSequenceAttribute].(*ddbtypes.AttributeValueMemberN); ok {
+		checkpoint.SubSequenceNumber, _ = strconv.ParseInt(v.Value, 10, 64)
+	}
+	if v, ok := item[checkpointFinishedAttribute].(*ddbtypes.AttributeValueMemberBOOL); ok {
+		checkpoint.Finished = v.Value
+	}
+	return checkpoint
+}
+
+// epochMillisValue representa um instante como número em milissegundos
+func epochMillisValue(t time.Time) ddbtypes.AttributeValue {
+	return &ddbtypes.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+}
+
+// isConditionalCheckFailed informa se a escrita condicional foi rejeitada
+func isConditionalCheckFailed(err error) bool {
+	var failed *ddbtypes.ConditionalCheckFailedException
+	return errors.As(err, &failed)
+}
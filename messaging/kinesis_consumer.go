@@ -0,0 +1,455 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/google/uuid"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Valores padrão do consumidor Kinesis
+const (
+	DefaultKinesisBatchSize         int32 = 100
+	DefaultKinesisPollInterval            = time.Second
+	DefaultKinesisShardSyncInterval       = 30 * time.Second
+	DefaultKinesisLeaseDuration           = 30 * time.Second
+	DefaultKinesisMaxRetries              = 3
+)
+
+// KinesisConsumerOptions configura um consumidor de stream
+type KinesisConsumerOptions struct {
+	// Checkpointer registra o progresso e a posse dos shards (obrigatório);
+	// normalmente um *DynamoDBCheckpointer
+	Checkpointer Checkpointer
+	// StartPosition é a posição inicial de shards sem checkpoint:
+	// TRIM_HORIZON (padrão) ou LATEST. Shards filhos de um resharding sempre
+	// começam do início.
+	StartPosition kinesistypes.ShardIteratorType
+	// BatchSize é o número máximo de registros por GetRecords (padrão 100)
+	BatchSize int32
+	// PollInterval é a espera entre leituras de um shard (padrão 1 segundo)
+	PollInterval time.Duration
+	// ShardSyncInterval é o intervalo de descoberta de shards (padrão 30 segundos)
+	ShardSyncInterval time.Duration
+	// LeaseDuration é a validade da posse de um shard sem renovação (padrão 30 segundos)
+	LeaseDuration time.Duration
+	// MaxShards limita os shards processados por este consumidor (0 = sem limite)
+	MaxShards int
+	// MaxRetries é o número de novas tentativas de um registro com falha.
+	// Esgotadas as tentativas, o erro é reportado e o registro é ignorado
+	// para não bloquear o shard.
+	MaxRetries int
+	// ErrorHandler é chamado quando um registro é ignorado após falhas.
+	// Se nil, o erro é registrado no log.
+	ErrorHandler func(msg coreinterfaces.Message, err error)
+}
+
+// KinesisConsumer consome um stream, processando cada shard em sequência e
+// os shards em paralelo. Registros agregados pela KPL são desagregados. No
+// Message entregue ao handler, ID é o número de sequência (com a posição no
+// agregado após ":", quando agregado) e ReceiptHandle é o ID do shard.
+type KinesisConsumer struct {
+	provider   *KinesisProvider
+	streamName string
+	handler    MessageHandler
+	options    KinesisConsumerOptions
+	owner      string
+
+	mu     sync.Mutex
+	active map[string]bool
+	resync chan struct{}
+}
+
+// NewConsumer cria um consumidor para o stream. streamName pode ser o nome ou
+// o ARN do stream.
+func (p *KinesisProvider) NewConsumer(streamName string, handler MessageHandler, options KinesisConsumerOptions) *KinesisConsumer {
+	if options.StartPosition == "" {
+		options.StartPosition = kinesistypes.ShardIteratorTypeTrimHorizon
+	}
+	if options.BatchSize <= 0 || options.BatchSize > 10000 {
+		options.BatchSize = DefaultKinesisBatchSize
+	}
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultKinesisPollInterval
+	}
+	if options.ShardSyncInterval <= 0 {
+		options.ShardSyncInterval = DefaultKinesisShardSyncInterval
+	}
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = DefaultKinesisLeaseDuration
+	}
+	if options.MaxRetries < 0 {
+		options.MaxRetries = 0
+	} else if options.MaxRetries == 0 {
+		options.MaxRetries = DefaultKinesisMaxRetries
+	}
+
+	return &KinesisConsumer{
+		provider:   p,
+		streamName: streamName,
+		handler:    handler,
+		options:    options,
+		owner:      uuid.New().String(),
+		active:     make(map[string]bool),
+		resync:     make(chan struct{}, 1),
+	}
+}
+
+// Run consome o stream até o contexto ser cancelado. Novos shards são
+// descobertos periodicamente; um shard criado por resharding só começa a
+// ser lido depois que seus pais foram lidos até o fim, preservando a ordem
+// por chave de partição. No encerramento cada shard termina o registro em
+// andamento, grava o checkpoint e libera a posse.
+func (c *KinesisConsumer) Run(ctx context.Context) error {
+	if c.handler == nil {
+		return fmt.Errorf("handler do consumidor não informado")
+	}
+	if c.options.Checkpointer == nil {
+		return fmt.Errorf("checkpointer do consumidor não informado")
+	}
+
+	log.Printf("Consumidor iniciado para o stream %s", c.streamName)
+
+	var shards sync.WaitGroup
+	ticker := time.NewTicker(c.options.ShardSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.syncShards(ctx, &shards); err != nil {
+			log.Printf("Erro ao sincronizar shards do stream %s: %v", c.streamName, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			shards.Wait()
+			log.Printf("Consumidor do stream %s encerrado", c.streamName)
+			return nil
+		case <-ticker.C:
+		case <-c.resync:
+		}
+	}
+}
+
+// syncShards lista os shards do stream e inicia o processamento dos que
+// estiverem prontos e puderem ser assumidos
+func (c *KinesisConsumer) syncShards(ctx context.Context, shards *sync.WaitGroup) error {
+	list, err := c.provider.ListShards(ctx, c.streamName)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(list))
+	for _, shard := range list {
+		known[aws.ToString(shard.ShardId)] = true
+	}
+
+	for _, shard := range list {
+		shardID := aws.ToString(shard.ShardId)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		c.mu.Lock()
+		running := c.active[shardID]
+		full := c.options.MaxShards > 0 && len(c.active) >= c.options.MaxShards
+		c.mu.Unlock()
+		if running || full {
+			continue
+		}
+
+		ready, err := c.parentsFinished(ctx, shard, known)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			continue
+		}
+
+		checkpoint, claimed, err := c.options.Checkpointer.Claim(ctx, shardID, c.owner, c.options.LeaseDuration)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		if checkpoint.Finished {
+			c.release(ctx, shardID)
+			continue
+		}
+
+		c.mu.Lock()
+		c.active[shardID] = true
+		c.mu.Unlock()
+
+		shards.Add(1)
+		go func(shard kinesistypes.Shard, checkpoint ShardCheckpoint) {
+			defer shards.Done()
+			defer func() {
+				c.mu.Lock()
+				delete(c.active, shardID)
+				c.mu.Unlock()
+			}()
+			c.consumeShard(ctx, shard, checkpoint)
+		}(shard, checkpoint)
+	}
+	return nil
+}
+
+// parentsFinished informa se os shards pais, quando ainda existem no stream,
+// já foram lidos até o fim. Pais que saíram do período de retenção não
+// bloqueiam os filhos.
+func (c *KinesisConsumer) parentsFinished(ctx context.Context, shard kinesistypes.Shard, known map[string]bool) (bool, error) {
+	for _, parent := range []*string{shard.ParentShardId, shard.AdjacentParentShardId} {
+		parentID := aws.ToString(parent)
+		if parentID == "" || !known[parentID] {
+			continue
+		}
+		checkpoint, err := c.options.Checkpointer.Get(ctx, parentID)
+		if err != nil {
+			return false, err
+		}
+		if !checkpoint.Finished {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// consumeShard lê o shard até o fim, a perda da posse ou o cancelamento do contexto
+func (c *KinesisConsumer) consumeShard(ctx context.Context, shard kinesistypes.Shard, checkpoint ShardCheckpoint) {
+	shardID := aws.ToString(shard.ShardId)
+	log.Printf("Processando shard %s do stream %s", shardID, c.streamName)
+
+	iterator, err := c.shardIterator(ctx, shard, checkpoint)
+	if err != nil {
+		log.Printf("Erro ao obter iterador do shard %s: %v", shardID, err)
+		c.release(ctx, shardID)
+		return
+	}
+
+	renewed := time.Now()
+	backoff := time.Duration(0)
+	for {
+		if ctx.Err() != nil {
+			c.release(ctx, shardID)
+			return
+		}
+
+		output, err := c.provider.client.GetRecords(ctx, &kinesis.GetRecordsInput{
+			ShardIterator: iterator,
+			Limit:         aws.Int32(c.options.BatchSize),
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			var expired *kinesistypes.ExpiredIteratorException
+			if errors.As(err, &expired) {
+				// O iterador expira em 5 minutos; recomeça do último checkpoint
+				if iterator, err = c.shardIterator(ctx, shard, checkpoint); err == nil {
+					continue
+				}
+			}
+			if !isKinesisThrottled(err) {
+				log.Printf("Erro ao ler shard %s do stream %s: %v", shardID, c.streamName, err)
+			}
+			backoff = nextBackoff(backoff)
+			c.wait(ctx, backoff)
+			continue
+		}
+		backoff = 0
+
+		progressed := false
+		for _, record := range output.Records {
+			next, ok := c.processRecord(ctx, shardID, record, checkpoint)
+			if next != checkpoint {
+				checkpoint = next
+				progressed = true
+			}
+			if !ok {
+				break
+			}
+		}
+
+		closed := output.NextShardIterator == nil
+		if closed && ctx.Err() == nil {
+			checkpoint.Finished = true
+			progressed = true
+		}
+
+		// O checkpoint também renova a posse
+		if progressed || time.Since(renewed) > c.options.LeaseDuration/3 {
+			saveCtx := context.WithoutCancel(ctx)
+			if err := c.options.Checkpointer.Checkpoint(saveCtx, shardID, c.owner, checkpoint, c.options.LeaseDuration); err != nil {
+				if errors.Is(err, ErrLeaseLost) {
+					log.Printf("Shard %s do stream %s assumido por outro consumidor", shardID, c.streamName)
+					return
+				}
+				log.Printf("Erro ao gravar checkpoint do shard %s: %v", shardID, err)
+			} else {
+				renewed = time.Now()
+			}
+		}
+
+		if checkpoint.Finished {
+			log.Printf("Shard %s do stream %s lido até o fim", shardID, c.streamName)
+			c.release(ctx, shardID)
+			// Os shards filhos podem começar
+			select {
+			case c.resync <- struct{}{}:
+			default:
+			}
+			return
+		}
+
+		iterator = output.NextShardIterator
+		if len(output.Records) == 0 || aws.ToInt64(output.MillisBehindLatest) == 0 {
+			c.wait(ctx, c.options.PollInterval)
+		}
+	}
+}
+
+// processRecord desagrega o registro e executa o handler para cada registro
+// de usuário ainda não processado. Retorna o novo checkpoint e false se o
+// contexto foi cancelado antes do fim.
+func (c *KinesisConsumer) processRecord(ctx context.Context, shardID string, record kinesistypes.Record, checkpoint ShardCheckpoint) (ShardCheckpoint, bool) {
+	sequence := aws.ToString(record.SequenceNumber)
+
+	userRecords, err := Deaggregate(aws.ToString(record.PartitionKey), record.Data)
+	if err != nil {
+		msg := coreinterfaces.Message{ID: sequence, Body: record.Data, ReceiptHandle: shardID}
+		c.reportError(msg, err)
+		return ShardCheckpoint{SequenceNumber: sequence}, true
+	}
+	aggregated := IsAggregatedRecord(record.Data)
+
+	for _, userRecord := range userRecords {
+		position := ShardCheckpoint{SequenceNumber: sequence, SubSequenceNumber: int64(userRecord.SubSequenceNumber)}
+		if !isAfterCheckpoint(position, checkpoint) {
+			continue
+		}
+
+		id := sequence
+		if aggregated {
+			id = sequence + ":" + strconv.Itoa(userRecord.SubSequenceNumber)
+		}
+		msg := coreinterfaces.Message{ID: id, Body: userRecord.Data, ReceiptHandle: shardID}
+
+		if !c.handleWithRetry(ctx, msg) {
+			return checkpoint, false
+		}
+		checkpoint = position
+	}
+	return checkpoint, true
+}
+
+// handleWithRetry executa o handler com novas tentativas. Retorna false
+// apenas se o contexto foi cancelado antes de o registro ser concluído.
+func (c *KinesisConsumer) handleWithRetry(ctx context.Context, msg coreinterfaces.Message) bool {
+	backoff := time.Duration(0)
+	for attempt := 0; ; attempt++ {
+		err := c.handle(ctx, msg)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		if attempt >= c.options.MaxRetries {
+			c.reportError(msg, err)
+			return true
+		}
+		backoff = nextBackoff(backoff)
+		log.Printf("Erro ao processar registro %s do shard %s, nova tentativa em %s: %v", msg.ID, msg.ReceiptHandle, backoff, err)
+		if !c.wait(ctx, backoff) {
+			return false
+		}
+	}
+}
+
+// handle executa o handler convertendo pânicos em erro
+func (c *KinesisConsumer) handle(ctx context.Context, msg coreinterfaces.Message) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pânico no handler: %v", r)
+		}
+	}()
+	return c.handler(ctx, msg)
+}
+
+// reportError encaminha a falha ao ErrorHandler ou ao log
+func (c *KinesisConsumer) reportError(msg coreinterfaces.Message, err error) {
+	if c.options.ErrorHandler != nil {
+		c.options.ErrorHandler(msg, err)
+		return
+	}
+	log.Printf("Registro %s do shard %s ignorado após falhas: %v", msg.ID, msg.ReceiptHandle, err)
+}
+
+// shardIterator obtém o iterador a partir do checkpoint. Sem checkpoint,
+// shards filhos começam do início e os demais da posição configurada.
+func (c *KinesisConsumer) shardIterator(ctx context.Context, shard kinesistypes.Shard, checkpoint ShardCheckpoint) (*string, error) {
+	input := &kinesis.GetShardIteratorInput{ShardId: shard.ShardId}
+	input.StreamName, input.StreamARN = streamRef(c.streamName)
+
+	switch {
+	case checkpoint.SequenceNumber != "":
+		// Registros já processados do mesmo agregado são ignorados em processRecord
+		input.ShardIteratorType = kinesistypes.ShardIteratorTypeAtSequenceNumber
+		input.StartingSequenceNumber = aws.String(checkpoint.SequenceNumber)
+	case shard.ParentShardId != nil:
+		input.ShardIteratorType = kinesistypes.ShardIteratorTypeTrimHorizon
+	default:
+		input.ShardIteratorType = c.options.StartPosition
+	}
+
+	output, err := c.provider.client.GetShardIterator(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter iterador do shard %s: %w", aws.ToString(shard.ShardId), err)
+	}
+	return output.ShardIterator, nil
+}
+
+// release libera a posse do shard, mesmo com o contexto cancelado
+func (c *KinesisConsumer) release(ctx context.Context, shardID string) {
+	if err := c.options.Checkpointer.Release(context.WithoutCancel(ctx), shardID, c.owner); err != nil {
+		log.Printf("Erro ao liberar shard %s do stream %s: %v", shardID, c.streamName, err)
+	}
+}
+
+// wait espera a duração informada; retorna false se o contexto foi cancelado
+func (c *KinesisConsumer) wait(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// isAfterCheckpoint informa se a posição vem depois do checkpoint. Números de sequência
+// são comparados como inteiros.
+func isAfterCheckpoint(position, checkpoint ShardCheckpoint) bool {
+	if checkpoint.SequenceNumber == "" {
+		return true
+	}
+	current, ok1 := new(big.Int).SetString(position.SequenceNumber, 10)
+	last, ok2 := new(big.Int).SetString(checkpoint.SequenceNumber, 10)
+	if !ok1 || !ok2 {
+		return position.SequenceNumber != checkpoint.SequenceNumber || position.SubSequenceNumber > checkpoint.SubSequenceNumber
+	}
+	if cmp := current.Cmp(last); cmp != 0 {
+		return cmp > 0
+	}
+	return position.SubSequenceNumber > checkpoint.SubSequenceNumber
+}
@@ -0,0 +1,148 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	kinesistypes "github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// fakeKinesisClient registra os registros gravados e rejeita por throttling
+// os registros cujo conteúdo está em throttled, tantas vezes quanto indicado
+type fakeKinesisClient struct {
+	mu        sync.Mutex
+	throttled map[string]int
+	written   []string
+}
+
+func (c *fakeKinesisClient) PutRecords(ctx context.Context, params *kinesis.PutRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := &kinesis.PutRecordsOutput{}
+	for i, entry := range params.Records {
+		data := string(entry.Data)
+		if c.throttled[data] > 0 {
+			c.throttled[data]--
+			output.Records = append(output.Records, kinesistypes.PutRecordsResultEntry{
+				ErrorCode:    aws.String("ProvisionedThroughputExceededException"),
+				ErrorMessage: aws.String("falha simulada"),
+			})
+			continue
+		}
+		c.written = append(c.written, data)
+		output.Records = append(output.Records, kinesistypes.PutRecordsResultEntry{
+			SequenceNumber: aws.String(fmt.Sprintf("%d-%d", len(c.written), i)),
+			ShardId:        aws.String("shardId-000000000000"),
+		})
+	}
+	return output, nil
+}
+
+func (c *fakeKinesisClient) ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+func (c *fakeKinesisClient) GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+func (c *fakeKinesisClient) GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+// writtenWithKey retorna os registros gravados com o prefixo da chave, na ordem
+func (c *fakeKinesisClient) writtenWithKey(key string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var data []string
+	for _, record := range c.written {
+		if strings.HasPrefix(record, key+"/") {
+			data = append(data, record)
+		}
+	}
+	return data
+}
+
+// keyedRecords monta registros alternando as chaves A e B; o conteúdo é
+// "<chave>/<número>"
+func keyedRecords(count int) []KinesisRecord {
+	var records []KinesisRecord
+	for n := 1; n <= count; n++ {
+		for _, key := range []string{"A", "B"} {
+			records = append(records, KinesisRecord{Data: []byte(fmt.Sprintf("%s/%d", key, n)), PartitionKey: key})
+		}
+	}
+	return records
+}
+
+func TestPutRecordsRetriesInOrder(t *testing.T) {
+	client := &fakeKinesisClient{throttled: map[string]int{"A/3": 1}}
+	p := NewKinesisProviderWithClient(client, nil)
+
+	// A/3 é o último registro da chave A: pode ser reenviado sem inverter a ordem
+	results, err := p.PutRecords(context.Background(), "stream", keyedRecords(3))
+	if err != nil {
+		t.Fatalf("PutRecords: %v (%+v)", err, results)
+	}
+	if got := client.writtenWithKey("A"); !reflect.DeepEqual(got, []string{"A/1", "A/2", "A/3"}) {
+		t.Fatalf("chave A fora de ordem: %v", got)
+	}
+}
+
+func TestPutRecordsStopsKeyAfterFailure(t *testing.T) {
+	client := &fakeKinesisClient{throttled: map[string]int{"A/1": 1}}
+	p := NewKinesisProviderWithClient(client, nil)
+
+	// 300 registros por chave: o primeiro lote leva A/1..A/250 e B/1..B/250
+	records := keyedRecords(300)
+	results, err := p.PutRecords(context.Background(), "stream", records)
+	if err == nil {
+		t.Fatal("PutRecords deveria reportar a falha")
+	}
+
+	// A/1 não é reenviado depois de A/2, aceito no mesmo lote, e a chave A não
+	// avança no lote seguinte
+	written := client.writtenWithKey("A")
+	if len(written) != 249 || written[0] != "A/2" || written[248] != "A/250" {
+		t.Fatalf("chave A: gravados %d registros, de %v a %v", len(written), written[0], written[len(written)-1])
+	}
+	if got := client.writtenWithKey("B"); len(got) != 300 {
+		t.Fatalf("chave B não deveria ser afetada: %d registros gravados", len(got))
+	}
+	if results[0].Err == nil || errors.Is(results[0].Err, ErrOrderBlocked) {
+		t.Fatalf("A/1: esperada a falha de throttling, obtido %v", results[0].Err)
+	}
+	for i := 500; i < len(records); i += 2 {
+		if !errors.Is(results[i].Err, ErrOrderBlocked) {
+			t.Fatalf("registro %d: esperado ErrOrderBlocked, obtido %v", i, results[i].Err)
+		}
+	}
+}
+
+func TestPutRecordsInvalidRecordBlocksKey(t *testing.T) {
+	client := &fakeKinesisClient{}
+	p := NewKinesisProviderWithClient(client, nil)
+
+	records := keyedRecords(2)
+	records[0].ExplicitHashKey = "não numérica"
+	results, err := p.PutRecords(context.Background(), "stream", records)
+	if err == nil {
+		t.Fatal("PutRecords deveria reportar o registro inválido")
+	}
+	if !errors.Is(results[2].Err, ErrOrderBlocked) {
+		t.Fatalf("A/2: esperado ErrOrderBlocked, obtido %v", results[2].Err)
+	}
+	if got := client.writtenWithKey("A"); len(got) != 0 {
+		t.Fatalf("chave A não deveria ser gravada: %v", got)
+	}
+	if got := client.writtenWithKey("B"); len(got) != 2 {
+		t.Fatalf("chave B não deveria ser afetada: %v", got)
+	}
+}
@@ -0,0 +1,457 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/provider"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Situação dos registros do outbox
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+)
+
+// Atributos da tabela do outbox. A chave de partição, do tipo string, deve se
+// chamar "outboxId". A consulta de pendentes usa um índice global com chave de
+// partição "status" e chave de ordenação "createdAt" (número). O createdAt
+// guarda o instante de gravação em milissegundos multiplicado por
+// createdAtScale, somado à posição da mensagem na transação, para que as
+// mensagens de uma mesma transação tenham ordem estrita no índice.
+const (
+	outboxKeyAttribute      = "outboxId"
+	outboxStatusAttribute   = "status"
+	outboxCreatedAttribute  = "createdAt"
+	outboxSentAttribute     = "sentAt"
+	outboxExpiresAttribute  = "expiresAt"
+	outboxAttemptsAttribute = "attempts"
+	outboxNextAttribute     = "nextAttemptAt"
+	outboxErrorAttribute    = "lastError"
+
+	// maxTransactItems é o limite de itens de TransactWriteItems
+	maxTransactItems = 100
+
+	// createdAtScale separa o instante da posição na transação em createdAt;
+	// deve ser maior que maxTransactItems
+	createdAtScale = 1000
+)
+
+// DefaultOutboxStatusIndex é o nome padrão do índice de consulta de pendentes
+const DefaultOutboxStatusIndex = "status-createdAt-index"
+
+// OutboxIDAttribute é o atributo de mensagem com o ID do registro do outbox,
+// que os consumidores podem usar para descartar entregas repetidas
+const OutboxIDAttribute = "outboxId"
+
+// OutboxAPI define as operações do cliente DynamoDB utilizadas pelo outbox.
+// É satisfeita por *dynamodb.Client.
+type OutboxAPI interface {
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// OutboxMessage é uma mensagem a publicar junto com uma alteração de estado
+type OutboxMessage struct {
+	// Destination é a fila, tópico, barramento ou stream de destino
+	Destination string
+	// Message é serializada em JSON no momento da escrita
+	Message interface{}
+	// DeduplicationID é usado na publicação em destinos FIFO; se vazio, é o ID do registro
+	DeduplicationID string
+	// GroupID é o grupo da mensagem em destinos FIFO
+	GroupID string
+	// Attributes são atributos de mensagem do tipo String
+	Attributes map[string]string
+}
+
+// OutboxRecord é um registro gravado no outbox
+type OutboxRecord struct {
+	ID              string
+	Destination     string
+	Body            json.RawMessage
+	DeduplicationID string
+	GroupID         string
+	Attributes      map[string]string
+	Status          string
+	CreatedAt       time.Time
+	// Attempts é o número de publicações que falharam
+	Attempts int
+	// NextAttemptAt é o instante a partir do qual o registro pode ser
+	// publicado de novo após uma falha; zero se não houve falha
+	NextAttemptAt time.Time
+	// LastError é o erro da última publicação que falhou
+	LastError string
+}
+
+// groupKey identifica o grupo FIFO do registro no destino; vazio sem grupo
+func (r OutboxRecord) groupKey() string {
+	if r.GroupID == "" {
+		return ""
+	}
+	return r.Destination + "/" + r.GroupID
+}
+
+// outboxItem é a representação do registro na tabela
+type outboxItem struct {
+	ID              string            `dynamodbav:"outboxId"`
+	Status          string            `dynamodbav:"status"`
+	Destination     string            `dynamodbav:"destination"`
+	Body            string            `dynamodbav:"body"`
+	DeduplicationID string            `dynamodbav:"deduplicationId"`
+	GroupID         string            `dynamodbav:"groupId,omitempty"`
+	Attributes      map[string]string `dynamodbav:"attributes,omitempty"`
+	CreatedAt       int64             `dynamodbav:"createdAt"`
+	Attempts        int               `dynamodbav:"attempts,omitempty"`
+	NextAttemptAt   int64             `dynamodbav:"nextAttemptAt,omitempty"`
+	LastError       string            `dynamodbav:"lastError,omitempty"`
+}
+
+// record converte o item em registro
+func (i outboxItem) record() OutboxRecord {
+	record := OutboxRecord{
+		ID:              i.ID,
+		Destination:     i.Destination,
+		Body:            json.RawMessage(i.Body),
+		DeduplicationID: i.DeduplicationID,
+		GroupID:         i.GroupID,
+		Attributes:      i.Attributes,
+		Status:          i.Status,
+		CreatedAt:       createdAtTime(i.CreatedAt),
+		Attempts:        i.Attempts,
+		LastError:       i.LastError,
+	}
+	if i.NextAttemptAt > 0 {
+		record.NextAttemptAt = time.UnixMilli(i.NextAttemptAt)
+	}
+	return record
+}
+
+// Outbox grava mensagens na mesma transação DynamoDB da alteração de estado,
+// para que sejam publicadas depois pelo OutboxRelay. Assim uma falha entre a
+// gravação e o envio não perde eventos.
+type Outbox struct {
+	client      OutboxAPI
+	tableName   string
+	statusIndex string
+}
+
+// NewOutbox cria um outbox na tabela informada com a configuração AWS do provedor
+func NewOutbox(cloudProvider coreinterfaces.CloudProvider, tableName string) (*Outbox, error) {
+	awsProvider, ok := cloudProvider.(*provider.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provedor não é do tipo AWS")
+	}
+
+	awsConfig, ok := awsProvider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+
+	return NewOutboxWithClient(dynamodb.NewFromConfig(awsConfig), tableName), nil
+}
+
+// NewOutboxWithClient cria um outbox com um cliente DynamoDB já configurado
+func NewOutboxWithClient(client OutboxAPI, tableName string) *Outbox {
+	return &Outbox{
+		client:      client,
+		tableName:   tableName,
+		statusIndex: DefaultOutboxStatusIndex,
+	}
+}
+
+// SetStatusIndex altera o nome do índice usado na consulta de pendentes
+func (o *Outbox) SetStatusIndex(indexName string) {
+	o.statusIndex = indexName
+}
+
+// TableName retorna a tabela do outbox
+func (o *Outbox) TableName() string {
+	return o.tableName
+}
+
+// Write grava o item na tabela informada e as mensagens no outbox, em uma
+// única transação
+func (o *Outbox) Write(ctx context.Context, tableName string, item interface{}, messages ...OutboxMessage) ([]OutboxRecord, error) {
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao converter item para atributos do DynamoDB: %w", err)
+	}
+
+	return o.WriteTransaction(ctx, []ddbtypes.TransactWriteItem{{
+		Put: &ddbtypes.Put{TableName: aws.String(tableName), Item: av},
+	}}, messages...)
+}
+
+// WriteTransaction executa as escritas informadas (Put, Update, Delete ou
+// ConditionCheck) e grava as mensagens no outbox, em uma única transação.
+// Se qualquer escrita falhar nenhuma mensagem é gravada.
+func (o *Outbox) WriteTransaction(ctx context.Context, writes []ddbtypes.TransactWriteItem, messages ...OutboxMessage) ([]OutboxRecord, error) {
+	if len(writes)+len(messages) > maxTransactItems {
+		return nil, fmt.Errorf("transação com %d itens excede o limite de %d", len(writes)+len(messages), maxTransactItems)
+	}
+
+	now := time.Now()
+	items := make([]ddbtypes.TransactWriteItem, 0, len(writes)+len(messages))
+	items = append(items, writes...)
+	records := make([]OutboxRecord, 0, len(messages))
+	for i, msg := range messages {
+		if msg.Destination == "" {
+			return nil, fmt.Errorf("destino da mensagem %d não informado", i)
+		}
+
+		body, err := json.Marshal(msg.Message)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao serializar mensagem %d: %w", i, err)
+		}
+
+		item := outboxItem{
+			ID:              uuid.New().String(),
+			Status:          OutboxStatusPending,
+			Destination:     msg.Destination,
+			Body:            string(body),
+			DeduplicationID: msg.DeduplicationID,
+			GroupID:         msg.GroupID,
+			Attributes:      msg.Attributes,
+			CreatedAt:       createdAtValue(now, i),
+		}
+		if item.DeduplicationID == "" {
+			item.DeduplicationID = item.ID
+		}
+
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao converter registro do outbox: %w", err)
+		}
+		items = append(items, ddbtypes.TransactWriteItem{
+			Put: &ddbtypes.Put{
+				TableName:                aws.String(o.tableName),
+				Item:                     av,
+				ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+				ExpressionAttributeNames: map[string]string{"#key": outboxKeyAttribute},
+			},
+		})
+		records = append(records, item.record())
+	}
+
+	if _, err := o.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items}); err != nil {
+		return nil, fmt.Errorf("erro ao gravar transação com outbox: %w", err)
+	}
+
+	log.Printf("Outbox: %d mensagens gravadas na tabela %s", len(records), o.tableName)
+	return records, nil
+}
+
+// createdAtValue calcula o createdAt da mensagem na posição index de uma
+// transação gravada em now
+func createdAtValue(now time.Time, index int) int64 {
+	return now.UnixMilli()*createdAtScale + int64(index)
+}
+
+// createdAtTime converte o createdAt gravado no instante de gravação
+func createdAtTime(value int64) time.Time {
+	return time.UnixMilli(value / createdAtScale)
+}
+
+// Pending retorna até limit registros pendentes prontos para publicação, dos
+// mais antigos para os mais novos. Registros aguardando nova tentativa após
+// uma falha são ignorados, assim como os seguintes do mesmo grupo, e a
+// consulta avança pelas páginas até reunir limit registros.
+func (o *Outbox) Pending(ctx context.Context, limit int) ([]OutboxRecord, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(o.tableName),
+		IndexName:              aws.String(o.statusIndex),
+		KeyConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": outboxStatusAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":pending": &ddbtypes.AttributeValueMemberS{Value: OutboxStatusPending},
+		},
+		ScanIndexForward: aws.Bool(true),
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(int32(limit))
+	}
+
+	var records []OutboxRecord
+	selector := newReadySelector(time.Now())
+	for {
+		output, err := o.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao consultar pendentes do outbox %s: %w", o.tableName, err)
+		}
+
+		for _, av := range output.Items {
+			var item outboxItem
+			if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+				return nil, fmt.Errorf("erro ao converter registro do outbox: %w", err)
+			}
+			record := item.record()
+			if !selector.ready(record) {
+				continue
+			}
+			records = append(records, record)
+			if limit > 0 && len(records) == limit {
+				return records, nil
+			}
+		}
+
+		if limit <= 0 || output.LastEvaluatedKey == nil {
+			return records, nil
+		}
+		input.ExclusiveStartKey = output.LastEvaluatedKey
+	}
+}
+
+// readySelector escolhe, na ordem de criação, os registros que podem ser
+// publicados. Um registro aguardando nova tentativa retém os seguintes do
+// mesmo grupo, preservando a ordem FIFO.
+type readySelector struct {
+	now     time.Time
+	waiting map[string]bool
+}
+
+// newReadySelector cria um seletor para o instante informado
+func newReadySelector(now time.Time) *readySelector {
+	return &readySelector{now: now, waiting: make(map[string]bool)}
+}
+
+// ready informa se o registro pode ser publicado agora. Os registros devem
+// ser apresentados na ordem de criação.
+func (s *readySelector) ready(record OutboxRecord) bool {
+	key := record.groupKey()
+	if key != "" && s.waiting[key] {
+		return false
+	}
+	if record.NextAttemptAt.After(s.now) {
+		if key != "" {
+			s.waiting[key] = true
+		}
+		return false
+	}
+	return true
+}
+
+// Get lê um registro do outbox com leitura consistente. Retorna nil se não existir.
+func (o *Outbox) Get(ctx context.Context, id string) (*OutboxRecord, error) {
+	output, err := o.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(o.tableName),
+		Key:            o.key(id),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler registro %s do outbox: %w", id, err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	var item outboxItem
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, fmt.Errorf("erro ao converter registro %s do outbox: %w", id, err)
+	}
+	record := item.record()
+	return &record, nil
+}
+
+// MarkSent marca o registro como enviado. Retorna false se o registro já não
+// estava pendente, por exemplo porque outra instância do relay o enviou.
+// Com retention maior que zero, o atributo expiresAt (epoch em segundos)
+// permite a remoção pelo TTL da tabela.
+func (o *Outbox) MarkSent(ctx context.Context, id string, retention time.Duration) (bool, error) {
+	now := time.Now()
+	update := "SET #status = :sent, #sentAt = :now"
+	names := map[string]string{
+		"#status": outboxStatusAttribute,
+		"#sentAt": outboxSentAttribute,
+	}
+	values := map[string]ddbtypes.AttributeValue{
+		":sent":    &ddbtypes.AttributeValueMemberS{Value: OutboxStatusSent},
+		":pending": &ddbtypes.AttributeValueMemberS{Value: OutboxStatusPending},
+		":now":     epochMillisValue(now),
+	}
+	if retention > 0 {
+		update += ", #expires = :expires"
+		names["#expires"] = outboxExpiresAttribute
+		values[":expires"] = &ddbtypes.AttributeValueMemberN{Value: fmt.Sprint(now.Add(retention).Unix())}
+	}
+
+	_, err := o.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(o.tableName),
+		Key:                       o.key(id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#status = :pending"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao marcar registro %s do outbox como enviado: %w", id, err)
+	}
+	return true, nil
+}
+
+// MarkFailed registra uma publicação que falhou: incrementa attempts, guarda
+// o erro em lastError e adia a próxima tentativa até retryAt. Com retryAt
+// zero o registro passa para failed e deixa de ser retornado por Pending.
+// Retorna false se o registro já não estava pendente.
+func (o *Outbox) MarkFailed(ctx context.Context, id string, cause error, retryAt time.Time) (bool, error) {
+	update := "SET #attempts = if_not_exists(#attempts, :zero) + :one, #error = :error"
+	names := map[string]string{
+		"#status":   outboxStatusAttribute,
+		"#attempts": outboxAttemptsAttribute,
+		"#error":    outboxErrorAttribute,
+	}
+	values := map[string]ddbtypes.AttributeValue{
+		":pending": &ddbtypes.AttributeValueMemberS{Value: OutboxStatusPending},
+		":zero":    &ddbtypes.AttributeValueMemberN{Value: "0"},
+		":one":     &ddbtypes.AttributeValueMemberN{Value: "1"},
+		":error":   &ddbtypes.AttributeValueMemberS{Value: fmt.Sprint(cause)},
+	}
+	if retryAt.IsZero() {
+		update += ", #status = :failed"
+		values[":failed"] = &ddbtypes.AttributeValueMemberS{Value: OutboxStatusFailed}
+	} else {
+		update += ", #next = :next"
+		names["#next"] = outboxNextAttribute
+		values[":next"] = epochMillisValue(retryAt)
+	}
+
+	_, err := o.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(o.tableName),
+		Key:                       o.key(id),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#status = :pending"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao registrar falha do registro %s do outbox: %w", id, err)
+	}
+	return true, nil
+}
+
+// key monta a chave do item do registro
+func (o *Outbox) key(id string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		outboxKeyAttribute: &ddbtypes.AttributeValueMemberS{Value: id},
+	}
+}
@@ -0,0 +1,313 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Valores padrão do relay do outbox
+const (
+	DefaultOutboxPollInterval = time.Second
+	DefaultOutboxBatchSize    = 25
+	DefaultOutboxMaxAttempts  = 10
+	DefaultOutboxRetryBackoff = 5 * time.Second
+
+	// outboxMaxRetryBackoff limita a espera entre tentativas de um registro
+	outboxMaxRetryBackoff = 15 * time.Minute
+)
+
+// OutboxRelayOptions configura o relay do outbox
+type OutboxRelayOptions struct {
+	// PollInterval é o intervalo entre consultas quando não há pendentes (padrão 1s)
+	PollInterval time.Duration
+	// BatchSize é o número de registros lidos por consulta (padrão 25)
+	BatchSize int
+	// SentRetention, se maior que zero, define o TTL dos registros enviados
+	SentRetention time.Duration
+	// MaxAttempts é o número de publicações tentadas por registro (padrão 10).
+	// Esgotadas as tentativas o registro passa para failed, e os seguintes do
+	// mesmo grupo voltam a ser publicados.
+	MaxAttempts int
+	// RetryBackoff é a espera após a primeira falha de um registro, dobrada a
+	// cada nova falha até 15 minutos (padrão 5s). Enquanto espera, o registro
+	// não bloqueia os demais, apenas os seguintes do mesmo grupo.
+	RetryBackoff time.Duration
+	// ErrorHandler é chamado quando uma publicação falha. Se nil, o erro é
+	// registrado no log.
+	ErrorHandler func(record OutboxRecord, err error)
+}
+
+// OutboxRelay publica os registros pendentes do outbox e os marca como
+// enviados. A entrega é pelo menos uma vez: uma falha entre a publicação e a
+// marcação repete o envio, com o mesmo ID de deduplicação e o atributo
+// OutboxIDAttribute.
+type OutboxRelay struct {
+	outbox    *Outbox
+	publisher coreinterfaces.MessagingProvider
+	options   OutboxRelayOptions
+}
+
+// NewRelay cria um relay que publica pelo provedor de mensageria informado.
+// Com SQSProvider e SNSProvider o ID de deduplicação e o grupo são usados em
+// destinos FIFO e os atributos são enviados como atributos de mensagem; com
+// outros provedores é usado SendMessage.
+func (o *Outbox) NewRelay(publisher coreinterfaces.MessagingProvider, options OutboxRelayOptions) *OutboxRelay {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultOutboxPollInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultOutboxBatchSize
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultOutboxMaxAttempts
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = DefaultOutboxRetryBackoff
+	}
+
+	return &OutboxRelay{
+		outbox:    o,
+		publisher: publisher,
+		options:   options,
+	}
+}
+
+// Run consulta os pendentes periodicamente até o contexto ser cancelado
+func (r *OutboxRelay) Run(ctx context.Context) error {
+	log.Printf("Iniciando relay do outbox %s", r.outbox.tableName)
+
+	backoff := time.Duration(0)
+	for {
+		sent, err := r.RelayPending(ctx)
+		if ctx.Err() != nil {
+			log.Printf("Relay do outbox %s encerrado", r.outbox.tableName)
+			return nil
+		}
+
+		wait := r.options.PollInterval
+		switch {
+		case err != nil:
+			log.Printf("Erro no relay do outbox %s: %v", r.outbox.tableName, err)
+			backoff = nextBackoff(backoff)
+			wait = backoff
+		case sent > 0:
+			// Pode haver mais pendentes; consultar de novo sem esperar
+			backoff = 0
+			continue
+		default:
+			backoff = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Relay do outbox %s encerrado", r.outbox.tableName)
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// RelayPending publica um lote de pendentes e retorna quantos foram
+// publicados. Em um grupo, após uma falha, os registros seguintes ficam para
+// a próxima consulta, preservando a ordem.
+func (r *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	records, err := r.outbox.Pending(ctx, r.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return r.relay(ctx, records)
+}
+
+// HandleStreamEvent publica os registros inseridos no outbox, recebidos de
+// um DynamoDB Stream (com NEW_IMAGE) em uma função Lambda. Retorna erro se
+// algum registro falhar, para que a Lambda repita o lote. Como a imagem é a
+// da inserção, cada registro é relido com leitura consistente antes da
+// publicação: os já enviados, por exemplo em uma execução anterior do mesmo
+// lote, e os que esgotaram as tentativas são ignorados.
+func (r *OutboxRelay) HandleStreamEvent(ctx context.Context, event events.DynamoDBEvent) error {
+	var records []OutboxRecord
+	for _, streamRecord := range event.Records {
+		if streamRecord.EventName != string(events.DynamoDBOperationTypeInsert) {
+			continue
+		}
+		record, err := outboxRecordFromImage(streamRecord.Change.NewImage)
+		if err != nil {
+			return fmt.Errorf("registro %s do stream: %w", streamRecord.EventID, err)
+		}
+		if record.Status != OutboxStatusPending {
+			continue
+		}
+
+		current, err := r.outbox.Get(ctx, record.ID)
+		if err != nil {
+			return err
+		}
+		if current != nil && current.Status == OutboxStatusPending {
+			records = append(records, *current)
+		}
+	}
+
+	sent, err := r.relay(ctx, records)
+	if err != nil {
+		return err
+	}
+	if sent < len(records) {
+		return fmt.Errorf("%d de %d registros do outbox não publicados", len(records)-sent, len(records))
+	}
+	return nil
+}
+
+// relay publica os registros em ordem e marca cada um como enviado
+func (r *OutboxRelay) relay(ctx context.Context, records []OutboxRecord) (int, error) {
+	sent := 0
+	failedGroups := make(map[string]bool)
+	for _, record := range records {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		groupKey := record.groupKey()
+		if groupKey != "" && failedGroups[groupKey] {
+			continue
+		}
+
+		if err := r.publish(ctx, record); err != nil {
+			if groupKey != "" {
+				failedGroups[groupKey] = true
+			}
+			r.reportError(record, err)
+			r.recordFailure(ctx, record, err)
+			continue
+		}
+
+		if _, err := r.outbox.MarkSent(ctx, record.ID, r.options.SentRetention); err != nil {
+			// A mensagem foi publicada e será repetida na próxima consulta
+			r.reportError(record, err)
+			continue
+		}
+		sent++
+	}
+
+	if len(records) > 0 {
+		log.Printf("Outbox %s: %d de %d registros publicados", r.outbox.tableName, sent, len(records))
+	}
+	return sent, nil
+}
+
+// recordFailure adia a próxima tentativa do registro conforme RetryBackoff
+// ou, esgotadas as tentativas, marca-o como failed
+func (r *OutboxRelay) recordFailure(ctx context.Context, record OutboxRecord, cause error) {
+	attempts := record.Attempts + 1
+	var retryAt time.Time
+	if attempts < r.options.MaxAttempts {
+		retryAt = time.Now().Add(retryBackoff(r.options.RetryBackoff, attempts, outboxMaxRetryBackoff))
+	}
+
+	updated, err := r.outbox.MarkFailed(context.WithoutCancel(ctx), record.ID, cause, retryAt)
+	if err != nil {
+		log.Printf("Erro ao registrar falha do registro %s do outbox: %v", record.ID, err)
+		return
+	}
+	if updated && retryAt.IsZero() {
+		log.Printf("Registro %s do outbox marcado como %s após %d tentativas", record.ID, OutboxStatusFailed, attempts)
+	}
+}
+
+// publish envia o registro pelo provedor, com o ID do registro em OutboxIDAttribute
+func (r *OutboxRelay) publish(ctx context.Context, record OutboxRecord) error {
+	attributes := map[string]MessageAttribute{
+		OutboxIDAttribute: StringAttribute(record.ID),
+	}
+	for k, v := range record.Attributes {
+		attributes[k] = StringAttribute(v)
+	}
+	return publishBody(ctx, r.publisher, record.Destination, record.Body, attributes, record.GroupID, record.DeduplicationID, 0)
+}
+
+// publishBody envia um corpo JSON já serializado pelo provedor. Com
+// SQSProvider e SNSProvider usa atributos e, em destinos FIFO, grupo e ID de
+// deduplicação; o atraso só é aplicado em filas SQS padrão. Com outros
+// provedores é usado SendMessage.
+func publishBody(ctx context.Context, publisher coreinterfaces.MessagingProvider, destination string, body json.RawMessage, attributes map[string]MessageAttribute, groupID, deduplicationID string, delaySeconds int32) error {
+	// ID de deduplicação e grupo só são aceitos em destinos FIFO
+	if !IsFIFOQueue(destination) {
+		groupID, deduplicationID = "", ""
+	}
+
+	switch p := publisher.(type) {
+	case *SQSProvider:
+		if IsFIFOQueue(destination) {
+			delaySeconds = 0
+		}
+		_, err := p.SendMessageWithOptions(ctx, destination, body, SendOptions{
+			Attributes:      attributes,
+			DelaySeconds:    delaySeconds,
+			MessageGroupID:  groupID,
+			DeduplicationID: deduplicationID,
+		})
+		return err
+	case *SNSProvider:
+		_, err := p.Publish(ctx, destination, body, PublishOptions{
+			Attributes:      attributes,
+			MessageGroupID:  groupID,
+			DeduplicationID: deduplicationID,
+		})
+		return err
+	default:
+		return publisher.SendMessage(ctx, destination, body)
+	}
+}
+
+// reportError repassa a falha ao ErrorHandler ou registra no log
+func (r *OutboxRelay) reportError(record OutboxRecord, err error) {
+	if r.options.ErrorHandler != nil {
+		r.options.ErrorHandler(record, err)
+		return
+	}
+	log.Printf("Erro ao publicar registro %s do outbox para %s: %v", record.ID, record.Destination, err)
+}
+
+// outboxRecordFromImage converte a imagem de um DynamoDB Stream em registro
+func outboxRecordFromImage(image map[string]events.DynamoDBAttributeValue) (OutboxRecord, error) {
+	stringValue := func(name string) string {
+		if v, ok := image[name]; ok && v.DataType() == events.DataTypeString {
+			return v.String()
+		}
+		return ""
+	}
+
+	record := OutboxRecord{
+		ID:              stringValue(outboxKeyAttribute),
+		Destination:     stringValue("destination"),
+		Body:            json.RawMessage(stringValue("body")),
+		DeduplicationID: stringValue("deduplicationId"),
+		GroupID:         stringValue("groupId"),
+		Status:          stringValue(outboxStatusAttribute),
+	}
+	if record.ID == "" || record.Destination == "" {
+		return OutboxRecord{}, errors.New("imagem sem outboxId ou destino; o stream deve incluir NEW_IMAGE")
+	}
+
+	if v, ok := image[outboxCreatedAttribute]; ok && v.DataType() == events.DataTypeNumber {
+		if created, err := strconv.ParseInt(v.Number(), 10, 64); err == nil {
+			record.CreatedAt = createdAtTime(created)
+		}
+	}
+	if v, ok := image["attributes"]; ok && v.DataType() == events.DataTypeMap {
+		record.Attributes = make(map[string]string)
+		for k, attr := range v.Map() {
+			if attr.DataType() == events.DataTypeString {
+				record.Attributes[k] = attr.String()
+			}
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,177 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// pagedOutboxClient devolve os itens em páginas de pageSize, na ordem, e
+// registra as consultas e transações feitas
+type pagedOutboxClient struct {
+	items        []outboxItem
+	pageSize     int
+	queries      int
+	transactions []*dynamodb.TransactWriteItemsInput
+}
+
+func (c *pagedOutboxClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	c.queries++
+	start := 0
+	if params.ExclusiveStartKey != nil {
+		var last outboxItem
+		if err := attributevalue.UnmarshalMap(params.ExclusiveStartKey, &last); err != nil {
+			return nil, err
+		}
+		for i, item := range c.items {
+			if item.ID == last.ID {
+				start = i + 1
+			}
+		}
+	}
+
+	end := min(start+c.pageSize, len(c.items))
+	output := &dynamodb.QueryOutput{}
+	for _, item := range c.items[start:end] {
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return nil, err
+		}
+		output.Items = append(output.Items, av)
+	}
+	if end < len(c.items) {
+		output.LastEvaluatedKey = map[string]ddbtypes.AttributeValue{
+			outboxKeyAttribute: &ddbtypes.AttributeValueMemberS{Value: c.items[end-1].ID},
+		}
+	}
+	return output, nil
+}
+
+func (c *pagedOutboxClient) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	c.transactions = append(c.transactions, params)
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func (c *pagedOutboxClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+func (c *pagedOutboxClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+// recordIDs retorna os IDs dos registros, na ordem
+func recordIDs(records []OutboxRecord) []string {
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = record.ID
+	}
+	return ids
+}
+
+func TestOutboxPendingSkipsRecordsWaitingForRetry(t *testing.T) {
+	later := time.Now().Add(time.Hour).UnixMilli()
+	var items []outboxItem
+	// Os quatro registros mais antigos falharam e aguardam nova tentativa
+	for _, id := range []string{"w1", "w2", "w3", "w4"} {
+		items = append(items, outboxItem{ID: id, Status: OutboxStatusPending, Destination: "q", Attempts: 1, NextAttemptAt: later})
+	}
+	items = append(items,
+		outboxItem{ID: "r1", Status: OutboxStatusPending, Destination: "q"},
+		outboxItem{ID: "r2", Status: OutboxStatusPending, Destination: "q", Attempts: 2, NextAttemptAt: time.Now().Add(-time.Second).UnixMilli()},
+		outboxItem{ID: "r3", Status: OutboxStatusPending, Destination: "q"},
+	)
+	client := &pagedOutboxClient{items: items, pageSize: 2}
+	outbox := NewOutboxWithClient(client, "outbox")
+
+	records, err := outbox.Pending(context.Background(), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recordIDs(records); !reflect.DeepEqual(got, []string{"r1", "r2"}) {
+		t.Fatalf("pendentes: obtido %v", got)
+	}
+	if client.queries != 3 {
+		t.Fatalf("esperadas 3 páginas consultadas, obtidas %d", client.queries)
+	}
+}
+
+func TestOutboxPendingHoldsGroupBehindWaitingRecord(t *testing.T) {
+	later := time.Now().Add(time.Hour).UnixMilli()
+	client := &pagedOutboxClient{pageSize: 10, items: []outboxItem{
+		{ID: "a1", Status: OutboxStatusPending, Destination: "q.fifo", GroupID: "A", Attempts: 1, NextAttemptAt: later},
+		{ID: "b1", Status: OutboxStatusPending, Destination: "q.fifo", GroupID: "B"},
+		{ID: "a2", Status: OutboxStatusPending, Destination: "q.fifo", GroupID: "A"},
+		{ID: "a3", Status: OutboxStatusPending, Destination: "other.fifo", GroupID: "A"},
+		{ID: "x1", Status: OutboxStatusPending, Destination: "q.fifo"},
+	}}
+	outbox := NewOutboxWithClient(client, "outbox")
+
+	records, err := outbox.Pending(context.Background(), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recordIDs(records); !reflect.DeepEqual(got, []string{"b1", "a3", "x1"}) {
+		t.Fatalf("pendentes: obtido %v", got)
+	}
+}
+
+func TestOutboxWriteTransactionOrdersMessages(t *testing.T) {
+	client := &pagedOutboxClient{}
+	outbox := NewOutboxWithClient(client, "outbox")
+
+	before := time.Now().Truncate(time.Millisecond)
+	messages := make([]OutboxMessage, 5)
+	for i := range messages {
+		messages[i] = OutboxMessage{Destination: "fila", Message: i}
+	}
+	records, err := outbox.WriteTransaction(context.Background(), nil, messages...)
+	if err != nil {
+		t.Fatalf("WriteTransaction: %v", err)
+	}
+	if len(client.transactions) != 1 || len(client.transactions[0].TransactItems) != len(messages) {
+		t.Fatalf("transações gravadas: %v", client.transactions)
+	}
+
+	var previous int64
+	for i, write := range client.transactions[0].TransactItems {
+		var item outboxItem
+		if err := attributevalue.UnmarshalMap(write.Put.Item, &item); err != nil {
+			t.Fatalf("UnmarshalMap: %v", err)
+		}
+		if i > 0 && item.CreatedAt <= previous {
+			t.Fatalf("createdAt da mensagem %d (%d) não é maior que o da anterior (%d)", i, item.CreatedAt, previous)
+		}
+		previous = item.CreatedAt
+
+		if item.ID != records[i].ID {
+			t.Fatalf("registro %d: obtido %s, esperado %s", i, records[i].ID, item.ID)
+		}
+		if created := records[i].CreatedAt; created.Before(before) || created.After(time.Now()) {
+			t.Fatalf("CreatedAt do registro %d fora do intervalo da gravação: %v", i, created)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	cases := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{4, 40 * time.Second},
+		{20, 15 * time.Minute},
+	}
+	for _, c := range cases {
+		if got := retryBackoff(5*time.Second, c.attempts, 15*time.Minute); got != c.want {
+			t.Fatalf("retryBackoff após %d falhas: esperado %v, obtido %v", c.attempts, c.want, got)
+		}
+	}
+}
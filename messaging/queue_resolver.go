@@ -0,0 +1,159 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/silviomfa/go-cloud-aws/internal/sqsarn"
+)
+
+// queueResolver converte nomes, ARNs e URLs de filas em URLs, mantendo um
+// cache seguro para uso concorrente
+type queueResolver struct {
+	client   SQSAPI
+	local    bool
+	endpoint *url.URL
+
+	mu     sync.RWMutex
+	cache  map[string]string
+	owners map[string]string
+}
+
+// newQueueResolver cria um resolvedor; endpoint só é usado em ambiente local
+func newQueueResolver(client SQSAPI, local bool, endpoint string) *queueResolver {
+	r := &queueResolver{
+		client: client,
+		local:  local,
+		cache:  make(map[string]string),
+		owners: make(map[string]string),
+	}
+	if local && endpoint != "" {
+		if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+			r.endpoint = u
+		}
+	}
+	return r
+}
+
+// setOwner registra a conta proprietária de uma fila de outra conta
+func (r *queueResolver) setOwner(queueName, accountID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.owners[queueName] = accountID
+	delete(r.cache, queueName)
+}
+
+// invalidate remove uma fila do cache, forçando nova resolução
+func (r *queueResolver) invalidate(queue string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cache, queue)
+}
+
+// resolve retorna a URL da fila. Aceita:
+//   - URL completa (AWS ou endpoint local)
+//   - ARN no formato arn:aws:sqs:região:conta:nome, convertido diretamente na
+//     URL da região e conta do ARN, sem consulta ao SQS
+//   - nome simples, opcionalmente com conta proprietária registrada
+func (r *queueResolver) resolve(ctx context.Context, queue string) (string, error) {
+	if queue == "" {
+		return "", fmt.Errorf("nome da fila não informado")
+	}
+
+	r.mu.RLock()
+	cached, ok := r.cache[queue]
+	owner := r.owners[queue]
+	r.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	var queueURL string
+	switch {
+	case strings.HasPrefix(queue, "https://") || strings.HasPrefix(queue, "http://"):
+		// URLs não precisam de consulta; em ambiente local são reescritas
+		// para o endpoint configurado
+		queueURL = r.localizeURL(queue)
+
+	case strings.HasPrefix(queue, "arn:"):
+		// GetQueueUrl consultaria a região do cliente, onde pode existir
+		// outra fila com o mesmo nome; a URL é montada a partir do próprio ARN
+		arn, err := sqsarn.Parse(queue)
+		if err != nil {
+			return "", err
+		}
+		queueURL = r.localizeURL(arn.URL())
+
+	default:
+		var err error
+		if queueURL, err = r.lookup(ctx, queue, owner); err != nil {
+			return "", err
+		}
+	}
+
+	r.mu.Lock()
+	r.cache[queue] = queueURL
+	r.mu.Unlock()
+
+	return queueURL, nil
+}
+
+// lookup consulta a URL da fila via GetQueueUrl
+func (r *queueResolver) lookup(ctx context.Context, name, ownerAccountID string) (string, error) {
+	input := &sqs.GetQueueUrlInput{QueueName: aws.String(name)}
+	if ownerAccountID != "" {
+		input.QueueOwnerAWSAccountId = aws.String(ownerAccountID)
+	}
+
+	output, err := r.client.GetQueueUrl(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("erro ao obter URL da fila %s: %w", name, err)
+	}
+
+	queueURL := r.localizeURL(aws.ToString(output.QueueUrl))
+	log.Printf("Fila %s resolvida para %s", name, queueURL)
+	return queueURL, nil
+}
+
+// localizeURL reescreve o esquema e o host de uma URL de fila para o endpoint
+// local. Emuladores como ElasticMQ e LocalStack podem devolver hosts internos
+// do contêiner, e URLs da AWS configuradas para produção também passam a
+// funcionar localmente. O caminho (/conta/nome) é preservado.
+func (r *queueResolver) localizeURL(queueURL string) string {
+	if r.endpoint == nil {
+		return queueURL
+	}
+
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Host == r.endpoint.Host {
+		return queueURL
+	}
+
+	u.Scheme = r.endpoint.Scheme
+	u.Host = r.endpoint.Host
+	return u.String()
+}
+
+// QueueNameFromURL extrai o nome da fila do último segmento de uma URL de fila
+func QueueNameFromURL(queueURL string) string {
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return queueURL
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// isQueueDoesNotExist informa se o erro indica fila inexistente
+func isQueueDoesNotExist(err error) bool {
+	var notExist *types.QueueDoesNotExist
+	return errors.As(err, &notExist)
+}
@@ -0,0 +1,55 @@
+package messaging
+
+import (
+	"context"
+	"testing"
+)
+
+func TestQueueResolverARN(t *testing.T) {
+	r := newQueueResolver(NewMemorySQSClient(), false, "")
+
+	tests := []struct {
+		arn  string
+		want string
+	}{
+		{"arn:aws:sqs:us-east-1:111111111111:orders", "https://sqs.us-east-1.amazonaws.com/111111111111/orders"},
+		// A região do ARN prevalece sobre a região do cliente
+		{"arn:aws:sqs:eu-west-1:222222222222:orders", "https://sqs.eu-west-1.amazonaws.com/222222222222/orders"},
+		{"arn:aws-cn:sqs:cn-north-1:333333333333:orders.fifo", "https://sqs.cn-north-1.amazonaws.com.cn/333333333333/orders.fifo"},
+	}
+	for _, tt := range tests {
+		got, err := r.resolve(context.Background(), tt.arn)
+		if err != nil {
+			t.Fatalf("resolve(%s): %v", tt.arn, err)
+		}
+		if got != tt.want {
+			t.Errorf("resolve(%s) = %s, esperado %s", tt.arn, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"arn:aws:sns:us-east-1:111111111111:topic", "arn:aws:sqs::111111111111:orders", "arn:aws:sqs:us-east-1::orders"} {
+		if _, err := r.resolve(context.Background(), invalid); err == nil {
+			t.Errorf("resolve(%s) deveria falhar", invalid)
+		}
+	}
+}
+
+func TestQueueResolverLocalEndpoint(t *testing.T) {
+	r := newQueueResolver(NewMemorySQSClient(), true, "http://localhost:4566")
+
+	got, err := r.resolve(context.Background(), "arn:aws:sqs:us-east-1:000000000000:orders")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "http://localhost:4566/000000000000/orders" {
+		t.Fatalf("ARN em ambiente local: obtido %s", got)
+	}
+
+	got, err = r.resolve(context.Background(), "https://sqs.us-east-1.amazonaws.com/000000000000/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "http://localhost:4566/000000000000/other" {
+		t.Fatalf("URL em ambiente local: obtido %s", got)
+	}
+}
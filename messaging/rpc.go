@@ -0,0 +1,441 @@
+package messaging
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Atributos de mensagem do protocolo de requisição e resposta
+const (
+	// CorrelationIDAttribute liga a resposta à requisição
+	CorrelationIDAttribute = "correlationId"
+	// ReplyToAttribute é a URL da fila que recebe a resposta
+	ReplyToAttribute = "replyTo"
+	// ReplyErrorAttribute, na resposta, indica que o handler falhou e traz a mensagem de erro
+	ReplyErrorAttribute = "replyError"
+)
+
+// Tags das filas de resposta, usadas por CleanupResponseQueues
+const (
+	ResponseQueueTag         = "go-cloud-aws:response-queue"
+	ResponseQueueLastSeenTag = "go-cloud-aws:last-seen"
+)
+
+// Valores padrão do requisitante
+const (
+	DefaultResponseQueuePrefix     = "rpc-reply-"
+	DefaultRequestTimeout          = 30 * time.Second
+	DefaultResponseQueueHeartbeat  = 5 * time.Minute
+	responseQueueRetentionSeconds  = "300"
+	responseQueueReceiveWaitTime   = 20
+	responseQueueReceiveBatchLimit = 10
+)
+
+// ReplyError é o erro retornado pelo handler remoto
+type ReplyError struct {
+	Message string
+}
+
+// Error retorna a mensagem do erro remoto
+func (e *ReplyError) Error() string {
+	return "erro no handler remoto: " + e.Message
+}
+
+// RequesterOptions configura o requisitante
+type RequesterOptions struct {
+	// QueuePrefix é o prefixo do nome da fila de resposta (padrão "rpc-reply-")
+	QueuePrefix string
+	// DefaultTimeout limita a espera quando o contexto não tem prazo (padrão 30s)
+	DefaultTimeout time.Duration
+	// HeartbeatInterval é o intervalo de atualização da tag de atividade da
+	// fila de resposta (padrão 5 min)
+	HeartbeatInterval time.Duration
+}
+
+// Requester envia requisições por SQS e espera as respostas em uma fila
+// exclusiva do processo, criada no primeiro uso e removida em Close
+type Requester struct {
+	provider *SQSProvider
+	options  RequesterOptions
+
+	mu       sync.Mutex
+	queueURL string
+	pending  map[string]chan ReceivedMessage
+	stop     context.CancelFunc
+	done     chan struct{}
+}
+
+// NewRequester cria um requisitante. A fila de resposta só é criada na
+// primeira requisição.
+func (p *SQSProvider) NewRequester(options RequesterOptions) *Requester {
+	if options.QueuePrefix == "" {
+		options.QueuePrefix = DefaultResponseQueuePrefix
+	}
+	if options.DefaultTimeout <= 0 {
+		options.DefaultTimeout = DefaultRequestTimeout
+	}
+	if options.HeartbeatInterval <= 0 {
+		options.HeartbeatInterval = DefaultResponseQueueHeartbeat
+	}
+
+	return &Requester{
+		provider: p,
+		options:  options,
+		pending:  make(map[string]chan ReceivedMessage),
+	}
+}
+
+// ResponseQueue retorna a URL da fila de resposta, criando-a se necessário.
+// A fila é marcada com ResponseQueueTag e a tag ResponseQueueLastSeenTag é
+// atualizada enquanto o requisitante estiver ativo.
+func (r *Requester) ResponseQueue(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queueURL != "" {
+		return r.queueURL, nil
+	}
+
+	name := r.options.QueuePrefix + uuid.New().String()
+	output, err := r.provider.client.CreateQueue(ctx, &sqs.CreateQueueInput{
+		QueueName: aws.String(name),
+		Attributes: map[string]string{
+			"MessageRetentionPeriod": responseQueueRetentionSeconds,
+		},
+		Tags: map[string]string{
+			ResponseQueueTag:         "true",
+			ResponseQueueLastSeenTag: strconv.FormatInt(time.Now().Unix(), 10),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao criar fila de resposta %s: %w", name, err)
+	}
+	r.queueURL = aws.ToString(output.QueueUrl)
+	log.Printf("Fila de resposta %s criada", name)
+
+	// O ouvinte vive até Close, independente do contexto da requisição
+	listenCtx, stop := context.WithCancel(context.Background())
+	r.stop = stop
+	r.done = make(chan struct{})
+	go r.listen(listenCtx, r.queueURL, r.done)
+
+	return r.queueURL, nil
+}
+
+// Request envia a requisição e espera a resposta correspondente até o prazo
+// do contexto (ou DefaultTimeout, se não houver). Se o handler remoto falhar,
+// retorna a resposta e um *ReplyError.
+func (r *Requester) Request(ctx context.Context, queueName string, message interface{}, opts SendOptions) (ReceivedMessage, error) {
+	replyTo, err := r.ResponseQueue(ctx)
+	if err != nil {
+		return ReceivedMessage{}, err
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.options.DefaultTimeout)
+		defer cancel()
+	}
+
+	correlationID := uuid.New().String()
+	responses := make(chan ReceivedMessage, 1)
+	r.mu.Lock()
+	r.pending[correlationID] = responses
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, correlationID)
+		r.mu.Unlock()
+	}()
+
+	attributes := make(map[string]MessageAttribute, len(opts.Attributes)+2)
+	for k, v := range opts.Attributes {
+		attributes[k] = v
+	}
+	attributes[CorrelationIDAttribute] = StringAttribute(correlationID)
+	attributes[ReplyToAttribute] = StringAttribute(replyTo)
+	opts.Attributes = attributes
+
+	if _, err := r.provider.SendMessageWithOptions(ctx, queueName, message, opts); err != nil {
+		return ReceivedMessage{}, fmt.Errorf("erro ao enviar requisição para %s: %w", queueName, err)
+	}
+
+	select {
+	case response := <-responses:
+		if replyErr, ok := response.Attributes[ReplyErrorAttribute]; ok {
+			return response, &ReplyError{Message: replyErr.StringValue}
+		}
+		return response, nil
+	case <-ctx.Done():
+		return ReceivedMessage{}, fmt.Errorf("sem resposta de %s para a requisição %s: %w", queueName, correlationID, ctx.Err())
+	}
+}
+
+// Call envia a requisição e desserializa a resposta em T
+func Call[T any](ctx context.Context, r *Requester, queueName string, message interface{}) (T, error) {
+	var zero T
+	response, err := r.Request(ctx, queueName, message, SendOptions{})
+	if err != nil {
+		return zero, err
+	}
+
+	value, target := newDecodeTarget[T]()
+	if err := r.provider.DecodeMessage(response, target); err != nil {
+		return zero, err
+	}
+	return *value, nil
+}
+
+// Close encerra o ouvinte e remove a fila de resposta
+func (r *Requester) Close(ctx context.Context) error {
+	r.mu.Lock()
+	queueURL, stop, done := r.queueURL, r.stop, r.done
+	r.queueURL, r.stop, r.done = "", nil, nil
+	r.mu.Unlock()
+	if queueURL == "" {
+		return nil
+	}
+
+	stop()
+	<-done
+
+	if _, err := r.provider.client.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(queueURL)}); err != nil {
+		return fmt.Errorf("erro ao remover fila de resposta %s: %w", queueURL, err)
+	}
+	log.Printf("Fila de resposta %s removida", QueueNameFromURL(queueURL))
+	return nil
+}
+
+// listen recebe as respostas, entrega cada uma à requisição em espera e as
+// remove da fila. Respostas sem requisição em espera (atrasadas) são descartadas.
+func (r *Requester) listen(ctx context.Context, queueURL string, done chan struct{}) {
+	defer close(done)
+
+	heartbeat := time.NewTicker(r.options.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	backoff := time.Duration(0)
+	for ctx.Err() == nil {
+		select {
+		case <-heartbeat.C:
+			r.heartbeat(ctx, queueURL)
+		default:
+		}
+
+		messages, err := r.provider.ReceiveMessagesWithOptions(ctx, queueURL, ReceiveOptions{
+			MaxMessages:     responseQueueReceiveBatchLimit,
+			WaitTimeSeconds: responseQueueReceiveWaitTime,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Erro ao receber respostas da fila %s: %v", queueURL, err)
+			backoff = nextBackoff(backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+
+		handles := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			handles = append(handles, msg.ReceiptHandle)
+
+			correlationID := msg.Attributes[CorrelationIDAttribute].StringValue
+			r.mu.Lock()
+			responses, ok := r.pending[correlationID]
+			r.mu.Unlock()
+			if !ok {
+				log.Printf("Resposta %s sem requisição em espera descartada", correlationID)
+				continue
+			}
+			select {
+			case responses <- msg:
+			default:
+			}
+		}
+		if len(handles) > 0 {
+			if _, err := r.provider.DeleteMessages(context.WithoutCancel(ctx), queueURL, handles); err != nil {
+				log.Printf("Erro ao remover respostas da fila %s: %v", queueURL, err)
+			}
+		}
+	}
+}
+
+// heartbeat atualiza a tag de atividade da fila de resposta
+func (r *Requester) heartbeat(ctx context.Context, queueURL string) {
+	_, err := r.provider.client.TagQueue(ctx, &sqs.TagQueueInput{
+		QueueUrl: aws.String(queueURL),
+		Tags:     map[string]string{ResponseQueueLastSeenTag: strconv.FormatInt(time.Now().Unix(), 10)},
+	})
+	if err != nil {
+		log.Printf("Erro ao atualizar atividade da fila %s: %v", queueURL, err)
+	}
+}
+
+// CleanupResponseQueues remove as filas de resposta com o prefixo informado
+// cuja tag de atividade não é atualizada há mais de idleFor, deixadas por
+// processos encerrados sem Close. Retorna quantas filas foram removidas.
+func (p *SQSProvider) CleanupResponseQueues(ctx context.Context, prefix string, idleFor time.Duration) (int, error) {
+	if prefix == "" {
+		prefix = DefaultResponseQueuePrefix
+	}
+	cutoff := time.Now().Add(-idleFor).Unix()
+
+	removed := 0
+	input := &sqs.ListQueuesInput{QueueNamePrefix: aws.String(prefix)}
+	for {
+		output, err := p.client.ListQueues(ctx, input)
+		if err != nil {
+			return removed, fmt.Errorf("erro ao listar filas %s*: %w", prefix, err)
+		}
+
+		for _, queueURL := range output.QueueUrls {
+			tags, err := p.client.ListQueueTags(ctx, &sqs.ListQueueTagsInput{QueueUrl: aws.String(queueURL)})
+			if err != nil {
+				log.Printf("Erro ao ler tags da fila %s: %v", queueURL, err)
+				continue
+			}
+			if tags.Tags[ResponseQueueTag] != "true" {
+				continue
+			}
+			lastSeen, err := strconv.ParseInt(tags.Tags[ResponseQueueLastSeenTag], 10, 64)
+			if err == nil && lastSeen >= cutoff {
+				continue
+			}
+
+			if _, err := p.client.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(queueURL)}); err != nil {
+				log.Printf("Erro ao remover fila de resposta %s: %v", queueURL, err)
+				continue
+			}
+			log.Printf("Fila de resposta abandonada %s removida", QueueNameFromURL(queueURL))
+			removed++
+		}
+
+		if output.NextToken == nil {
+			return removed, nil
+		}
+		input.NextToken = output.NextToken
+	}
+}
+
+// Reply envia a resposta de uma requisição para a fila indicada em
+// ReplyToAttribute, com o mesmo CorrelationIDAttribute. Com handlerErr, a
+// resposta leva ReplyErrorAttribute e o requisitante recebe um *ReplyError.
+func (p *SQSProvider) Reply(ctx context.Context, request ReceivedMessage, response interface{}, handlerErr error) error {
+	return p.reply(ctx, stringAttributes(request.Attributes), response, handlerErr)
+}
+
+// ReplyToEvent responde a uma requisição recebida como evento, por exemplo em
+// uma Lambda acionada por SQS, usando os atributos em Metadata["messageAttributes"]
+func (p *SQSProvider) ReplyToEvent(ctx context.Context, event coreinterfaces.Event, response interface{}, handlerErr error) error {
+	return p.reply(ctx, eventMessageAttributes(event), response, handlerErr)
+}
+
+// ReplyHandler adapta um GenericHandler para responder requisições no
+// Consumer. Cada mensagem vira um evento; o resultado, ou o erro, é enviado à
+// fila de resposta e a requisição é removida. Mensagens sem ReplyToAttribute
+// são processadas normalmente, sem resposta.
+func (p *SQSProvider) ReplyHandler(handler coreinterfaces.GenericHandler) MessageHandler {
+	return func(ctx context.Context, msg coreinterfaces.Message) error {
+		received, ok := ReceivedMessageFromContext(ctx)
+		if !ok {
+			received = ReceivedMessage{Message: msg}
+		}
+
+		event := requestEvent(received)
+		result, handlerErr := handler.Handle(ctx, event)
+		if received.Attributes[ReplyToAttribute].StringValue == "" {
+			return handlerErr
+		}
+		return p.Reply(ctx, received, result, handlerErr)
+	}
+}
+
+// reply envia a resposta conforme os atributos da requisição
+func (p *SQSProvider) reply(ctx context.Context, requestAttributes map[string]string, response interface{}, handlerErr error) error {
+	replyTo := requestAttributes[ReplyToAttribute]
+	if replyTo == "" {
+		return fmt.Errorf("requisição sem o atributo %s", ReplyToAttribute)
+	}
+
+	attributes := map[string]MessageAttribute{
+		CorrelationIDAttribute: StringAttribute(requestAttributes[CorrelationIDAttribute]),
+	}
+	if handlerErr != nil {
+		attributes[ReplyErrorAttribute] = StringAttribute(handlerErr.Error())
+		response = nil
+	}
+
+	if _, err := p.SendMessageWithOptions(ctx, replyTo, response, SendOptions{Attributes: attributes}); err != nil {
+		return fmt.Errorf("erro ao enviar resposta para %s: %w", replyTo, err)
+	}
+	return nil
+}
+
+// requestEvent converte a requisição no evento entregue ao GenericHandler,
+// pela mesma conversão do adaptador de eventos SQS, com o ID de correlação em
+// RequestID
+func requestEvent(msg ReceivedMessage) coreinterfaces.Event {
+	message := events.SQSMessage{
+		MessageId:         msg.ID,
+		ReceiptHandle:     msg.ReceiptHandle,
+		Body:              string(msg.Body),
+		Attributes:        msg.SystemAttributes,
+		MessageAttributes: make(map[string]events.SQSMessageAttribute, len(msg.Attributes)),
+	}
+	for name, attr := range msg.Attributes {
+		converted := events.SQSMessageAttribute{DataType: attr.DataType, BinaryValue: attr.BinaryValue}
+		if attr.BaseType() != AttributeTypeBinary {
+			converted.StringValue = aws.String(attr.StringValue)
+		}
+		message.MessageAttributes[name] = converted
+	}
+
+	event := adapter.ConvertSQSMessage(message)
+	if correlationID := msg.Attributes[CorrelationIDAttribute].StringValue; correlationID != "" {
+		event.RequestID = correlationID
+	}
+	return event
+}
+
+// stringAttributes extrai os valores dos atributos do tipo String e Number
+func stringAttributes(attributes map[string]MessageAttribute) map[string]string {
+	values := make(map[string]string, len(attributes))
+	for k, v := range attributes {
+		if v.BaseType() != AttributeTypeBinary {
+			values[k] = v.StringValue
+		}
+	}
+	return values
+}
+
+// eventMessageAttributes lê os atributos de mensagem dos metadados do evento
+func eventMessageAttributes(event coreinterfaces.Event) map[string]string {
+	switch attributes := event.Metadata["messageAttributes"].(type) {
+	case map[string]string:
+		return attributes
+	case map[string]interface{}:
+		values := make(map[string]string, len(attributes))
+		for k, v := range attributes {
+			values[k] = strings.TrimSpace(fmt.Sprint(v))
+		}
+		return values
+	}
+	return nil
+}
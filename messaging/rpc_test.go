@@ -0,0 +1,69 @@
+package messaging
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// eventRecorder é um GenericHandler que guarda o evento recebido e responde result
+type eventRecorder struct {
+	event  coreinterfaces.Event
+	result interface{}
+}
+
+func (h *eventRecorder) Handle(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+	h.event = event
+	return h.result, nil
+}
+
+func TestReplyHandlerUsesAdapterEvent(t *testing.T) {
+	client := NewMemorySQSClient()
+	client.DefineQueue("requests", nil)
+	replyURL := client.DefineQueue("replies", nil)
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	_, err := p.SendMessageWithOptions(ctx, "requests", "ping", SendOptions{Attributes: map[string]MessageAttribute{
+		ReplyToAttribute:       StringAttribute(replyURL),
+		CorrelationIDAttribute: StringAttribute("c-1"),
+	}})
+	if err != nil {
+		t.Fatalf("SendMessageWithOptions: %v", err)
+	}
+	received, err := p.ReceiveMessagesWithOptions(ctx, "requests", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(received) != 1 {
+		t.Fatalf("ReceiveMessagesWithOptions: %v (%d mensagens)", err, len(received))
+	}
+
+	handler := &eventRecorder{result: "pong"}
+	if err := p.ReplyHandler(handler)(withReceivedMessage(ctx, received[0]), received[0].Message); err != nil {
+		t.Fatalf("ReplyHandler: %v", err)
+	}
+
+	// Mesmo formato que o handler recebe na Lambda
+	expected := adapter.ConvertSQSMessage(events.SQSMessage{})
+	event := handler.event
+	if event.Source != expected.Source || event.Type != expected.Type {
+		t.Fatalf("evento: Source=%s, Type=%s; esperado %s, %s", event.Source, event.Type, expected.Source, expected.Type)
+	}
+	if event.ID != received[0].ID || string(event.Data) != `"ping"` || event.RequestID != "c-1" {
+		t.Fatalf("evento: ID=%s, Data=%s, RequestID=%s", event.ID, event.Data, event.RequestID)
+	}
+	want := map[string]string{ReplyToAttribute: replyURL, CorrelationIDAttribute: "c-1"}
+	if got := event.Metadata["messageAttributes"]; !reflect.DeepEqual(got, want) {
+		t.Fatalf("messageAttributes: obtido %#v", got)
+	}
+
+	replies, err := p.ReceiveMessagesWithOptions(ctx, "replies", ReceiveOptions{MaxMessages: 1})
+	if err != nil || len(replies) != 1 {
+		t.Fatalf("resposta: %v (%d mensagens)", err, len(replies))
+	}
+	if string(replies[0].Body) != `"pong"` || replies[0].Attributes[CorrelationIDAttribute].StringValue != "c-1" {
+		t.Fatalf("resposta: corpo %s, atributos %v", replies[0].Body, replies[0].Attributes)
+	}
+}
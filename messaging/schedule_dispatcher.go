@@ -0,0 +1,226 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// DispatcherOptions configura o despachante de agendamentos
+type DispatcherOptions struct {
+	// PollInterval é o intervalo entre consultas quando não há vencidos (padrão 5s)
+	PollInterval time.Duration
+	// BatchSize é o número de agendamentos lidos por consulta (padrão 25)
+	BatchSize int
+	// LeaseDuration é o tempo de posse de um agendamento em despacho; se o
+	// despachante falhar, outro o assume depois desse tempo (padrão 1 min)
+	LeaseDuration time.Duration
+	// DispatchAhead antecipa o despacho para filas SQS padrão, usando
+	// DelaySeconds para o tempo restante, o que mantém a precisão com consultas
+	// espaçadas (máximo 15 minutos). Outros destinos são despachados no vencimento.
+	DispatchAhead time.Duration
+	// DispatchedRetention, se maior que zero, define o TTL dos despachados
+	DispatchedRetention time.Duration
+	// MaxAttempts é o número de despachos tentados por agendamento (padrão
+	// 10). Esgotadas as tentativas o agendamento passa para failed.
+	MaxAttempts int
+	// RetryBackoff é o adiamento do vencimento após a primeira falha de um
+	// agendamento, dobrado a cada nova falha até 15 minutos (padrão 5s)
+	RetryBackoff time.Duration
+	// ErrorHandler é chamado quando um despacho falha. Se nil, o erro é
+	// registrado no log.
+	ErrorHandler func(msg ScheduledMessage, err error)
+}
+
+// ScheduleDispatcher publica os agendamentos vencidos. Cada agendamento é
+// obtido com escrita condicional antes da publicação, de modo que várias
+// instâncias podem rodar sem publicar o mesmo agendamento duas vezes; apenas
+// uma falha entre a publicação e a conclusão faz outra instância repeti-lo,
+// com o mesmo ID de deduplicação e o atributo ScheduleIDAttribute.
+type ScheduleDispatcher struct {
+	scheduler *Scheduler
+	publisher coreinterfaces.MessagingProvider
+	options   DispatcherOptions
+	owner     string
+}
+
+// NewDispatcher cria um despachante que publica pelo provedor de mensageria informado
+func (s *Scheduler) NewDispatcher(publisher coreinterfaces.MessagingProvider, options DispatcherOptions) *ScheduleDispatcher {
+	if options.PollInterval <= 0 {
+		options.PollInterval = DefaultSchedulerPollInterval
+	}
+	if options.BatchSize <= 0 {
+		options.BatchSize = DefaultSchedulerBatchSize
+	}
+	if options.LeaseDuration <= 0 {
+		options.LeaseDuration = DefaultSchedulerLeaseDuration
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = DefaultSchedulerMaxAttempts
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = DefaultSchedulerRetryBackoff
+	}
+	if options.DispatchAhead < 0 {
+		options.DispatchAhead = 0
+	}
+	if options.DispatchAhead > maxSQSDelay {
+		options.DispatchAhead = maxSQSDelay
+	}
+
+	return &ScheduleDispatcher{
+		scheduler: s,
+		publisher: publisher,
+		options:   options,
+		owner:     uuid.New().String(),
+	}
+}
+
+// Run consulta os vencidos periodicamente até o contexto ser cancelado
+func (d *ScheduleDispatcher) Run(ctx context.Context) error {
+	log.Printf("Iniciando despachante de agendamentos da tabela %s", d.scheduler.tableName)
+
+	backoff := time.Duration(0)
+	for {
+		dispatched, err := d.DispatchDue(ctx)
+		if ctx.Err() != nil {
+			log.Printf("Despachante de agendamentos da tabela %s encerrado", d.scheduler.tableName)
+			return nil
+		}
+
+		wait := d.options.PollInterval
+		switch {
+		case err != nil:
+			log.Printf("Erro no despachante de agendamentos da tabela %s: %v", d.scheduler.tableName, err)
+			backoff = nextBackoff(backoff)
+			wait = backoff
+		case dispatched > 0:
+			// Pode haver mais vencidos; consultar de novo sem esperar
+			backoff = 0
+			continue
+		default:
+			backoff = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Printf("Despachante de agendamentos da tabela %s encerrado", d.scheduler.tableName)
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// DispatchDue publica um lote de agendamentos vencidos, incluindo os que
+// ficaram em despacho com a posse expirada, e retorna quantos foram publicados
+func (d *ScheduleDispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	pending, err := d.scheduler.due(ctx, ScheduleStatusPending, now.Add(d.options.DispatchAhead), d.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	stale, err := d.scheduler.due(ctx, ScheduleStatusDispatching, now, d.options.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for _, msg := range append(pending, stale...) {
+		if ctx.Err() != nil {
+			return dispatched, ctx.Err()
+		}
+
+		// Antecipação só vale para filas SQS padrão, que aceitam atraso
+		if msg.DueAt.After(now) && !d.canDelay(msg.Destination) {
+			continue
+		}
+
+		ok, err := d.dispatch(ctx, msg)
+		if err != nil {
+			d.reportError(msg, err)
+			continue
+		}
+		if ok {
+			dispatched++
+		}
+	}
+
+	if dispatched > 0 {
+		log.Printf("Agendamentos da tabela %s: %d despachados", d.scheduler.tableName, dispatched)
+	}
+	return dispatched, nil
+}
+
+// dispatch obtém a posse, publica e conclui um agendamento. Retorna false se
+// outro despachante obteve a posse ou o agendamento foi cancelado.
+func (d *ScheduleDispatcher) dispatch(ctx context.Context, msg ScheduledMessage) (bool, error) {
+	claimed, err := d.scheduler.claim(ctx, msg.ID, d.owner, d.options.LeaseDuration)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	attributes := map[string]MessageAttribute{
+		ScheduleIDAttribute: StringAttribute(msg.ID),
+	}
+	for k, v := range msg.Attributes {
+		attributes[k] = StringAttribute(v)
+	}
+
+	var delaySeconds int32
+	if remaining := time.Until(msg.DueAt); remaining > 0 {
+		delaySeconds = int32(math.Ceil(remaining.Seconds()))
+	}
+
+	if err := publishBody(ctx, d.publisher, msg.Destination, msg.Body, attributes, msg.GroupID, msg.DeduplicationID, delaySeconds); err != nil {
+		d.release(ctx, msg, err)
+		return false, err
+	}
+
+	if err := d.scheduler.complete(ctx, msg.ID, d.owner, d.options.DispatchedRetention); err != nil {
+		if errors.Is(err, ErrLeaseLost) {
+			// Outra instância assumiu após a posse expirar e pode publicar de novo
+			log.Printf("Posse do agendamento %s perdida após a publicação", msg.ID)
+			return true, nil
+		}
+		return true, err
+	}
+	return true, nil
+}
+
+// release adia o agendamento conforme RetryBackoff após uma falha ou,
+// esgotadas as tentativas, marca-o como failed
+func (d *ScheduleDispatcher) release(ctx context.Context, msg ScheduledMessage, cause error) {
+	attempts := msg.Attempts + 1
+	var retryAt time.Time
+	if attempts < d.options.MaxAttempts {
+		retryAt = time.Now().Add(retryBackoff(d.options.RetryBackoff, attempts, schedulerMaxRetryBackoff))
+	}
+
+	if err := d.scheduler.release(context.WithoutCancel(ctx), msg.ID, d.owner, cause, retryAt); err != nil {
+		log.Printf("Erro ao liberar agendamento %s: %v", msg.ID, err)
+		return
+	}
+	if retryAt.IsZero() {
+		log.Printf("Agendamento %s marcado como %s após %d tentativas", msg.ID, ScheduleStatusFailed, attempts)
+	}
+}
+
+// canDelay informa se o destino aceita atraso por mensagem
+func (d *ScheduleDispatcher) canDelay(destination string) bool {
+	_, ok := d.publisher.(*SQSProvider)
+	return ok && !IsFIFOQueue(destination)
+}
+
+// reportError repassa a falha ao ErrorHandler ou registra no log
+func (d *ScheduleDispatcher) reportError(msg ScheduledMessage, err error) {
+	if d.options.ErrorHandler != nil {
+		d.options.ErrorHandler(msg, err)
+		return
+	}
+	log.Printf("Erro ao despachar agendamento %s para %s: %v", msg.ID, msg.Destination, err)
+}
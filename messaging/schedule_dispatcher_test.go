@@ -0,0 +1,120 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// recordingSchedulerClient devolve os agendamentos pendentes informados e
+// aceita todas as escritas, registrando-as
+type recordingSchedulerClient struct {
+	mu      sync.Mutex
+	pending []scheduleItem
+	updates []*dynamodb.UpdateItemInput
+}
+
+func (c *recordingSchedulerClient) Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	output := &dynamodb.QueryOutput{}
+	status := params.ExpressionAttributeValues[":status"].(*ddbtypes.AttributeValueMemberS).Value
+	if status != ScheduleStatusPending {
+		return output, nil
+	}
+	for _, item := range c.pending {
+		av, err := attributevalue.MarshalMap(item)
+		if err != nil {
+			return nil, err
+		}
+		output.Items = append(output.Items, av)
+	}
+	return output, nil
+}
+
+func (c *recordingSchedulerClient) UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.updates = append(c.updates, params)
+	return &dynamodb.UpdateItemOutput{}, nil
+}
+
+func (c *recordingSchedulerClient) PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+func (c *recordingSchedulerClient) GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	return nil, errors.New("não suportado")
+}
+
+// lastUpdate retorna a última escrita registrada
+func (c *recordingSchedulerClient) lastUpdate(t *testing.T) *dynamodb.UpdateItemInput {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.updates) == 0 {
+		t.Fatal("nenhuma escrita registrada")
+	}
+	return c.updates[len(c.updates)-1]
+}
+
+// dispatchFailing despacha um agendamento vencido para uma fila inexistente,
+// o que faz a publicação falhar, e retorna a escrita de liberação
+func dispatchFailing(t *testing.T, attempts int) *dynamodb.UpdateItemInput {
+	t.Helper()
+	client := &recordingSchedulerClient{pending: []scheduleItem{{
+		ID:          "s-1",
+		Status:      ScheduleStatusPending,
+		DueAt:       time.Now().Add(-time.Minute).UnixMilli(),
+		Destination: "missing",
+		Body:        `"x"`,
+		Attempts:    attempts,
+	}}}
+	publisher := NewSQSProviderWithClient(NewMemorySQSClient(), nil)
+	dispatcher := NewSchedulerWithClient(client, "schedules").NewDispatcher(publisher, DispatcherOptions{MaxAttempts: 3, RetryBackoff: 10 * time.Second})
+
+	var reported error
+	dispatcher.options.ErrorHandler = func(msg ScheduledMessage, err error) { reported = err }
+	if dispatched, err := dispatcher.DispatchDue(context.Background()); err != nil || dispatched != 0 {
+		t.Fatalf("DispatchDue: %d despachados, erro %v", dispatched, err)
+	}
+	if reported == nil {
+		t.Fatal("a falha de publicação deveria ser reportada")
+	}
+	return client.lastUpdate(t)
+}
+
+func TestDispatcherPostponesFailedSchedule(t *testing.T) {
+	before := time.Now()
+	update := dispatchFailing(t, 1)
+
+	if got := update.ExpressionAttributeValues[":status"].(*ddbtypes.AttributeValueMemberS).Value; got != ScheduleStatusPending {
+		t.Fatalf("situação após a falha: esperado %s, obtido %s", ScheduleStatusPending, got)
+	}
+	due, ok := update.ExpressionAttributeValues[":due"].(*ddbtypes.AttributeValueMemberN)
+	if !ok {
+		t.Fatalf("o vencimento deveria ser adiado: %s", aws.ToString(update.UpdateExpression))
+	}
+	ms, _ := strconv.ParseInt(due.Value, 10, 64)
+	// Segunda falha: o adiamento dobra para 20s
+	if wait := time.UnixMilli(ms).Sub(before); wait < 19*time.Second || wait > 21*time.Second {
+		t.Fatalf("adiamento inesperado: %v", wait)
+	}
+}
+
+func TestDispatcherFailsScheduleAfterMaxAttempts(t *testing.T) {
+	update := dispatchFailing(t, 2)
+
+	if got := update.ExpressionAttributeValues[":status"].(*ddbtypes.AttributeValueMemberS).Value; got != ScheduleStatusFailed {
+		t.Fatalf("situação após esgotar as tentativas: esperado %s, obtido %s", ScheduleStatusFailed, got)
+	}
+	if _, ok := update.ExpressionAttributeValues[":due"]; ok {
+		t.Fatal("agendamento failed não deveria ter o vencimento adiado")
+	}
+}
@@ -0,0 +1,438 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	ddbtypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/provider"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Situação dos agendamentos
+const (
+	ScheduleStatusPending     = "pending"
+	ScheduleStatusDispatching = "dispatching"
+	ScheduleStatusDispatched  = "dispatched"
+	ScheduleStatusCancelled   = "cancelled"
+	ScheduleStatusFailed      = "failed"
+)
+
+// Atributos da tabela de agendamentos. A chave de partição, do tipo string,
+// deve se chamar "scheduleId". A consulta dos vencidos usa um índice global
+// com chave de partição "status" e chave de ordenação "dueAt" (número).
+const (
+	scheduleKeyAttribute      = "scheduleId"
+	scheduleStatusAttribute   = "status"
+	scheduleDueAttribute      = "dueAt"
+	scheduleOwnerAttribute    = "leaseOwner"
+	scheduleLeaseAttribute    = "leaseExpires"
+	scheduleDispatchAttribute = "dispatchedAt"
+	scheduleExpiresAttribute  = "expiresAt"
+	scheduleAttemptsAttribute = "attempts"
+	scheduleErrorAttribute    = "lastError"
+)
+
+// Valores padrão do agendador
+const (
+	DefaultScheduleDueIndex       = "status-dueAt-index"
+	DefaultSchedulerPollInterval  = 5 * time.Second
+	DefaultSchedulerBatchSize     = 25
+	DefaultSchedulerLeaseDuration = time.Minute
+	DefaultSchedulerMaxAttempts   = 10
+	DefaultSchedulerRetryBackoff  = 5 * time.Second
+
+	// schedulerMaxRetryBackoff limita a espera entre tentativas de um agendamento
+	schedulerMaxRetryBackoff = 15 * time.Minute
+
+	// maxSQSDelay é o maior atraso por mensagem aceito pelo SQS
+	maxSQSDelay = 15 * time.Minute
+)
+
+// ScheduleIDAttribute é o atributo de mensagem com o ID do agendamento
+const ScheduleIDAttribute = "scheduleId"
+
+// ErrScheduleNotPending indica que o agendamento não existe ou já foi
+// despachado ou cancelado
+var ErrScheduleNotPending = errors.New("agendamento não está pendente")
+
+// ErrScheduleExists indica que já existe um agendamento com o ID informado
+var ErrScheduleExists = errors.New("agendamento já existe")
+
+// SchedulerAPI define as operações do cliente DynamoDB utilizadas pelo
+// agendador. É satisfeita por *dynamodb.Client.
+type SchedulerAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// ScheduleOptions configura um agendamento
+type ScheduleOptions struct {
+	// ScheduleID identifica o agendamento; se vazio, é gerado. Informar o ID
+	// torna o agendamento idempotente: repetir retorna ErrScheduleExists.
+	ScheduleID string
+	// GroupID é o grupo da mensagem em destinos FIFO
+	GroupID string
+	// DeduplicationID é usado em destinos FIFO; se vazio, é o ID do agendamento
+	DeduplicationID string
+	// Attributes são atributos de mensagem do tipo String
+	Attributes map[string]string
+}
+
+// ScheduledMessage é uma mensagem agendada
+type ScheduledMessage struct {
+	ID              string
+	Destination     string
+	Body            json.RawMessage
+	DueAt           time.Time
+	Status          string
+	GroupID         string
+	DeduplicationID string
+	Attributes      map[string]string
+	CreatedAt       time.Time
+	// Attempts é o número de despachos que falharam
+	Attempts int
+	// LastError é o erro do último despacho que falhou
+	LastError string
+}
+
+// scheduleItem é a representação do agendamento na tabela
+type scheduleItem struct {
+	ID              string            `dynamodbav:"scheduleId"`
+	Status          string            `dynamodbav:"status"`
+	DueAt           int64             `dynamodbav:"dueAt"`
+	Destination     string            `dynamodbav:"destination"`
+	Body            string            `dynamodbav:"body"`
+	GroupID         string            `dynamodbav:"groupId,omitempty"`
+	DeduplicationID string            `dynamodbav:"deduplicationId"`
+	Attributes      map[string]string `dynamodbav:"attributes,omitempty"`
+	CreatedAt       int64             `dynamodbav:"createdAt"`
+	Attempts        int               `dynamodbav:"attempts,omitempty"`
+	LastError       string            `dynamodbav:"lastError,omitempty"`
+}
+
+// message converte o item em mensagem agendada
+func (i scheduleItem) message() ScheduledMessage {
+	return ScheduledMessage{
+		ID:              i.ID,
+		Destination:     i.Destination,
+		Body:            json.RawMessage(i.Body),
+		DueAt:           time.UnixMilli(i.DueAt),
+		Status:          i.Status,
+		GroupID:         i.GroupID,
+		DeduplicationID: i.DeduplicationID,
+		Attributes:      i.Attributes,
+		CreatedAt:       time.UnixMilli(i.CreatedAt),
+		Attempts:        i.Attempts,
+		LastError:       i.LastError,
+	}
+}
+
+// Scheduler agenda mensagens para qualquer instante futuro, além do limite de
+// 15 minutos do DelaySeconds do SQS. Os agendamentos ficam em uma tabela
+// DynamoDB e são publicados pelo ScheduleDispatcher no vencimento.
+type Scheduler struct {
+	client    SchedulerAPI
+	tableName string
+	dueIndex  string
+}
+
+// NewScheduler cria um agendador na tabela informada com a configuração AWS do provedor
+func NewScheduler(cloudProvider coreinterfaces.CloudProvider, tableName string) (*Scheduler, error) {
+	awsProvider, ok := cloudProvider.(*provider.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provedor não é do tipo AWS")
+	}
+
+	awsConfig, ok := awsProvider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+
+	return NewSchedulerWithClient(dynamodb.NewFromConfig(awsConfig), tableName), nil
+}
+
+// NewSchedulerWithClient cria um agendador com um cliente DynamoDB já configurado
+func NewSchedulerWithClient(client SchedulerAPI, tableName string) *Scheduler {
+	return &Scheduler{
+		client:    client,
+		tableName: tableName,
+		dueIndex:  DefaultScheduleDueIndex,
+	}
+}
+
+// SetDueIndex altera o nome do índice usado na consulta dos vencidos
+func (s *Scheduler) SetDueIndex(indexName string) {
+	s.dueIndex = indexName
+}
+
+// ScheduleAfter agenda a mensagem para depois do intervalo informado
+func (s *Scheduler) ScheduleAfter(ctx context.Context, destination string, message interface{}, delay time.Duration, opts ScheduleOptions) (string, error) {
+	return s.ScheduleAt(ctx, destination, message, time.Now().Add(delay), opts)
+}
+
+// ScheduleAt agenda a mensagem para o instante informado e retorna o ID do
+// agendamento. Instantes passados são publicados na próxima consulta.
+func (s *Scheduler) ScheduleAt(ctx context.Context, destination string, message interface{}, at time.Time, opts ScheduleOptions) (string, error) {
+	if destination == "" {
+		return "", fmt.Errorf("destino não informado")
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return "", fmt.Errorf("erro ao serializar mensagem: %w", err)
+	}
+
+	item := scheduleItem{
+		ID:              opts.ScheduleID,
+		Status:          ScheduleStatusPending,
+		DueAt:           at.UnixMilli(),
+		Destination:     destination,
+		Body:            string(body),
+		GroupID:         opts.GroupID,
+		DeduplicationID: opts.DeduplicationID,
+		Attributes:      opts.Attributes,
+		CreatedAt:       time.Now().UnixMilli(),
+	}
+	if item.ID == "" {
+		item.ID = uuid.New().String()
+	}
+	if item.DeduplicationID == "" {
+		item.DeduplicationID = item.ID
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return "", fmt.Errorf("erro ao converter agendamento: %w", err)
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                aws.String(s.tableName),
+		Item:                     av,
+		ConditionExpression:      aws.String("attribute_not_exists(#key)"),
+		ExpressionAttributeNames: map[string]string{"#key": scheduleKeyAttribute},
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return "", fmt.Errorf("%w: %s", ErrScheduleExists, item.ID)
+		}
+		return "", fmt.Errorf("erro ao gravar agendamento: %w", err)
+	}
+
+	log.Printf("Mensagem agendada %s para %s em %s", item.ID, destination, at.Format(time.RFC3339))
+	return item.ID, nil
+}
+
+// Cancel cancela um agendamento pendente. Retorna ErrScheduleNotPending se o
+// agendamento não existe, já foi cancelado ou já está sendo despachado.
+func (s *Scheduler) Cancel(ctx context.Context, scheduleID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 s.key(scheduleID),
+		UpdateExpression:    aws.String("SET #status = :cancelled"),
+		ConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": scheduleStatusAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":cancelled": &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusCancelled},
+			":pending":   &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusPending},
+		},
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return fmt.Errorf("%w: %s", ErrScheduleNotPending, scheduleID)
+		}
+		return fmt.Errorf("erro ao cancelar agendamento %s: %w", scheduleID, err)
+	}
+
+	log.Printf("Agendamento %s cancelado", scheduleID)
+	return nil
+}
+
+// Get lê um agendamento. Retorna nil se não existir.
+func (s *Scheduler) Get(ctx context.Context, scheduleID string) (*ScheduledMessage, error) {
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.tableName),
+		Key:            s.key(scheduleID),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler agendamento %s: %w", scheduleID, err)
+	}
+	if output.Item == nil {
+		return nil, nil
+	}
+
+	var item scheduleItem
+	if err := attributevalue.UnmarshalMap(output.Item, &item); err != nil {
+		return nil, fmt.Errorf("erro ao converter agendamento %s: %w", scheduleID, err)
+	}
+	msg := item.message()
+	return &msg, nil
+}
+
+// due consulta os agendamentos na situação informada com vencimento até until
+func (s *Scheduler) due(ctx context.Context, status string, until time.Time, limit int) ([]ScheduledMessage, error) {
+	output, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(s.dueIndex),
+		KeyConditionExpression: aws.String("#status = :status AND #due <= :until"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": scheduleStatusAttribute,
+			"#due":    scheduleDueAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":status": &ddbtypes.AttributeValueMemberS{Value: status},
+			":until":  epochMillisValue(until),
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao consultar agendamentos vencidos: %w", err)
+	}
+
+	messages := make([]ScheduledMessage, 0, len(output.Items))
+	for _, av := range output.Items {
+		var item scheduleItem
+		if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+			return nil, fmt.Errorf("erro ao converter agendamento: %w", err)
+		}
+		messages = append(messages, item.message())
+	}
+	return messages, nil
+}
+
+// claim obtém a posse do agendamento para despachá-lo. Só um despachante
+// consegue a posse: a escrita exige que o agendamento esteja pendente, ou em
+// despacho com a posse anterior expirada.
+func (s *Scheduler) claim(ctx context.Context, scheduleID, owner string, leaseDuration time.Duration) (bool, error) {
+	now := time.Now()
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.tableName),
+		Key:                 s.key(scheduleID),
+		UpdateExpression:    aws.String("SET #status = :dispatching, #owner = :owner, #lease = :lease"),
+		ConditionExpression: aws.String("#status = :pending OR (#status = :dispatching AND #lease < :now)"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": scheduleStatusAttribute,
+			"#owner":  scheduleOwnerAttribute,
+			"#lease":  scheduleLeaseAttribute,
+		},
+		ExpressionAttributeValues: map[string]ddbtypes.AttributeValue{
+			":dispatching": &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusDispatching},
+			":pending":     &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusPending},
+			":owner":       &ddbtypes.AttributeValueMemberS{Value: owner},
+			":lease":       epochMillisValue(now.Add(leaseDuration)),
+			":now":         epochMillisValue(now),
+		},
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("erro ao obter posse do agendamento %s: %w", scheduleID, err)
+	}
+	return true, nil
+}
+
+// complete marca o agendamento como despachado, desde que owner ainda tenha a posse
+func (s *Scheduler) complete(ctx context.Context, scheduleID, owner string, retention time.Duration) error {
+	now := time.Now()
+	update := "SET #status = :dispatched, #dispatchedAt = :now REMOVE #owner, #lease"
+	names := map[string]string{
+		"#status":       scheduleStatusAttribute,
+		"#dispatchedAt": scheduleDispatchAttribute,
+		"#owner":        scheduleOwnerAttribute,
+		"#lease":        scheduleLeaseAttribute,
+	}
+	values := map[string]ddbtypes.AttributeValue{
+		":dispatched":  &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusDispatched},
+		":dispatching": &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusDispatching},
+		":owner":       &ddbtypes.AttributeValueMemberS{Value: owner},
+		":now":         epochMillisValue(now),
+	}
+	if retention > 0 {
+		update = "SET #status = :dispatched, #dispatchedAt = :now, #expires = :expires REMOVE #owner, #lease"
+		names["#expires"] = scheduleExpiresAttribute
+		values[":expires"] = &ddbtypes.AttributeValueMemberN{Value: fmt.Sprint(now.Add(retention).Unix())}
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       s.key(scheduleID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#status = :dispatching AND #owner = :owner"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		if isConditionalCheckFailed(err) {
+			return fmt.Errorf("%w: agendamento %s", ErrLeaseLost, scheduleID)
+		}
+		return fmt.Errorf("erro ao concluir agendamento %s: %w", scheduleID, err)
+	}
+	return nil
+}
+
+// release devolve o agendamento após uma falha de publicação: incrementa
+// attempts, guarda o erro e adia o vencimento para retryAt, para que o
+// agendamento não volte à frente da consulta dos vencidos. Com retryAt zero o
+// agendamento passa para failed e não é mais despachado.
+func (s *Scheduler) release(ctx context.Context, scheduleID, owner string, cause error, retryAt time.Time) error {
+	status, update := ScheduleStatusPending, "SET #status = :status, #due = :due"
+	if retryAt.IsZero() {
+		status, update = ScheduleStatusFailed, "SET #status = :status"
+	}
+	update += ", #attempts = if_not_exists(#attempts, :zero) + :one, #error = :error REMOVE #owner, #lease"
+
+	names := map[string]string{
+		"#status":   scheduleStatusAttribute,
+		"#owner":    scheduleOwnerAttribute,
+		"#lease":    scheduleLeaseAttribute,
+		"#attempts": scheduleAttemptsAttribute,
+		"#error":    scheduleErrorAttribute,
+	}
+	values := map[string]ddbtypes.AttributeValue{
+		":status":      &ddbtypes.AttributeValueMemberS{Value: status},
+		":dispatching": &ddbtypes.AttributeValueMemberS{Value: ScheduleStatusDispatching},
+		":owner":       &ddbtypes.AttributeValueMemberS{Value: owner},
+		":zero":        &ddbtypes.AttributeValueMemberN{Value: "0"},
+		":one":         &ddbtypes.AttributeValueMemberN{Value: "1"},
+		":error":       &ddbtypes.AttributeValueMemberS{Value: fmt.Sprint(cause)},
+	}
+	if !retryAt.IsZero() {
+		names["#due"] = scheduleDueAttribute
+		values[":due"] = epochMillisValue(retryAt)
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.tableName),
+		Key:                       s.key(scheduleID),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#status = :dispatching AND #owner = :owner"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil && !isConditionalCheckFailed(err) {
+		return fmt.Errorf("erro ao liberar agendamento %s: %w", scheduleID, err)
+	}
+	return nil
+}
+
+// key monta a chave do item do agendamento
+func (s *Scheduler) key(scheduleID string) map[string]ddbtypes.AttributeValue {
+	return map[string]ddbtypes.AttributeValue{
+		scheduleKeyAttribute: &ddbtypes.AttributeValueMemberS{Value: scheduleID},
+	}
+}
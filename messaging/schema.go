@@ -0,0 +1,380 @@
+package messaging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// Atributos de mensagem com o tipo e a versão do schema do corpo
+const (
+	MessageTypeAttribute    = "messageType"
+	MessageVersionAttribute = "messageVersion"
+)
+
+// ErrSchemaNotFound indica um tipo ou versão de mensagem sem schema registrado
+var ErrSchemaNotFound = errors.New("schema não registrado")
+
+// ErrMissingUpcaster indica que falta um passo na migração entre versões
+var ErrMissingUpcaster = errors.New("upcaster não registrado")
+
+// MessageTyper pode ser implementado pelas mensagens para informar o seu tipo
+type MessageTyper interface {
+	MessageType() string
+}
+
+// Upcaster migra o corpo JSON de uma versão para a versão seguinte
+type Upcaster func(data json.RawMessage) (json.RawMessage, error)
+
+// SchemaViolation é uma falha de validação em um ponto do documento
+type SchemaViolation struct {
+	// Path é o ponteiro JSON do valor inválido, por exemplo "/itens/0/preco"
+	Path    string
+	Message string
+}
+
+// SchemaValidationError indica que o corpo não corresponde ao schema do tipo e versão
+type SchemaValidationError struct {
+	MessageType string
+	Version     int
+	Violations  []SchemaViolation
+}
+
+// Error descreve as violações
+func (e *SchemaValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		path := v.Path
+		if path == "" {
+			path = "/"
+		}
+		parts[i] = fmt.Sprintf("%s: %s", path, v.Message)
+	}
+	return fmt.Sprintf("mensagem %s v%d inválida: %s", e.MessageType, e.Version, strings.Join(parts, "; "))
+}
+
+// UpcastError indica uma falha ao migrar o corpo de uma versão para a seguinte
+type UpcastError struct {
+	MessageType string
+	FromVersion int
+	Err         error
+}
+
+// Error descreve a falha
+func (e *UpcastError) Error() string {
+	return fmt.Sprintf("erro ao migrar mensagem %s da versão %d: %v", e.MessageType, e.FromVersion, e.Err)
+}
+
+// Unwrap retorna a causa
+func (e *UpcastError) Unwrap() error {
+	return e.Err
+}
+
+// SchemaRegistry guarda os JSON Schemas por tipo e versão de mensagem, os
+// tipos Go associados a cada tipo de mensagem e os upcasters entre versões.
+// A versão atual de um tipo é a maior versão registrada.
+type SchemaRegistry struct {
+	mu        sync.RWMutex
+	schemas   map[string]map[int]*jsonschema.Schema
+	upcasters map[string]map[int]Upcaster
+	types     map[reflect.Type]string
+}
+
+// NewSchemaRegistry cria um registro de schemas vazio
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:   make(map[string]map[int]*jsonschema.Schema),
+		upcasters: make(map[string]map[int]Upcaster),
+		types:     make(map[reflect.Type]string),
+	}
+}
+
+// Register compila e registra o JSON Schema de uma versão (a partir de 1) do tipo de mensagem
+func (r *SchemaRegistry) Register(messageType string, version int, schema []byte) error {
+	if messageType == "" {
+		return fmt.Errorf("tipo de mensagem não informado")
+	}
+	if version < 1 {
+		return fmt.Errorf("versão inválida do schema %s: %d", messageType, version)
+	}
+
+	url := fmt.Sprintf("mem://schemas/%s/v%d.json", messageType, version)
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(url, bytes.NewReader(schema)); err != nil {
+		return fmt.Errorf("schema %s v%d inválido: %w", messageType, version, err)
+	}
+	compiled, err := compiler.Compile(url)
+	if err != nil {
+		return fmt.Errorf("erro ao compilar schema %s v%d: %w", messageType, version, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.schemas[messageType] == nil {
+		r.schemas[messageType] = make(map[int]*jsonschema.Schema)
+	}
+	r.schemas[messageType][version] = compiled
+	return nil
+}
+
+// RegisterType associa o tipo Go do valor ao tipo de mensagem, usado no envio
+// quando a mensagem não implementa MessageTyper
+func (r *SchemaRegistry) RegisterType(messageType string, value interface{}) {
+	t := reflect.TypeOf(value)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[t] = messageType
+	if t.Kind() == reflect.Ptr {
+		r.types[t.Elem()] = messageType
+	} else {
+		r.types[reflect.PointerTo(t)] = messageType
+	}
+}
+
+// RegisterUpcaster registra a migração de fromVersion para fromVersion+1
+func (r *SchemaRegistry) RegisterUpcaster(messageType string, fromVersion int, upcaster Upcaster) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.upcasters[messageType] == nil {
+		r.upcasters[messageType] = make(map[int]Upcaster)
+	}
+	r.upcasters[messageType][fromVersion] = upcaster
+}
+
+// CurrentVersion retorna a maior versão registrada do tipo
+func (r *SchemaRegistry) CurrentVersion(messageType string) (int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	current := 0
+	for version := range r.schemas[messageType] {
+		if version > current {
+			current = version
+		}
+	}
+	return current, current > 0
+}
+
+// Versions retorna as versões registradas do tipo, em ordem crescente
+func (r *SchemaRegistry) Versions(messageType string) []int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make([]int, 0, len(r.schemas[messageType]))
+	for version := range r.schemas[messageType] {
+		versions = append(versions, version)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// MessageTypeOf resolve o tipo de mensagem pelo MessageTyper ou pelo tipo Go
+// registrado. Retorna vazio se não for possível.
+func (r *SchemaRegistry) MessageTypeOf(message interface{}) string {
+	if typer, ok := message.(MessageTyper); ok {
+		return typer.MessageType()
+	}
+	if message == nil {
+		return ""
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.types[reflect.TypeOf(message)]
+}
+
+// Validate valida o corpo JSON contra o schema do tipo e versão
+func (r *SchemaRegistry) Validate(messageType string, version int, data []byte) error {
+	r.mu.RLock()
+	schema := r.schemas[messageType][version]
+	r.mu.RUnlock()
+	if schema == nil {
+		return fmt.Errorf("%w: %s v%d", ErrSchemaNotFound, messageType, version)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var document interface{}
+	if err := decoder.Decode(&document); err != nil {
+		return &SchemaValidationError{
+			MessageType: messageType,
+			Version:     version,
+			Violations:  []SchemaViolation{{Path: "", Message: "JSON inválido: " + err.Error()}},
+		}
+	}
+
+	if err := schema.Validate(document); err != nil {
+		var validationErr *jsonschema.ValidationError
+		if !errors.As(err, &validationErr) {
+			return fmt.Errorf("erro ao validar mensagem %s v%d: %w", messageType, version, err)
+		}
+		return &SchemaValidationError{
+			MessageType: messageType,
+			Version:     version,
+			Violations:  schemaViolations(validationErr),
+		}
+	}
+	return nil
+}
+
+// Upcast valida o corpo na versão informada e o migra, passo a passo, até a
+// versão atual, validando o resultado. Retorna o corpo e a versão finais.
+func (r *SchemaRegistry) Upcast(messageType string, version int, data []byte) ([]byte, int, error) {
+	current, ok := r.CurrentVersion(messageType)
+	if !ok {
+		return nil, 0, fmt.Errorf("%w: %s", ErrSchemaNotFound, messageType)
+	}
+	if version > current {
+		return nil, 0, fmt.Errorf("%w: %s v%d é mais nova que a versão atual %d", ErrSchemaNotFound, messageType, version, current)
+	}
+	if err := r.Validate(messageType, version, data); err != nil {
+		return nil, 0, err
+	}
+	if version == current {
+		return data, version, nil
+	}
+
+	for ; version < current; version++ {
+		r.mu.RLock()
+		upcaster := r.upcasters[messageType][version]
+		r.mu.RUnlock()
+		if upcaster == nil {
+			return nil, 0, &UpcastError{MessageType: messageType, FromVersion: version, Err: ErrMissingUpcaster}
+		}
+
+		migrated, err := upcaster(json.RawMessage(data))
+		if err != nil {
+			return nil, 0, &UpcastError{MessageType: messageType, FromVersion: version, Err: err}
+		}
+		data = migrated
+	}
+
+	if err := r.Validate(messageType, current, data); err != nil {
+		return nil, 0, err
+	}
+	return data, current, nil
+}
+
+// Attributes monta os atributos de tipo e versão da mensagem
+func (r *SchemaRegistry) Attributes(messageType string, version int) map[string]MessageAttribute {
+	return map[string]MessageAttribute{
+		MessageTypeAttribute:    StringAttribute(messageType),
+		MessageVersionAttribute: NumberAttribute(version),
+	}
+}
+
+// schemaViolations achata a árvore de erros do validador, mantendo as folhas
+func schemaViolations(err *jsonschema.ValidationError) []SchemaViolation {
+	var violations []SchemaViolation
+	var walk func(*jsonschema.ValidationError)
+	walk = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			violations = append(violations, SchemaViolation{Path: e.InstanceLocation, Message: e.Message})
+			return
+		}
+		for _, cause := range e.Causes {
+			walk(cause)
+		}
+	}
+	walk(err)
+	return violations
+}
+
+// messageVersion lê a versão dos atributos; mensagens sem versão são da versão 1
+func messageVersion(messageType string, attributes map[string]MessageAttribute) (int, error) {
+	attr, ok := attributes[MessageVersionAttribute]
+	if !ok || attr.StringValue == "" {
+		return 1, nil
+	}
+	version, err := strconv.Atoi(attr.StringValue)
+	if err != nil || version < 1 {
+		return 0, &SchemaValidationError{
+			MessageType: messageType,
+			Violations:  []SchemaViolation{{Path: "", Message: "atributo " + MessageVersionAttribute + " inválido: " + attr.StringValue}},
+		}
+	}
+	return version, nil
+}
+
+// schemaHook guarda o registro de schemas de um provedor e valida as
+// mensagens enviadas. É compartilhado pelos provedores SQS, SNS, EventBridge
+// e Kinesis; sem registro, nada é validado.
+type schemaHook struct {
+	mu      sync.RWMutex
+	schemas *SchemaRegistry
+}
+
+// set substitui o registro de schemas; nil desativa a validação
+func (h *schemaHook) set(schemas *SchemaRegistry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.schemas = schemas
+}
+
+// registry retorna o registro de schemas, ou nil se não houver
+func (h *schemaHook) registry() *SchemaRegistry {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.schemas
+}
+
+// messageTypeOf resolve o tipo da mensagem pelo registro, se houver
+func (h *schemaHook) messageTypeOf(message interface{}) string {
+	schemas := h.registry()
+	if schemas == nil {
+		return ""
+	}
+	return schemas.MessageTypeOf(message)
+}
+
+// validate resolve o tipo da mensagem e, se houver, valida a sua forma JSON
+// contra o schema da versão atual, retornando os atributos de tipo e versão.
+// Mensagens sem tipo não são validadas. Corpos que não são JSON recebem os
+// atributos, mas não são validados.
+func (h *schemaHook) validate(message interface{}, messageType string, contentType string) (map[string]MessageAttribute, error) {
+	schemas := h.registry()
+	if schemas == nil {
+		return nil, nil
+	}
+	if messageType == "" {
+		messageType = schemas.MessageTypeOf(message)
+	}
+	if messageType == "" {
+		return nil, nil
+	}
+
+	version, ok := schemas.CurrentVersion(messageType)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrSchemaNotFound, messageType)
+	}
+
+	if contentType == "" || mediaType(contentType) == ContentTypeJSON {
+		data, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao serializar mensagem: %w", err)
+		}
+		if err := schemas.Validate(messageType, version, data); err != nil {
+			return nil, err
+		}
+	}
+	return schemas.Attributes(messageType, version), nil
+}
+
+// validateData valida um corpo JSON já serializado contra o schema da versão
+// atual do tipo informado. Sem tipo, o corpo não é validado.
+func (h *schemaHook) validateData(messageType string, data []byte) error {
+	schemas := h.registry()
+	if schemas == nil || messageType == "" {
+		return nil
+	}
+	version, ok := schemas.CurrentVersion(messageType)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrSchemaNotFound, messageType)
+	}
+	return schemas.Validate(messageType, version, data)
+}
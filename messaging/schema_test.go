@@ -0,0 +1,182 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// testOrder é a mensagem validada pelo schema "pedido"
+type testOrder struct {
+	Key   string `json:"key"`
+	Total int    `json:"total"`
+}
+
+// newTestSchemas cria um registro com o schema "pedido" v1, que exige total
+// não negativo, e testOrder registrado como esse tipo
+func newTestSchemas(t *testing.T) *SchemaRegistry {
+	t.Helper()
+	schemas := NewSchemaRegistry()
+	schema := `{"type":"object","required":["total"],"properties":{"total":{"type":"integer","minimum":0}}}`
+	if err := schemas.Register("pedido", 1, []byte(schema)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	schemas.RegisterType("pedido", testOrder{})
+	return schemas
+}
+
+// expectSchemaError falha o teste se err não for SchemaValidationError
+func expectSchemaError(t *testing.T, err error) {
+	t.Helper()
+	var validationErr *SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("esperado SchemaValidationError, obtido %v", err)
+	}
+}
+
+// fakeEventBridgeClient registra os detalhes publicados
+type fakeEventBridgeClient struct {
+	mu      sync.Mutex
+	details []string
+}
+
+func (c *fakeEventBridgeClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := &eventbridge.PutEventsOutput{}
+	for _, entry := range params.Entries {
+		c.details = append(c.details, aws.ToString(entry.Detail))
+		output.Entries = append(output.Entries, ebtypes.PutEventsResultEntry{EventId: aws.String("id")})
+	}
+	return output, nil
+}
+
+func TestSNSPublishValidatesSchema(t *testing.T) {
+	client := &fakeSNSClient{}
+	p := NewSNSProviderWithClient(client, nil)
+	p.SetSchemaRegistry(newTestSchemas(t))
+	topic := "arn:aws:sns:us-east-1:000000000000:pedidos"
+
+	_, err := p.Publish(context.Background(), topic, testOrder{Total: -1}, PublishOptions{})
+	expectSchemaError(t, err)
+	if len(client.published) != 0 {
+		t.Fatalf("mensagem inválida não deveria ser publicada: %v", client.published)
+	}
+
+	if _, err := p.Publish(context.Background(), topic, testOrder{Total: 10}, PublishOptions{}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	entry, err := p.newPublishEntry(topic, 0, testOrder{Total: 10}, PublishOptions{})
+	if err != nil {
+		t.Fatalf("newPublishEntry: %v", err)
+	}
+	if got := aws.ToString(entry.entry.MessageAttributes[MessageTypeAttribute].StringValue); got != "pedido" {
+		t.Fatalf("atributo %s: obtido %q", MessageTypeAttribute, got)
+	}
+}
+
+func TestEventBridgePutEventsValidatesSchema(t *testing.T) {
+	client := &fakeEventBridgeClient{}
+	p := NewEventBridgeProviderWithClient(client, nil)
+	p.SetSchemaRegistry(newTestSchemas(t))
+
+	results, err := p.PutEvents(context.Background(), "", []EventEntry{
+		{Detail: testOrder{Total: -1}},
+		{Detail: testOrder{Total: 10}},
+	})
+	if err == nil {
+		t.Fatal("PutEvents deveria reportar o evento inválido")
+	}
+	expectSchemaError(t, results[0].Err)
+	if results[1].Err != nil {
+		t.Fatalf("evento válido: %v", results[1].Err)
+	}
+	want := `{"key":"","total":10,"_schema":{"messageType":"pedido","messageVersion":"1"}}`
+	if !reflect.DeepEqual(client.details, []string{want}) {
+		t.Fatalf("detalhes publicados: %v", client.details)
+	}
+
+	// O adaptador devolve tipo e versão em messageAttributes e remove a chave do detalhe
+	cwEvent, _ := json.Marshal(events.CloudWatchEvent{
+		ID:         "1",
+		DetailType: "pedido",
+		Source:     "app",
+		Detail:     json.RawMessage(client.details[0]),
+	})
+	event, err := adapter.ConvertToGenericEvent(context.Background(), cwEvent)
+	if err != nil {
+		t.Fatalf("ConvertToGenericEvent: %v", err)
+	}
+	if string(event.Data) != `{"key":"","total":10}` {
+		t.Fatalf("Data: obtido %s", event.Data)
+	}
+	attributes := eventMessageAttributes(event)
+	if attributes[MessageTypeAttribute] != "pedido" || attributes[MessageVersionAttribute] != "1" {
+		t.Fatalf("messageAttributes: obtido %v", event.Metadata["messageAttributes"])
+	}
+
+	_, err = p.PutEvents(context.Background(), "", []EventEntry{
+		{Detail: map[string]interface{}{"total": 1, adapter.EventBridgeSchemaKey: "x"}, MessageType: "pedido"},
+	})
+	if err == nil {
+		t.Fatalf("detalhe com a chave %s deveria ser recusado", adapter.EventBridgeSchemaKey)
+	}
+}
+
+func TestKinesisSendMessagesValidatesSchema(t *testing.T) {
+	client := &fakeKinesisClient{}
+	p := NewKinesisProviderWithClient(client, nil)
+	p.SetSchemaRegistry(newTestSchemas(t))
+	p.SetPartitionKeyFunc(func(message interface{}) string { return message.(testOrder).Key })
+
+	results, err := p.SendMessages(context.Background(), "stream", []interface{}{
+		testOrder{Key: "A", Total: -1},
+		testOrder{Key: "B", Total: 1},
+		testOrder{Key: "A", Total: 2},
+	})
+	if err == nil {
+		t.Fatal("SendMessages deveria reportar a mensagem inválida")
+	}
+	expectSchemaError(t, results[0].Err)
+	if !errors.Is(results[2].Err, ErrOrderBlocked) {
+		t.Fatalf("mensagem seguinte da chave A: esperado ErrOrderBlocked, obtido %v", results[2].Err)
+	}
+	if len(client.written) != 1 {
+		t.Fatalf("apenas a chave B deveria ser gravada: %v", client.written)
+	}
+
+	// Em PutRecords o tipo vem do registro
+	records := []KinesisRecord{{Data: []byte(`{"total":"x"}`), PartitionKey: "C", MessageType: "pedido"}}
+	results, _ = p.PutRecords(context.Background(), "stream", records)
+	expectSchemaError(t, results[0].Err)
+}
+
+func TestDecodeMessageInvalidVersion(t *testing.T) {
+	p := NewSQSProviderWithClient(NewMemorySQSClient(), nil)
+	p.SetSchemaRegistry(newTestSchemas(t))
+
+	msg := ReceivedMessage{
+		Message: coreinterfaces.Message{Body: []byte(`{"total":1}`)},
+		Attributes: map[string]MessageAttribute{
+			MessageTypeAttribute:    StringAttribute("pedido"),
+			MessageVersionAttribute: StringAttribute("v2"),
+		},
+	}
+	var order testOrder
+	err := p.DecodeMessage(msg, &order)
+	expectSchemaError(t, err)
+	var validationErr *SchemaValidationError
+	if errors.As(err, &validationErr) && validationErr.MessageType != "pedido" {
+		t.Fatalf("tipo no erro: obtido %q", validationErr.MessageType)
+	}
+}
@@ -0,0 +1,449 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/silviomfa/go-cloud-aws/provider"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// AttributeTypeStringArray é o tipo de atributo SNS com uma lista de valores,
+// usado em políticas de filtro de assinaturas
+const AttributeTypeStringArray = "String.Array"
+
+// SNSAPI define as operações do cliente SNS utilizadas pelo provedor.
+// É satisfeita por *sns.Client e permite substituir o cliente em testes.
+type SNSAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+	PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error)
+	ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error)
+}
+
+// SNSProvider implementa a interface coreinterfaces.MessagingProvider para
+// tópicos SNS. Apenas a publicação é suportada; para consumir, assine uma fila
+// SQS ao tópico.
+type SNSProvider struct {
+	client   SNSAPI
+	provider *provider.Provider
+	fifo     fifoRegistry
+	schemas  schemaHook
+
+	mu        sync.RWMutex
+	topics    map[string]string
+	missing   map[string]time.Time
+	accountID string
+}
+
+// topicMissTTL é por quanto tempo um tópico não encontrado deixa de ser
+// procurado via ListTopics
+const topicMissTTL = time.Minute
+
+// PublishOptions configura a publicação de uma mensagem
+type PublishOptions struct {
+	// Attributes são os atributos de mensagem, usados nas políticas de filtro
+	Attributes map[string]MessageAttribute
+	// Subject é o assunto usado em assinaturas de e-mail
+	Subject string
+	// MessageGroupID substitui o grupo gerado pela configuração FIFO
+	MessageGroupID string
+	// DeduplicationID substitui o ID de deduplicação gerado pela configuração FIFO
+	DeduplicationID string
+	// MessageType define o tipo usado na validação por schema; se vazio, é
+	// obtido de MessageTyper ou dos tipos registrados no SchemaRegistry
+	MessageType string
+	// ProtocolMessages define mensagens diferentes por protocolo ("sqs",
+	// "lambda", "email", "http" etc.). Quando informado, a mensagem é publicada
+	// com MessageStructure "json" e a mensagem principal vira a chave "default".
+	ProtocolMessages map[string]interface{}
+}
+
+// PublishEntry é uma mensagem de uma publicação em lote
+type PublishEntry struct {
+	Message interface{}
+	Options PublishOptions
+}
+
+// StringArrayAttribute cria um atributo do tipo String.Array
+func StringArrayAttribute(values ...string) MessageAttribute {
+	encoded, _ := json.Marshal(values)
+	return MessageAttribute{DataType: AttributeTypeStringArray, StringValue: string(encoded)}
+}
+
+// NewSNSProvider cria um novo provedor de mensageria SNS
+func NewSNSProvider(cloudProvider coreinterfaces.CloudProvider) (*SNSProvider, error) {
+	awsProvider, ok := cloudProvider.(*provider.Provider)
+	if !ok {
+		return nil, fmt.Errorf("provedor não é do tipo AWS")
+	}
+
+	awsConfig, ok := awsProvider.GetConfig().(aws.Config)
+	if !ok {
+		return nil, fmt.Errorf("configuração não é do tipo AWS")
+	}
+
+	return NewSNSProviderWithClient(sns.NewFromConfig(awsConfig), awsProvider), nil
+}
+
+// NewSNSProviderWithClient cria um provedor SNS a partir de um cliente já configurado
+func NewSNSProviderWithClient(client SNSAPI, cloudProvider *provider.Provider) *SNSProvider {
+	return &SNSProvider{
+		client:   client,
+		provider: cloudProvider,
+		topics:   make(map[string]string),
+		missing:  make(map[string]time.Time),
+	}
+}
+
+// SetAccountID define a conta dos tópicos. Com a conta e a região do
+// provedor, TopicARN monta o ARN a partir do nome, sem consultar o SNS.
+func (p *SNSProvider) SetAccountID(accountID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.accountID = accountID
+}
+
+// GetName retorna o nome do provedor
+func (p *SNSProvider) GetName() string {
+	return "AWS-SNS"
+}
+
+// SetFIFOConfig registra como grupos e IDs de deduplicação são gerados para
+// um tópico FIFO
+func (p *SNSProvider) SetFIFOConfig(topic string, config FIFOConfig) {
+	p.fifo.set(topic, config)
+}
+
+// SetSchemaRegistry ativa a validação por JSON Schema na publicação.
+// Mensagens com tipo conhecido (PublishOptions.MessageType, MessageTyper ou
+// RegisterType) são validadas contra a versão atual e levam os atributos de
+// tipo e versão; as inválidas não são publicadas e retornam SchemaValidationError.
+func (p *SNSProvider) SetSchemaRegistry(schemas *SchemaRegistry) {
+	p.schemas.set(schemas)
+}
+
+// SendMessage publica uma mensagem no tópico. topicName pode ser o nome ou o ARN.
+func (p *SNSProvider) SendMessage(ctx context.Context, topicName string, message interface{}) error {
+	_, err := p.Publish(ctx, topicName, message, PublishOptions{})
+	return err
+}
+
+// ReceiveMessages não é suportado por tópicos SNS
+func (p *SNSProvider) ReceiveMessages(ctx context.Context, topicName string, maxMessages int) ([]coreinterfaces.Message, error) {
+	return nil, fmt.Errorf("SNS não suporta recepção de mensagens; assine uma fila SQS ao tópico %s", topicName)
+}
+
+// DeleteMessage não é suportado por tópicos SNS
+func (p *SNSProvider) DeleteMessage(ctx context.Context, topicName string, receiptHandle string) error {
+	return fmt.Errorf("SNS não suporta remoção de mensagens; assine uma fila SQS ao tópico %s", topicName)
+}
+
+// Publish publica uma mensagem com atributos, assunto e estrutura por
+// protocolo, retornando o ID atribuído pelo SNS
+func (p *SNSProvider) Publish(ctx context.Context, topicName string, message interface{}, opts PublishOptions) (string, error) {
+	topicARN, err := p.TopicARN(ctx, topicName)
+	if err != nil {
+		return "", err
+	}
+
+	entry, err := p.newPublishEntry(topicName, 0, message, opts)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := p.client.Publish(ctx, &sns.PublishInput{
+		TopicArn:               &topicARN,
+		Message:                entry.entry.Message,
+		MessageAttributes:      entry.entry.MessageAttributes,
+		MessageStructure:       entry.entry.MessageStructure,
+		Subject:                entry.entry.Subject,
+		MessageGroupId:         entry.entry.MessageGroupId,
+		MessageDeduplicationId: entry.entry.MessageDeduplicationId,
+	})
+	if err != nil {
+		return "", fmt.Errorf("erro ao publicar no tópico %s: %w", topicName, err)
+	}
+
+	return aws.ToString(output.MessageId), nil
+}
+
+// PublishBatch publica mensagens em lotes de até 10 entradas e 256 KB,
+// reenviando apenas as entradas que falharem por erro do serviço. O resultado
+// tem uma posição por entrada. Em tópicos FIFO os lotes são enviados em
+// sequência e, depois da primeira falha de um grupo, as entradas seguintes
+// desse grupo não são publicadas e recebem ErrOrderBlocked.
+func (p *SNSProvider) PublishBatch(ctx context.Context, topicName string, entries []PublishEntry) ([]BatchResult, error) {
+	results := newBatchResults(len(entries))
+	if len(entries) == 0 {
+		return results, nil
+	}
+
+	topicARN, err := p.TopicARN(ctx, topicName)
+	if err != nil {
+		return nil, err
+	}
+
+	fifo := IsFIFOQueue(topicName)
+	fifoConfig := p.fifo.get(topicName)
+	groupOf := func(e PublishEntry) string {
+		if e.Options.MessageGroupID != "" {
+			return e.Options.MessageGroupID
+		}
+		return fifoConfig.groupID(e.Message)
+	}
+
+	failedGroups := make(map[string]bool)
+	pending := make([]publishEntry, 0, len(entries))
+	for i, e := range entries {
+		if fifo && failedGroups[groupOf(e)] {
+			results[i].Err = ErrOrderBlocked
+			continue
+		}
+
+		entry, err := p.newPublishEntry(topicName, i, e.Message, e.Options)
+		if err == nil && entry.size > maxBatchPayloadSize {
+			err = fmt.Errorf("mensagem %d excede o limite de %d bytes: %d bytes", i, maxBatchPayloadSize, entry.size)
+		}
+		if err != nil {
+			results[i].Err = err
+			if fifo {
+				failedGroups[groupOf(e)] = true
+			}
+			continue
+		}
+		pending = append(pending, entry)
+	}
+
+	send := func(batch []publishEntry) (batchOutcome, error) {
+		requestEntries := make([]snstypes.PublishBatchRequestEntry, len(batch))
+		for i, e := range batch {
+			requestEntries[i] = e.entry
+		}
+
+		output, err := p.client.PublishBatch(ctx, &sns.PublishBatchInput{
+			TopicArn:                   &topicARN,
+			PublishBatchRequestEntries: requestEntries,
+		})
+		if err != nil {
+			return batchOutcome{}, fmt.Errorf("erro ao publicar lote no tópico %s: %w", topicName, err)
+		}
+
+		outcome := batchOutcome{successful: make(map[string]string, len(output.Successful))}
+		for _, success := range output.Successful {
+			outcome.successful[aws.ToString(success.Id)] = aws.ToString(success.MessageId)
+		}
+		for _, failed := range output.Failed {
+			outcome.failed = append(outcome.failed, batchFailure{
+				id:          aws.ToString(failed.Id),
+				code:        aws.ToString(failed.Code),
+				message:     aws.ToString(failed.Message),
+				senderFault: failed.SenderFault,
+			})
+		}
+		return outcome, nil
+	}
+	entryID := func(e publishEntry) string { return aws.ToString(e.entry.Id) }
+
+	batches := chunkBySize(pending, maxBatchEntries, maxBatchPayloadSize, func(e publishEntry) int { return e.size })
+	if fifo {
+		entryGroup := func(e publishEntry) []string { return []string{aws.ToString(e.entry.MessageGroupId)} }
+		retryOrderedBatches(ctx, batches, entryID, entryGroup, send, results)
+	} else {
+		runBatches(ctx, batches, true, func(batch []publishEntry) {
+			retryBatch(ctx, batch, entryID, send, results)
+		})
+	}
+
+	return results, summarizeBatch(results, "publicadas")
+}
+
+// TopicARN resolve o nome ou ARN de um tópico para o seu ARN. Com a conta
+// definida em SetAccountID, o ARN é montado a partir da região do provedor;
+// sem ela, os nomes são procurados via ListTopics e mantidos em cache, e um
+// tópico não encontrado só volta a ser procurado após topicMissTTL.
+func (p *SNSProvider) TopicARN(ctx context.Context, topicName string) (string, error) {
+	if topicName == "" {
+		return "", fmt.Errorf("nome do tópico não informado")
+	}
+	if strings.HasPrefix(topicName, "arn:") {
+		return topicName, nil
+	}
+
+	p.mu.RLock()
+	arn, ok := p.topics[topicName]
+	missedAt, missed := p.missing[topicName]
+	accountID := p.accountID
+	p.mu.RUnlock()
+	if ok {
+		return arn, nil
+	}
+	if accountID != "" && p.provider != nil && p.provider.GetRegion() != "" {
+		region := p.provider.GetRegion()
+		return fmt.Sprintf("arn:%s:sns:%s:%s:%s", regionPartition(region), region, accountID, topicName), nil
+	}
+	if missed && time.Since(missedAt) < topicMissTTL {
+		return "", fmt.Errorf("tópico não encontrado: %s", topicName)
+	}
+
+	// Uma listagem completa preenche o cache com todos os tópicos da conta
+	found := make(map[string]string)
+	var token *string
+	for {
+		output, err := p.client.ListTopics(ctx, &sns.ListTopicsInput{NextToken: token})
+		if err != nil {
+			return "", fmt.Errorf("erro ao listar tópicos: %w", err)
+		}
+		for _, topic := range output.Topics {
+			topicARN := aws.ToString(topic.TopicArn)
+			found[queueBaseName(topicARN)] = topicARN
+		}
+		if output.NextToken == nil {
+			break
+		}
+		token = output.NextToken
+	}
+
+	arn, ok = found[topicName]
+	p.mu.Lock()
+	for name, topicARN := range found {
+		p.topics[name] = topicARN
+		delete(p.missing, name)
+	}
+	if !ok {
+		p.missing[topicName] = time.Now()
+	}
+	p.mu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("tópico não encontrado: %s", topicName)
+	}
+	log.Printf("Tópico %s resolvido para %s", topicName, arn)
+	return arn, nil
+}
+
+// regionPartition retorna a partição AWS da região
+func regionPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return "aws-cn"
+	case strings.HasPrefix(region, "us-gov-"):
+		return "aws-us-gov"
+	default:
+		return "aws"
+	}
+}
+
+// publishEntry é uma entrada de publicação com o tamanho já calculado
+type publishEntry struct {
+	entry snstypes.PublishBatchRequestEntry
+	size  int
+}
+
+// newPublishEntry valida e serializa a mensagem e monta a entrada de
+// publicação, usando a posição da mensagem como ID da entrada
+func (p *SNSProvider) newPublishEntry(topicName string, index int, message interface{}, opts PublishOptions) (publishEntry, error) {
+	schemaAttributes, err := p.schemas.validate(message, opts.MessageType, "")
+	if err != nil {
+		return publishEntry{}, err
+	}
+	if len(schemaAttributes) > 0 {
+		attributes := make(map[string]MessageAttribute, len(opts.Attributes)+len(schemaAttributes))
+		for k, v := range opts.Attributes {
+			attributes[k] = v
+		}
+		for k, v := range schemaAttributes {
+			attributes[k] = v
+		}
+		opts.Attributes = attributes
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return publishEntry{}, fmt.Errorf("erro ao serializar mensagem %d: %w", index, err)
+	}
+	if err := p.fifo.apply(topicName, message, body, &opts.MessageGroupID, &opts.DeduplicationID); err != nil {
+		return publishEntry{}, err
+	}
+
+	entry := snstypes.PublishBatchRequestEntry{Id: aws.String(strconv.Itoa(index))}
+
+	if len(opts.ProtocolMessages) > 0 {
+		structured, err := protocolMessages(body, opts.ProtocolMessages)
+		if err != nil {
+			return publishEntry{}, err
+		}
+		body = structured
+		entry.MessageStructure = aws.String("json")
+	}
+	entry.Message = aws.String(string(body))
+
+	attributes, err := toSNSAttributes(opts.Attributes)
+	if err != nil {
+		return publishEntry{}, err
+	}
+	entry.MessageAttributes = attributes
+
+	if opts.Subject != "" {
+		entry.Subject = aws.String(opts.Subject)
+	}
+	if opts.MessageGroupID != "" {
+		entry.MessageGroupId = aws.String(opts.MessageGroupID)
+	}
+	if opts.DeduplicationID != "" {
+		entry.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+	}
+
+	return publishEntry{entry: entry, size: len(body) + attributesSize(opts.Attributes)}, nil
+}
+
+// protocolMessages monta a mensagem com estrutura JSON do SNS. Cada valor é
+// convertido em string: strings são usadas como estão e os demais valores são
+// serializados em JSON. A chave "default" é obrigatória e, se ausente, recebe
+// a mensagem principal.
+func protocolMessages(body []byte, messages map[string]interface{}) ([]byte, error) {
+	structure := make(map[string]string, len(messages)+1)
+	structure["default"] = string(body)
+	for protocol, message := range messages {
+		if text, ok := message.(string); ok {
+			structure[protocol] = text
+			continue
+		}
+		encoded, err := json.Marshal(message)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao serializar mensagem do protocolo %s: %w", protocol, err)
+		}
+		structure[protocol] = string(encoded)
+	}
+	return json.Marshal(structure)
+}
+
+// toSNSAttributes converte atributos tipados para o formato do SDK do SNS
+func toSNSAttributes(attributes map[string]MessageAttribute) (map[string]snstypes.MessageAttributeValue, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]snstypes.MessageAttributeValue, len(attributes))
+	for name, attr := range attributes {
+		value := snstypes.MessageAttributeValue{DataType: aws.String(attr.DataType)}
+		switch attr.BaseType() {
+		case AttributeTypeBinary:
+			value.BinaryValue = attr.BinaryValue
+		case AttributeTypeString, AttributeTypeNumber:
+			value.StringValue = aws.String(attr.StringValue)
+		default:
+			return nil, fmt.Errorf("tipo de atributo inválido para %s: %s", name, attr.DataType)
+		}
+		result[name] = value
+	}
+	return result, nil
+}
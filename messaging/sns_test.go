@@ -0,0 +1,206 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/silviomfa/go-cloud-aws/provider"
+)
+
+// fakeSNSClient registra as publicações e falha, do lado do serviço, as
+// entradas cujo corpo está em failures, tantas vezes quanto indicado
+type fakeSNSClient struct {
+	mu        sync.Mutex
+	failures  map[string]int
+	published []string
+}
+
+func (c *fakeSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.published = append(c.published, aws.ToString(params.Message))
+	return &sns.PublishOutput{MessageId: aws.String("id")}, nil
+}
+
+func (c *fakeSNSClient) PublishBatch(ctx context.Context, params *sns.PublishBatchInput, optFns ...func(*sns.Options)) (*sns.PublishBatchOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	output := &sns.PublishBatchOutput{}
+	for _, entry := range params.PublishBatchRequestEntries {
+		body := aws.ToString(entry.Message)
+		if c.failures[body] > 0 {
+			c.failures[body]--
+			output.Failed = append(output.Failed, snstypes.BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    aws.String("InternalError"),
+				Message: aws.String("falha simulada"),
+			})
+			continue
+		}
+		c.published = append(c.published, body)
+		output.Successful = append(output.Successful, snstypes.PublishBatchResultEntry{Id: entry.Id, MessageId: aws.String("id-" + aws.ToString(entry.Id))})
+	}
+	return output, nil
+}
+
+func (c *fakeSNSClient) ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error) {
+	return &sns.ListTopicsOutput{}, nil
+}
+
+// publishedInGroup retorna os números publicados de um grupo, na ordem
+func (c *fakeSNSClient) publishedInGroup(t *testing.T, group string) []int {
+	t.Helper()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var numbers []int
+	for _, body := range c.published {
+		var msg groupedMessage
+		if err := json.Unmarshal([]byte(body), &msg); err != nil {
+			t.Fatal(err)
+		}
+		if msg.Group == group {
+			numbers = append(numbers, msg.N)
+		}
+	}
+	return numbers
+}
+
+// groupedEntries monta entradas de publicação alternando os grupos A e B
+func groupedEntries(count int) []PublishEntry {
+	var entries []PublishEntry
+	for n := 1; n <= count; n++ {
+		entries = append(entries,
+			PublishEntry{Message: groupedMessage{"A", n}, Options: PublishOptions{MessageGroupID: "A"}},
+			PublishEntry{Message: groupedMessage{"B", n}, Options: PublishOptions{MessageGroupID: "B"}},
+		)
+	}
+	return entries
+}
+
+// failing retorna o mapa de falhas para a mensagem informada
+func failing(msg groupedMessage, count int) map[string]int {
+	body, _ := json.Marshal(msg)
+	return map[string]int{string(body): count}
+}
+
+func TestPublishBatchFIFOStopsGroupAfterFailure(t *testing.T) {
+	const topic = "arn:aws:sns:us-east-1:111111111111:events.fifo"
+	client := &fakeSNSClient{failures: failing(groupedMessage{"A", 1}, 100)}
+	p := NewSNSProviderWithClient(client, nil)
+
+	entries := groupedEntries(12)
+	results, err := p.PublishBatch(context.Background(), topic, entries)
+	if err == nil {
+		t.Fatal("PublishBatch deveria reportar a falha")
+	}
+
+	// A/1 não é reenviada depois de A/2..A/5, aceitas no mesmo lote, e o grupo
+	// A não avança nos lotes seguintes
+	if got := client.publishedInGroup(t, "A"); !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Fatalf("grupo A: obtido %v", got)
+	}
+	if got := client.publishedInGroup(t, "B"); len(got) != 12 {
+		t.Fatalf("grupo B não deveria ser afetado: obtido %v", got)
+	}
+	for i := 10; i < len(entries); i += 2 {
+		if !errors.Is(results[i].Err, ErrOrderBlocked) {
+			t.Fatalf("entrada %d: esperado ErrOrderBlocked, obtido %v", i, results[i].Err)
+		}
+	}
+}
+
+func TestPublishBatchFIFORetriesInOrder(t *testing.T) {
+	const topic = "arn:aws:sns:us-east-1:111111111111:events.fifo"
+	client := &fakeSNSClient{failures: failing(groupedMessage{"A", 5}, 1)}
+	p := NewSNSProviderWithClient(client, nil)
+
+	// A/5 é a última entrada do grupo A no primeiro lote: pode ser reenviada
+	// antes do lote seguinte
+	results, err := p.PublishBatch(context.Background(), topic, groupedEntries(8))
+	if err != nil {
+		t.Fatalf("PublishBatch: %v (%+v)", err, results)
+	}
+	if got := client.publishedInGroup(t, "A"); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("grupo A fora de ordem: %v", got)
+	}
+}
+
+// listingSNSClient lista os tópicos em páginas de um e conta as chamadas
+type listingSNSClient struct {
+	fakeSNSClient
+	topics []string
+	calls  int
+}
+
+func (c *listingSNSClient) ListTopics(ctx context.Context, params *sns.ListTopicsInput, optFns ...func(*sns.Options)) (*sns.ListTopicsOutput, error) {
+	c.calls++
+	index := 0
+	if params.NextToken != nil {
+		index, _ = strconv.Atoi(aws.ToString(params.NextToken))
+	}
+	output := &sns.ListTopicsOutput{}
+	if index < len(c.topics) {
+		output.Topics = []snstypes.Topic{{TopicArn: aws.String(c.topics[index])}}
+	}
+	if index+1 < len(c.topics) {
+		output.NextToken = aws.String(strconv.Itoa(index + 1))
+	}
+	return output, nil
+}
+
+func TestTopicARNCachesListing(t *testing.T) {
+	client := &listingSNSClient{topics: []string{
+		"arn:aws:sns:us-east-1:000000000000:pedidos",
+		"arn:aws:sns:us-east-1:000000000000:pagamentos",
+	}}
+	p := NewSNSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	for _, name := range []string{"pagamentos", "pedidos", "pagamentos"} {
+		arn, err := p.TopicARN(ctx, name)
+		if err != nil || arn != "arn:aws:sns:us-east-1:000000000000:"+name {
+			t.Fatalf("TopicARN(%s): %s, %v", name, arn, err)
+		}
+	}
+	if client.calls != 2 {
+		t.Fatalf("uma listagem completa (2 páginas) deveria bastar: %d chamadas", client.calls)
+	}
+
+	// Tópicos ausentes não repetem a listagem a cada chamada
+	for i := 0; i < 3; i++ {
+		if _, err := p.TopicARN(ctx, "estoque"); err == nil {
+			t.Fatal("TopicARN deveria falhar para tópico inexistente")
+		}
+	}
+	if client.calls != 4 {
+		t.Fatalf("tópico ausente deveria ser listado uma vez: %d chamadas", client.calls)
+	}
+}
+
+func TestTopicARNFromAccount(t *testing.T) {
+	t.Setenv("AWS_REGION", "sa-east-1")
+	t.Setenv("AWS_ENDPOINT", "")
+	cloudProvider, err := provider.NewProvider()
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	client := &listingSNSClient{}
+	p := NewSNSProviderWithClient(client, cloudProvider)
+	p.SetAccountID("123456789012")
+
+	arn, err := p.TopicARN(context.Background(), "pedidos.fifo")
+	if err != nil || arn != "arn:aws:sns:sa-east-1:123456789012:pedidos.fifo" {
+		t.Fatalf("TopicARN: %s, %v", arn, err)
+	}
+	if client.calls != 0 {
+		t.Fatalf("ARN montado não deveria listar tópicos: %d chamadas", client.calls)
+	}
+}
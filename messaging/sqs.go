@@ -2,19 +2,44 @@ package messaging
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
 	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
 	"github.com/silviomfa/go-cloud-aws/provider"
 )
 
+// SQSAPI define as operações do cliente SQS utilizadas pelo provedor.
+// É satisfeita por *sqs.Client e permite substituir o cliente em testes.
+type SQSAPI interface {
+	GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error)
+	SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error)
+	SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error)
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+	DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error)
+	ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error)
+	GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error)
+	CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error)
+	DeleteQueue(ctx context.Context, params *sqs.DeleteQueueInput, optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error)
+	ListQueues(ctx context.Context, params *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error)
+	TagQueue(ctx context.Context, params *sqs.TagQueueInput, optFns ...func(*sqs.Options)) (*sqs.TagQueueOutput, error)
+	ListQueueTags(ctx context.Context, params *sqs.ListQueueTagsInput, optFns ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error)
+}
+
 // SQSProvider implementa a interface coreinterfaces.MessagingProvider para SQS
 type SQSProvider struct {
-	client   *sqs.Client
+	client   SQSAPI
 	provider *provider.Provider
+	queues   *queueResolver
+	fifo     fifoRegistry
+
+	largePayload *LargePayloadConfig
+	codecs       codecSettings
 }
 
 // NewSQSProvider cria um novo provedor de mensageria SQS
@@ -34,55 +59,196 @@ func NewSQSProvider(cloudProvider coreinterfaces.CloudProvider) (*SQSProvider, e
 	return &SQSProvider{
 		client:   client,
 		provider: awsProvider,
+		queues:   newQueueResolver(client, awsProvider.IsLocal(), awsProvider.GetEndpoint()),
 	}, nil
 }
 
+// NewSQSProviderWithClient cria um provedor SQS a partir de um cliente já configurado.
+// cloudProvider pode ser nil; nesse caso as URLs não são reescritas para endpoint local.
+func NewSQSProviderWithClient(client SQSAPI, cloudProvider *provider.Provider) *SQSProvider {
+	local, endpoint := false, ""
+	if cloudProvider != nil {
+		local, endpoint = cloudProvider.IsLocal(), cloudProvider.GetEndpoint()
+	}
+
+	return &SQSProvider{
+		client:   client,
+		provider: cloudProvider,
+		queues:   newQueueResolver(client, local, endpoint),
+	}
+}
+
+// Client retorna o cliente SQS utilizado pelo provedor
+func (p *SQSProvider) Client() SQSAPI {
+	return p.client
+}
+
+// QueueURL resolve o nome, ARN ou URL de uma fila para a sua URL
+func (p *SQSProvider) QueueURL(ctx context.Context, queueName string) (string, error) {
+	return p.queues.resolve(ctx, queueName)
+}
+
+// SetQueueOwner registra a conta proprietária de uma fila de outra conta,
+// usada ao resolver a fila pelo nome
+func (p *SQSProvider) SetQueueOwner(queueName string, accountID string) {
+	p.queues.setOwner(queueName, accountID)
+}
+
+// checkQueueError invalida o cache quando a fila deixou de existir,
+// permitindo que uma fila recriada seja resolvida novamente
+func (p *SQSProvider) checkQueueError(queueName string, err error) {
+	if err != nil && isQueueDoesNotExist(err) {
+		p.queues.invalidate(queueName)
+	}
+}
+
 // GetName retorna o nome do provedor
 func (p *SQSProvider) GetName() string {
 	return "AWS-SQS"
 }
 
-// SendMessage implementa o envio de mensagens para uma fila SQS
+// SendMessage implementa o envio de mensagens para uma fila SQS.
+// queueName pode ser o nome, o ARN ou a URL da fila. Em filas FIFO o grupo e
+// o ID de deduplicação são gerados conforme SetFIFOConfig.
 func (p *SQSProvider) SendMessage(ctx context.Context, queueName string, message interface{}) error {
-	messageBody, err := json.Marshal(message)
+	_, err := p.SendMessageWithOptions(ctx, queueName, message, SendOptions{})
+	return err
+}
+
+// SendMessageWithOptions envia uma mensagem com atributos e atraso de entrega,
+// retornando o ID atribuído pelo SQS
+func (p *SQSProvider) SendMessageWithOptions(ctx context.Context, queueName string, message interface{}, opts SendOptions) (string, error) {
+	messageBody, err := p.encodeMessage(queueName, message, &opts)
 	if err != nil {
-		return fmt.Errorf("erro ao serializar mensagem: %w", err)
+		return "", err
 	}
 
-	_, err = p.client.SendMessage(ctx, &sqs.SendMessageInput{
-		QueueUrl:    &queueName,
-		MessageBody: aws.String(string(messageBody)),
-	})
-	return err
+	return p.sendBody(ctx, queueName, message, messageBody, opts)
+}
+
+// sendBody envia um corpo já serializado; message é o valor original, usado
+// pelos extratores de grupo e deduplicação FIFO
+func (p *SQSProvider) sendBody(ctx context.Context, queueName string, message interface{}, messageBody []byte, opts SendOptions) (string, error) {
+	if opts.DelaySeconds < 0 || opts.DelaySeconds > 900 {
+		return "", fmt.Errorf("atraso inválido: %d segundos (permitido de 0 a 900)", opts.DelaySeconds)
+	}
+
+	if err := p.applyFIFO(queueName, message, messageBody, &opts); err != nil {
+		return "", err
+	}
+
+	messageBody, messageAttributes, err := p.offloadPayload(ctx, messageBody, opts.Attributes)
+	if err != nil {
+		return "", err
+	}
+
+	attributes, err := toSQSAttributes(messageAttributes)
+	if err != nil {
+		return "", err
+	}
+
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return "", err
+	}
+
+	input := &sqs.SendMessageInput{
+		QueueUrl:          &queueURL,
+		MessageBody:       aws.String(string(messageBody)),
+		MessageAttributes: attributes,
+		DelaySeconds:      opts.DelaySeconds,
+	}
+	if opts.MessageGroupID != "" {
+		input.MessageGroupId = aws.String(opts.MessageGroupID)
+	}
+	if opts.DeduplicationID != "" {
+		input.MessageDeduplicationId = aws.String(opts.DeduplicationID)
+	}
+
+	output, err := p.client.SendMessage(ctx, input)
+	if err != nil {
+		p.checkQueueError(queueName, err)
+		return "", err
+	}
+
+	return aws.ToString(output.MessageId), nil
 }
 
-// ReceiveMessages implementa a recepção de mensagens de uma fila SQS
+// ReceiveMessages implementa a recepção de mensagens de uma fila SQS.
+// Para obter atributos de mensagem e de sistema use ReceiveMessagesWithOptions.
 func (p *SQSProvider) ReceiveMessages(ctx context.Context, queueName string, maxMessages int) ([]coreinterfaces.Message, error) {
+	received, err := p.ReceiveMessagesWithOptions(ctx, queueName, ReceiveOptions{MaxMessages: maxMessages})
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]coreinterfaces.Message, len(received))
+	for i, msg := range received {
+		messages[i] = msg.Message
+	}
+
+	return messages, nil
+}
+
+// ReceiveMessagesWithOptions recebe mensagens de uma fila SQS junto com seus
+// atributos de mensagem e atributos de sistema (ApproximateReceiveCount,
+// SentTimestamp, MessageGroupId etc.)
+func (p *SQSProvider) ReceiveMessagesWithOptions(ctx context.Context, queueName string, opts ReceiveOptions) ([]ReceivedMessage, error) {
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return nil, err
+	}
+
+	messageAttributeNames := opts.MessageAttributeNames
+	if len(messageAttributeNames) == 0 {
+		messageAttributeNames = []string{"All"}
+	}
+
 	output, err := p.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
-		QueueUrl:            &queueName,
-		MaxNumberOfMessages: int32(maxMessages),
+		QueueUrl:              &queueURL,
+		MaxNumberOfMessages:   int32(opts.MaxMessages),
+		WaitTimeSeconds:       opts.WaitTimeSeconds,
+		VisibilityTimeout:     opts.VisibilityTimeout,
+		AttributeNames:        []types.QueueAttributeName{types.QueueAttributeNameAll},
+		MessageAttributeNames: messageAttributeNames,
 	})
 	if err != nil {
+		p.checkQueueError(queueName, err)
 		return nil, fmt.Errorf("erro ao receber mensagens: %w", err)
 	}
 
-	messages := make([]coreinterfaces.Message, len(output.Messages))
-	for i, msg := range output.Messages {
-		messages[i] = coreinterfaces.Message{
-			ID:            *msg.MessageId,
-			Body:          []byte(*msg.Body),
-			ReceiptHandle: *msg.ReceiptHandle,
+	messages := make([]ReceivedMessage, 0, len(output.Messages))
+	for _, msg := range output.Messages {
+		received := toReceivedMessage(msg)
+		if err := p.resolvePayload(ctx, &received); err != nil {
+			// A mensagem permanece na fila e volta após o tempo de visibilidade
+			log.Printf("Mensagem %s da fila %s ignorada: %v", received.ID, queueName, err)
+			continue
 		}
+		messages = append(messages, received)
 	}
 
 	return messages, nil
 }
 
-// DeleteMessage implementa a remoção de uma mensagem de uma fila SQS
+// DeleteMessage implementa a remoção de uma mensagem de uma fila SQS.
+// Mensagens grandes recebidas via S3 também têm o objeto removido.
 func (p *SQSProvider) DeleteMessage(ctx context.Context, queueName string, receiptHandle string) error {
-	_, err := p.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
-		QueueUrl:      &queueName,
-		ReceiptHandle: &receiptHandle,
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return err
+	}
+
+	handle, pointer := splitReceiptHandle(receiptHandle)
+	_, err = p.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      &queueURL,
+		ReceiptHandle: &handle,
 	})
-	return err
+	if err != nil {
+		p.checkQueueError(queueName, err)
+		return err
+	}
+
+	p.releasePayload(ctx, pointer)
+	return nil
 }
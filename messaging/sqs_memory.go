@@ -0,0 +1,836 @@
+package messaging
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/aws/smithy-go"
+	"github.com/google/uuid"
+	"github.com/silviomfa/go-cloud-aws/internal/sqsarn"
+)
+
+// Garantir em tempo de compilação que o cliente implementa a interface do SQS
+var _ SQSAPI = (*MemorySQSClient)(nil)
+
+// Valores padrão das filas em memória, os mesmos do SQS
+const (
+	memoryQueueAccount           = "000000000000"
+	memoryQueueRegion            = "us-east-1"
+	memoryQueueHost              = "https://sqs.memory.local/"
+	memoryDefaultVisibility      = 30
+	memoryDeduplicationInterval  = 5 * time.Minute
+	memoryLongPollCheckInterval  = 10 * time.Millisecond
+	memoryMaxReceiveMessages     = 10
+	memoryMaxVisibilityTimeout   = 43200
+	memoryMaxDelaySeconds        = 900
+	memoryMaxWaitTimeSeconds     = 20
+	memoryDefaultReceiveMessages = 1
+)
+
+// ManualClock é um relógio controlado pelo teste, para uso com
+// MemorySQSClient.SetClock
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock cria um relógio parado no instante informado
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+// Now retorna o instante atual do relógio
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance avança o relógio
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set posiciona o relógio no instante informado
+func (c *ManualClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
+// memoryMessage é uma mensagem armazenada em uma fila em memória
+type memoryMessage struct {
+	id              string
+	body            string
+	attributes      map[string]types.MessageAttributeValue
+	groupID         string
+	deduplicationID string
+	sequenceNumber  string
+	sentAt          time.Time
+	visibleAt       time.Time
+	firstReceivedAt time.Time
+	receiveCount    int
+	receiptHandle   string
+}
+
+// memoryQueue é uma fila em memória
+type memoryQueue struct {
+	name       string
+	attributes map[string]string
+	tags       map[string]string
+	messages   []*memoryMessage
+	// deduplication guarda o ID da mensagem e o fim da janela de deduplicação
+	deduplication map[string]memoryDeduplication
+	sequence      uint64
+	createdAt     time.Time
+}
+
+// memoryDeduplication é uma entrada da janela de deduplicação FIFO
+type memoryDeduplication struct {
+	messageID string
+	expires   time.Time
+}
+
+// MemorySQSClient implementa SQSAPI mantendo as filas em memória, com a
+// semântica do SQS necessária para testar consumidores: tempo de
+// visibilidade, receipt handles invalidados a cada nova entrega, contagem de
+// recepções, redirecionamento para a DLQ por maxReceiveCount, atraso de
+// entrega e ordem por grupo em filas FIFO. Como no SQS, as filas precisam
+// ser criadas (CreateQueue ou DefineQueue) antes do uso; SetAutoCreate faz
+// GetQueueUrl criar as filas inexistentes.
+type MemorySQSClient struct {
+	mu         sync.Mutex
+	queues     map[string]*memoryQueue
+	now        func() time.Time
+	autoCreate bool
+}
+
+// NewMemorySQSClient cria um novo cliente SQS em memória
+func NewMemorySQSClient() *MemorySQSClient {
+	return &MemorySQSClient{
+		queues: make(map[string]*memoryQueue),
+		now:    time.Now,
+	}
+}
+
+// NewMemorySQSProvider cria um provedor SQS que mantém as filas em memória.
+// O cliente, para DefineQueue, SetClock e ArrivedMessages, é obtido com
+// Client().(*MemorySQSClient).
+func NewMemorySQSProvider() *SQSProvider {
+	return NewSQSProviderWithClient(NewMemorySQSClient(), nil)
+}
+
+// SetClock substitui a fonte de horário, por exemplo por ManualClock.Now,
+// para testes determinísticos de visibilidade e atraso
+func (c *MemorySQSClient) SetClock(now func() time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// SetAutoCreate define se GetQueueUrl cria as filas inexistentes em vez de
+// retornar QueueDoesNotExist
+func (c *MemorySQSClient) SetAutoCreate(enabled bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.autoCreate = enabled
+}
+
+// DefineQueue cria a fila, ou atualiza os atributos de uma fila existente,
+// e retorna a sua URL. Atributos aceitos: VisibilityTimeout, DelaySeconds,
+// RedrivePolicy e ContentBasedDeduplication.
+func (c *MemorySQSClient) DefineQueue(name string, attributes map[string]string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue := c.queue(name)
+	for k, v := range attributes {
+		queue.attributes[k] = v
+	}
+	return memoryQueueURL(name)
+}
+
+// ArrivedMessages retorna os corpos das mensagens da fila, visíveis ou não,
+// na ordem de chegada
+func (c *MemorySQSClient) ArrivedMessages(name string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, ok := c.queues[name]
+	if !ok {
+		return nil
+	}
+	bodies := make([]string, len(queue.messages))
+	for i, msg := range queue.messages {
+		bodies[i] = msg.body
+	}
+	return bodies
+}
+
+// queue retorna a fila, criando-a se necessário. Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) queue(name string) *memoryQueue {
+	queue, ok := c.queues[name]
+	if !ok {
+		queue = &memoryQueue{
+			name:          name,
+			attributes:    map[string]string{string(types.QueueAttributeNameVisibilityTimeout): strconv.Itoa(memoryDefaultVisibility)},
+			tags:          make(map[string]string),
+			deduplication: make(map[string]memoryDeduplication),
+			createdAt:     c.now(),
+		}
+		c.queues[name] = queue
+	}
+	return queue
+}
+
+// queueByURL localiza a fila pela URL. Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) queueByURL(queueURL *string) (*memoryQueue, error) {
+	name := QueueNameFromURL(aws.ToString(queueURL))
+	queue, ok := c.queues[name]
+	if !ok {
+		return nil, &types.QueueDoesNotExist{Message: aws.String(fmt.Sprintf("fila %s não existe", name))}
+	}
+	return queue, nil
+}
+
+// GetQueueUrl retorna a URL da fila. Filas inexistentes resultam em
+// QueueDoesNotExist, exceto com SetAutoCreate, que as cria.
+func (c *MemorySQSClient) GetQueueUrl(ctx context.Context, params *sqs.GetQueueUrlInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueUrlOutput, error) {
+	name := aws.ToString(params.QueueName)
+	if name == "" {
+		return nil, &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: "nome da fila não informado"}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.queues[name]; !ok {
+		if !c.autoCreate {
+			return nil, &types.QueueDoesNotExist{Message: aws.String(fmt.Sprintf("fila %s não existe", name))}
+		}
+		c.queue(name)
+	}
+	return &sqs.GetQueueUrlOutput{QueueUrl: aws.String(memoryQueueURL(name))}, nil
+}
+
+// CreateQueue cria a fila com atributos e tags. Como no SQS, criar uma fila
+// existente apenas retorna a sua URL.
+func (c *MemorySQSClient) CreateQueue(ctx context.Context, params *sqs.CreateQueueInput, optFns ...func(*sqs.Options)) (*sqs.CreateQueueOutput, error) {
+	name := aws.ToString(params.QueueName)
+	if name == "" {
+		return nil, invalidParameter("nome da fila não informado")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, exists := c.queues[name]
+	queue := c.queue(name)
+	if !exists {
+		for k, v := range params.Attributes {
+			queue.attributes[k] = v
+		}
+		for k, v := range params.Tags {
+			queue.tags[k] = v
+		}
+	}
+	return &sqs.CreateQueueOutput{QueueUrl: aws.String(memoryQueueURL(name))}, nil
+}
+
+// DeleteQueue remove a fila e as suas mensagens
+func (c *MemorySQSClient) DeleteQueue(ctx context.Context, params *sqs.DeleteQueueInput, optFns ...func(*sqs.Options)) (*sqs.DeleteQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	delete(c.queues, queue.name)
+	return &sqs.DeleteQueueOutput{}, nil
+}
+
+// ListQueues lista as URLs das filas com o prefixo informado, em ordem
+func (c *MemorySQSClient) ListQueues(ctx context.Context, params *sqs.ListQueuesInput, optFns ...func(*sqs.Options)) (*sqs.ListQueuesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prefix := aws.ToString(params.QueueNamePrefix)
+	var urls []string
+	for name := range c.queues {
+		if strings.HasPrefix(name, prefix) {
+			urls = append(urls, memoryQueueURL(name))
+		}
+	}
+	sort.Strings(urls)
+	return &sqs.ListQueuesOutput{QueueUrls: urls}, nil
+}
+
+// TagQueue acrescenta ou substitui tags da fila
+func (c *MemorySQSClient) TagQueue(ctx context.Context, params *sqs.TagQueueInput, optFns ...func(*sqs.Options)) (*sqs.TagQueueOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range params.Tags {
+		queue.tags[k] = v
+	}
+	return &sqs.TagQueueOutput{}, nil
+}
+
+// ListQueueTags retorna as tags da fila
+func (c *MemorySQSClient) ListQueueTags(ctx context.Context, params *sqs.ListQueueTagsInput, optFns ...func(*sqs.Options)) (*sqs.ListQueueTagsOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(queue.tags))
+	for k, v := range queue.tags {
+		tags[k] = v
+	}
+	return &sqs.ListQueueTagsOutput{Tags: tags}, nil
+}
+
+// GetQueueAttributes retorna os atributos configurados e as contagens de mensagens
+func (c *MemorySQSClient) GetQueueAttributes(ctx context.Context, params *sqs.GetQueueAttributesInput, optFns ...func(*sqs.Options)) (*sqs.GetQueueAttributesOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	all := map[string]string{
+		string(types.QueueAttributeNameQueueArn):         memoryQueueARN(queue.name),
+		string(types.QueueAttributeNameCreatedTimestamp): strconv.FormatInt(queue.createdAt.Unix(), 10),
+	}
+	for k, v := range queue.attributes {
+		all[k] = v
+	}
+	if IsFIFOQueue(queue.name) {
+		all[string(types.QueueAttributeNameFifoQueue)] = "true"
+	}
+
+	visible, inflight, delayed := 0, 0, 0
+	for _, msg := range queue.messages {
+		switch {
+		case !msg.visibleAt.After(now):
+			visible++
+		case msg.receiptHandle != "":
+			inflight++
+		default:
+			delayed++
+		}
+	}
+	all[string(types.QueueAttributeNameApproximateNumberOfMessages)] = strconv.Itoa(visible)
+	all[string(types.QueueAttributeNameApproximateNumberOfMessagesNotVisible)] = strconv.Itoa(inflight)
+	all[string(types.QueueAttributeNameApproximateNumberOfMessagesDelayed)] = strconv.Itoa(delayed)
+
+	attributes := make(map[string]string)
+	for _, name := range params.AttributeNames {
+		if name == types.QueueAttributeNameAll {
+			attributes = all
+			break
+		}
+		if v, ok := all[string(name)]; ok {
+			attributes[string(name)] = v
+		}
+	}
+	return &sqs.GetQueueAttributesOutput{Attributes: attributes}, nil
+}
+
+// SendMessage enfileira uma mensagem
+func (c *MemorySQSClient) SendMessage(ctx context.Context, params *sqs.SendMessageInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := c.enqueue(queue, aws.ToString(params.MessageBody), params.MessageAttributes, params.DelaySeconds,
+		aws.ToString(params.MessageGroupId), aws.ToString(params.MessageDeduplicationId))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &sqs.SendMessageOutput{
+		MessageId:        aws.String(msg.id),
+		MD5OfMessageBody: aws.String(md5Hex(msg.body)),
+	}
+	if msg.sequenceNumber != "" {
+		output.SequenceNumber = aws.String(msg.sequenceNumber)
+	}
+	return output, nil
+}
+
+// SendMessageBatch enfileira as entradas, uma a uma, reportando falhas por entrada
+func (c *MemorySQSClient) SendMessageBatch(ctx context.Context, params *sqs.SendMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.SendMessageBatchOutput, error) {
+	if err := validateBatchSize(len(params.Entries)); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &sqs.SendMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		msg, err := c.enqueue(queue, aws.ToString(entry.MessageBody), entry.MessageAttributes, entry.DelaySeconds,
+			aws.ToString(entry.MessageGroupId), aws.ToString(entry.MessageDeduplicationId))
+		if err != nil {
+			output.Failed = append(output.Failed, batchErrorEntry(entry.Id, err))
+			continue
+		}
+
+		success := types.SendMessageBatchResultEntry{
+			Id:               entry.Id,
+			MessageId:        aws.String(msg.id),
+			MD5OfMessageBody: aws.String(md5Hex(msg.body)),
+		}
+		if msg.sequenceNumber != "" {
+			success.SequenceNumber = aws.String(msg.sequenceNumber)
+		}
+		output.Successful = append(output.Successful, success)
+	}
+	return output, nil
+}
+
+// enqueue valida e grava a mensagem, aplicando atraso e deduplicação FIFO.
+// Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) enqueue(queue *memoryQueue, body string, attributes map[string]types.MessageAttributeValue, delaySeconds int32, groupID, deduplicationID string) (*memoryMessage, error) {
+	if body == "" {
+		return nil, &smithy.GenericAPIError{Code: "MissingParameter", Message: "corpo da mensagem vazio"}
+	}
+	if delaySeconds < 0 || delaySeconds > memoryMaxDelaySeconds {
+		return nil, invalidParameter("DelaySeconds deve estar entre 0 e %d", memoryMaxDelaySeconds)
+	}
+
+	now := c.now()
+	fifo := IsFIFOQueue(queue.name)
+	if fifo {
+		if delaySeconds != 0 {
+			return nil, invalidParameter("filas FIFO não aceitam DelaySeconds por mensagem")
+		}
+		if groupID == "" {
+			return nil, &smithy.GenericAPIError{Code: "MissingParameter", Message: "MessageGroupId é obrigatório em filas FIFO"}
+		}
+		if deduplicationID == "" {
+			if queue.attributes[string(types.QueueAttributeNameContentBasedDeduplication)] != "true" {
+				return nil, invalidParameter("MessageDeduplicationId é obrigatório sem ContentBasedDeduplication")
+			}
+			sum := sha256.Sum256([]byte(body))
+			deduplicationID = hex.EncodeToString(sum[:])
+		}
+
+		// Dentro da janela de deduplicação a mensagem é aceita, mas não enfileirada
+		if previous, ok := queue.deduplication[deduplicationID]; ok && now.Before(previous.expires) {
+			return &memoryMessage{id: previous.messageID, body: body}, nil
+		}
+	}
+	if delaySeconds == 0 {
+		delaySeconds = int32(queueIntAttribute(queue, types.QueueAttributeNameDelaySeconds, 0))
+	}
+
+	msg := &memoryMessage{
+		id:              uuid.New().String(),
+		body:            body,
+		attributes:      attributes,
+		sentAt:          now,
+		visibleAt:       now.Add(time.Duration(delaySeconds) * time.Second),
+		groupID:         groupID,
+		deduplicationID: deduplicationID,
+	}
+	if fifo {
+		queue.sequence++
+		msg.sequenceNumber = fmt.Sprintf("%020d", queue.sequence)
+		queue.deduplication[deduplicationID] = memoryDeduplication{messageID: msg.id, expires: now.Add(memoryDeduplicationInterval)}
+	}
+	queue.messages = append(queue.messages, msg)
+	return msg, nil
+}
+
+// ReceiveMessage entrega as mensagens visíveis. Com WaitTimeSeconds, espera
+// (em tempo real) até haver mensagens ou o tempo acabar.
+func (c *MemorySQSClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	maxMessages := int(params.MaxNumberOfMessages)
+	if maxMessages == 0 {
+		maxMessages = memoryDefaultReceiveMessages
+	}
+	if maxMessages < 1 || maxMessages > memoryMaxReceiveMessages {
+		return nil, invalidParameter("MaxNumberOfMessages deve estar entre 1 e %d", memoryMaxReceiveMessages)
+	}
+	if params.WaitTimeSeconds < 0 || params.WaitTimeSeconds > memoryMaxWaitTimeSeconds {
+		return nil, invalidParameter("WaitTimeSeconds deve estar entre 0 e %d", memoryMaxWaitTimeSeconds)
+	}
+	if params.VisibilityTimeout < 0 || params.VisibilityTimeout > memoryMaxVisibilityTimeout {
+		return nil, invalidParameter("VisibilityTimeout deve estar entre 0 e %d", memoryMaxVisibilityTimeout)
+	}
+
+	deadline := time.Now().Add(time.Duration(params.WaitTimeSeconds) * time.Second)
+	for {
+		messages, err := c.receive(params, maxMessages)
+		if err != nil || len(messages) > 0 || !time.Now().Before(deadline) {
+			return &sqs.ReceiveMessageOutput{Messages: messages}, err
+		}
+
+		// Verificações periódicas também percebem avanços de um ManualClock
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(memoryLongPollCheckInterval):
+		}
+	}
+}
+
+// receive seleciona e entrega as mensagens disponíveis
+func (c *MemorySQSClient) receive(params *sqs.ReceiveMessageInput, maxMessages int) ([]types.Message, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	now := c.now()
+	visibility := params.VisibilityTimeout
+	if visibility == 0 {
+		// O cliente omite zero, e o SQS aplica o padrão da fila
+		visibility = int32(queueIntAttribute(queue, types.QueueAttributeNameVisibilityTimeout, memoryDefaultVisibility))
+	}
+
+	c.redriveExhausted(queue, now)
+
+	fifo := IsFIFOQueue(queue.name)
+	blocked := make(map[string]bool)
+	var messages []types.Message
+	for _, msg := range queue.messages {
+		if len(messages) == maxMessages {
+			break
+		}
+
+		// Em filas FIFO, uma mensagem indisponível bloqueia as seguintes do grupo
+		if msg.visibleAt.After(now) {
+			if fifo {
+				blocked[msg.groupID] = true
+			}
+			continue
+		}
+		if fifo && blocked[msg.groupID] {
+			continue
+		}
+
+		msg.receiveCount++
+		if msg.firstReceivedAt.IsZero() {
+			msg.firstReceivedAt = now
+		}
+		// Cada entrega gera um novo receipt handle; os anteriores deixam de valer
+		msg.receiptHandle = uuid.New().String()
+		msg.visibleAt = now.Add(time.Duration(visibility) * time.Second)
+
+		messages = append(messages, types.Message{
+			MessageId:         aws.String(msg.id),
+			ReceiptHandle:     aws.String(msg.receiptHandle),
+			Body:              aws.String(msg.body),
+			MD5OfBody:         aws.String(md5Hex(msg.body)),
+			Attributes:        msg.systemAttributes(),
+			MessageAttributes: selectMessageAttributes(msg.attributes, params.MessageAttributeNames),
+		})
+	}
+	return messages, nil
+}
+
+// redriveExhausted move para a DLQ as mensagens visíveis que já atingiram o
+// maxReceiveCount da RedrivePolicy. Se a DLQ não existir, as mensagens
+// continuam na fila de origem. Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) redriveExhausted(queue *memoryQueue, now time.Time) {
+	raw := queue.attributes[string(types.QueueAttributeNameRedrivePolicy)]
+	if raw == "" {
+		return
+	}
+
+	policy, err := parseRedrivePolicy(raw)
+	if err != nil || policy.MaxReceiveCount <= 0 {
+		return
+	}
+	maxReceiveCount := policy.MaxReceiveCount
+	dlqARN, err := sqsarn.Parse(policy.DeadLetterTargetArn)
+	if err != nil {
+		return
+	}
+	dlq, ok := c.queues[dlqARN.Name]
+	if !ok {
+		return
+	}
+
+	remaining := queue.messages[:0]
+	var moved []*memoryMessage
+	for _, msg := range queue.messages {
+		if !msg.visibleAt.After(now) && msg.receiveCount >= maxReceiveCount {
+			moved = append(moved, msg)
+			continue
+		}
+		remaining = append(remaining, msg)
+	}
+	queue.messages = remaining
+	if len(moved) == 0 {
+		return
+	}
+
+	for _, msg := range moved {
+		// A mensagem mantém ID, corpo, atributos e contagem de recepções
+		msg.receiptHandle = ""
+		msg.visibleAt = now
+		if IsFIFOQueue(dlq.name) {
+			dlq.sequence++
+			msg.sequenceNumber = fmt.Sprintf("%020d", dlq.sequence)
+		}
+		dlq.messages = append(dlq.messages, msg)
+	}
+}
+
+// DeleteMessage remove a mensagem pelo receipt handle da última entrega
+func (c *MemorySQSClient) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.delete(queue, aws.ToString(params.ReceiptHandle)); err != nil {
+		return nil, err
+	}
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+// DeleteMessageBatch remove as entradas, uma a uma, reportando falhas por entrada
+func (c *MemorySQSClient) DeleteMessageBatch(ctx context.Context, params *sqs.DeleteMessageBatchInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageBatchOutput, error) {
+	if err := validateBatchSize(len(params.Entries)); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &sqs.DeleteMessageBatchOutput{}
+	for _, entry := range params.Entries {
+		if err := c.delete(queue, aws.ToString(entry.ReceiptHandle)); err != nil {
+			output.Failed = append(output.Failed, batchErrorEntry(entry.Id, err))
+			continue
+		}
+		output.Successful = append(output.Successful, types.DeleteMessageBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+// delete remove a mensagem com o receipt handle informado. Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) delete(queue *memoryQueue, receiptHandle string) error {
+	for i, msg := range queue.messages {
+		if receiptHandle != "" && msg.receiptHandle == receiptHandle {
+			queue.messages = append(queue.messages[:i], queue.messages[i+1:]...)
+			return nil
+		}
+	}
+	return &types.ReceiptHandleIsInvalid{Message: aws.String("receipt handle inválido ou substituído por nova entrega")}
+}
+
+// ChangeMessageVisibility altera o tempo de visibilidade de uma mensagem em processamento
+func (c *MemorySQSClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.changeVisibility(queue, aws.ToString(params.ReceiptHandle), params.VisibilityTimeout); err != nil {
+		return nil, err
+	}
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+// ChangeMessageVisibilityBatch altera a visibilidade das entradas, uma a uma
+func (c *MemorySQSClient) ChangeMessageVisibilityBatch(ctx context.Context, params *sqs.ChangeMessageVisibilityBatchInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityBatchOutput, error) {
+	if err := validateBatchSize(len(params.Entries)); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	queue, err := c.queueByURL(params.QueueUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &sqs.ChangeMessageVisibilityBatchOutput{}
+	for _, entry := range params.Entries {
+		if err := c.changeVisibility(queue, aws.ToString(entry.ReceiptHandle), entry.VisibilityTimeout); err != nil {
+			output.Failed = append(output.Failed, batchErrorEntry(entry.Id, err))
+			continue
+		}
+		output.Successful = append(output.Successful, types.ChangeMessageVisibilityBatchResultEntry{Id: entry.Id})
+	}
+	return output, nil
+}
+
+// changeVisibility aplica o novo prazo a partir de agora, limitado a 12 horas
+// desde a entrega. Deve ser chamado com mu bloqueado.
+func (c *MemorySQSClient) changeVisibility(queue *memoryQueue, receiptHandle string, timeoutSeconds int32) error {
+	if timeoutSeconds < 0 || timeoutSeconds > memoryMaxVisibilityTimeout {
+		return invalidParameter("VisibilityTimeout deve estar entre 0 e %d", memoryMaxVisibilityTimeout)
+	}
+
+	now := c.now()
+	for _, msg := range queue.messages {
+		if receiptHandle == "" || msg.receiptHandle != receiptHandle {
+			continue
+		}
+		if !msg.visibleAt.After(now) {
+			return &types.MessageNotInflight{Message: aws.String("mensagem não está em processamento")}
+		}
+		msg.visibleAt = now.Add(time.Duration(timeoutSeconds) * time.Second)
+		return nil
+	}
+	return &types.ReceiptHandleIsInvalid{Message: aws.String("receipt handle inválido ou substituído por nova entrega")}
+}
+
+// systemAttributes monta os atributos de sistema da mensagem
+func (m *memoryMessage) systemAttributes() map[string]string {
+	attributes := map[string]string{
+		SystemAttributeApproximateReceiveCount: strconv.Itoa(m.receiveCount),
+		SystemAttributeSentTimestamp:           strconv.FormatInt(m.sentAt.UnixMilli(), 10),
+		SystemAttributeFirstReceiveTimestamp:   strconv.FormatInt(m.firstReceivedAt.UnixMilli(), 10),
+		SystemAttributeSenderID:                memoryQueueAccount,
+	}
+	if m.groupID != "" {
+		attributes[SystemAttributeMessageGroupID] = m.groupID
+	}
+	if m.deduplicationID != "" {
+		attributes[SystemAttributeMessageDeduplicationID] = m.deduplicationID
+	}
+	if m.sequenceNumber != "" {
+		attributes[SystemAttributeSequenceNumber] = m.sequenceNumber
+	}
+	return attributes
+}
+
+// selectMessageAttributes filtra os atributos pelos nomes pedidos, aceitando
+// "All", ".*" e prefixos terminados em ".*"
+func selectMessageAttributes(attributes map[string]types.MessageAttributeValue, names []string) map[string]types.MessageAttributeValue {
+	if len(attributes) == 0 || len(names) == 0 {
+		return nil
+	}
+
+	selected := make(map[string]types.MessageAttributeValue)
+	for _, name := range names {
+		if name == "All" || name == ".*" {
+			return attributes
+		}
+		prefix, isPrefix := strings.CutSuffix(name, ".*")
+		for k, v := range attributes {
+			if k == name || (isPrefix && strings.HasPrefix(k, prefix+".")) {
+				selected[k] = v
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	return selected
+}
+
+// queueIntAttribute lê um atributo numérico da fila
+func queueIntAttribute(queue *memoryQueue, name types.QueueAttributeName, fallback int) int {
+	value, err := strconv.Atoi(queue.attributes[string(name)])
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// validateBatchSize verifica o número de entradas de uma chamada em lote
+func validateBatchSize(n int) error {
+	if n == 0 {
+		return &types.EmptyBatchRequest{Message: aws.String("lote vazio")}
+	}
+	if n > maxBatchEntries {
+		return &types.TooManyEntriesInBatchRequest{Message: aws.String(fmt.Sprintf("lote com %d entradas", n))}
+	}
+	return nil
+}
+
+// batchErrorEntry converte o erro de uma entrada em falha de lote. As falhas
+// da fila em memória são sempre do chamador.
+func batchErrorEntry(id *string, err error) types.BatchResultErrorEntry {
+	code := "InternalError"
+	if apiErr, ok := err.(smithy.APIError); ok {
+		code = apiErr.ErrorCode()
+	}
+	return types.BatchResultErrorEntry{
+		Id:          id,
+		Code:        aws.String(code),
+		Message:     aws.String(err.Error()),
+		SenderFault: true,
+	}
+}
+
+// invalidParameter cria o erro de parâmetro inválido do SQS
+func invalidParameter(format string, args ...interface{}) error {
+	return &smithy.GenericAPIError{Code: "InvalidParameterValue", Message: fmt.Sprintf(format, args...)}
+}
+
+// memoryQueueURL monta a URL de uma fila em memória
+func memoryQueueURL(name string) string {
+	return memoryQueueHost + memoryQueueAccount + "/" + name
+}
+
+// memoryQueueARN monta o ARN de uma fila em memória, usado em RedrivePolicy
+func memoryQueueARN(name string) string {
+	return "arn:aws:sqs:" + memoryQueueRegion + ":" + memoryQueueAccount + ":" + name
+}
+
+// md5Hex calcula o MD5 do corpo, como em MD5OfMessageBody
+func md5Hex(body string) string {
+	sum := md5.Sum([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// MemoryQueueARN retorna o ARN de uma fila em memória, para compor a
+// RedrivePolicy passada a DefineQueue
+func MemoryQueueARN(name string) string {
+	return memoryQueueARN(name)
+}
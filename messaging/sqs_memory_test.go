@@ -0,0 +1,243 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// newTestMemorySQS cria um cliente em memória com relógio manual
+func newTestMemorySQS() (*MemorySQSClient, *ManualClock) {
+	clock := NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	client := NewMemorySQSClient()
+	client.SetClock(clock.Now)
+	return client, clock
+}
+
+// sendTestMessage envia um corpo à fila; groupID só é usado em filas FIFO
+func sendTestMessage(t *testing.T, client *MemorySQSClient, queueURL, body, groupID string) {
+	t.Helper()
+	input := &sqs.SendMessageInput{QueueUrl: aws.String(queueURL), MessageBody: aws.String(body)}
+	if groupID != "" {
+		input.MessageGroupId = aws.String(groupID)
+	}
+	if _, err := client.SendMessage(context.Background(), input); err != nil {
+		t.Fatalf("SendMessage(%s): %v", body, err)
+	}
+}
+
+// receiveTestMessages recebe até max mensagens, sem long polling
+func receiveTestMessages(t *testing.T, client *MemorySQSClient, queueURL string, max int32) []types.Message {
+	t.Helper()
+	output, err := client.ReceiveMessage(context.Background(), &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(queueURL),
+		MaxNumberOfMessages: max,
+	})
+	if err != nil {
+		t.Fatalf("ReceiveMessage: %v", err)
+	}
+	return output.Messages
+}
+
+// bodiesOf retorna os corpos das mensagens, na ordem
+func bodiesOf(messages []types.Message) []string {
+	bodies := make([]string, len(messages))
+	for i, msg := range messages {
+		bodies[i] = aws.ToString(msg.Body)
+	}
+	return bodies
+}
+
+// redrivePolicy monta a RedrivePolicy para a DLQ informada
+func redrivePolicy(dlq string, maxReceiveCount int) map[string]string {
+	return map[string]string{
+		string(types.QueueAttributeNameVisibilityTimeout): "1",
+		string(types.QueueAttributeNameRedrivePolicy):     `{"deadLetterTargetArn":"` + memoryQueueARN(dlq) + `","maxReceiveCount":"` + strconv.Itoa(maxReceiveCount) + `"}`,
+	}
+}
+
+func TestMemorySQSGetQueueUrlRequiresQueue(t *testing.T) {
+	client := NewMemorySQSClient()
+	ctx := context.Background()
+
+	_, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String("missing")})
+	if !isQueueDoesNotExist(err) {
+		t.Fatalf("fila inexistente: esperado QueueDoesNotExist, obtido %v", err)
+	}
+	if client.ArrivedMessages("missing") != nil || len(client.queues) != 0 {
+		t.Fatal("GetQueueUrl não deveria criar a fila")
+	}
+
+	p := NewSQSProviderWithClient(client, nil)
+	if err := p.SendMessage(ctx, "missing", "x"); !isQueueDoesNotExist(err) {
+		t.Fatalf("envio para fila inexistente: esperado QueueDoesNotExist, obtido %v", err)
+	}
+
+	client.SetAutoCreate(true)
+	output, err := client.GetQueueUrl(ctx, &sqs.GetQueueUrlInput{QueueName: aws.String("created")})
+	if err != nil {
+		t.Fatalf("GetQueueUrl com SetAutoCreate: %v", err)
+	}
+	if aws.ToString(output.QueueUrl) != memoryQueueURL("created") {
+		t.Fatalf("URL inesperada: %s", aws.ToString(output.QueueUrl))
+	}
+}
+
+func TestMemorySQSProviderClient(t *testing.T) {
+	p := NewMemorySQSProvider()
+	client, ok := p.Client().(*MemorySQSClient)
+	if !ok {
+		t.Fatalf("Client(): esperado *MemorySQSClient, obtido %T", p.Client())
+	}
+
+	client.DefineQueue("jobs", nil)
+	if err := p.SendMessage(context.Background(), "jobs", "x"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if got := client.ArrivedMessages("jobs"); len(got) != 1 {
+		t.Fatalf("ArrivedMessages: obtido %v", got)
+	}
+}
+
+func TestMemorySQSVisibilityTimeout(t *testing.T) {
+	client, clock := newTestMemorySQS()
+	queueURL := client.DefineQueue("jobs", map[string]string{string(types.QueueAttributeNameVisibilityTimeout): "10"})
+	sendTestMessage(t, client, queueURL, "job", "")
+
+	first := receiveTestMessages(t, client, queueURL, 1)
+	if len(first) != 1 || first[0].Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)] != "1" {
+		t.Fatalf("primeira entrega: obtido %+v", first)
+	}
+	if got := receiveTestMessages(t, client, queueURL, 1); len(got) != 0 {
+		t.Fatalf("mensagem em processamento não deveria ser entregue: %v", bodiesOf(got))
+	}
+
+	clock.Advance(9 * time.Second)
+	if got := receiveTestMessages(t, client, queueURL, 1); len(got) != 0 {
+		t.Fatalf("mensagem entregue antes do fim da visibilidade: %v", bodiesOf(got))
+	}
+
+	clock.Advance(2 * time.Second)
+	second := receiveTestMessages(t, client, queueURL, 1)
+	if len(second) != 1 || second[0].Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)] != "2" {
+		t.Fatalf("segunda entrega: obtido %+v", second)
+	}
+
+	// O receipt handle da primeira entrega foi substituído
+	_, err := client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{QueueUrl: aws.String(queueURL), ReceiptHandle: first[0].ReceiptHandle})
+	var invalid *types.ReceiptHandleIsInvalid
+	if !errors.As(err, &invalid) {
+		t.Fatalf("receipt handle antigo: esperado ReceiptHandleIsInvalid, obtido %v", err)
+	}
+	if _, err := client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{QueueUrl: aws.String(queueURL), ReceiptHandle: second[0].ReceiptHandle}); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if got := client.ArrivedMessages("jobs"); len(got) != 0 {
+		t.Fatalf("mensagem removida ainda está na fila: %v", got)
+	}
+}
+
+func TestMemorySQSRedrivesToDLQ(t *testing.T) {
+	client, clock := newTestMemorySQS()
+	client.DefineQueue("jobs-dlq", nil)
+	queueURL := client.DefineQueue("jobs", redrivePolicy("jobs-dlq", 2))
+	sendTestMessage(t, client, queueURL, "job", "")
+
+	for i := 0; i < 2; i++ {
+		if got := receiveTestMessages(t, client, queueURL, 1); len(got) != 1 {
+			t.Fatalf("entrega %d: obtido %v", i+1, bodiesOf(got))
+		}
+		clock.Advance(2 * time.Second)
+	}
+
+	if got := receiveTestMessages(t, client, queueURL, 1); len(got) != 0 {
+		t.Fatalf("mensagem esgotada não deveria ser entregue: %v", bodiesOf(got))
+	}
+	if got := client.ArrivedMessages("jobs"); len(got) != 0 {
+		t.Fatalf("fila de origem: obtido %v", got)
+	}
+	if got := client.ArrivedMessages("jobs-dlq"); !reflect.DeepEqual(got, []string{"job"}) {
+		t.Fatalf("DLQ: obtido %v", got)
+	}
+}
+
+func TestMemorySQSMissingDLQKeepsMessages(t *testing.T) {
+	client, clock := newTestMemorySQS()
+	queueURL := client.DefineQueue("jobs", redrivePolicy("missing-dlq", 1))
+	sendTestMessage(t, client, queueURL, "job", "")
+
+	for i := 1; i <= 3; i++ {
+		got := receiveTestMessages(t, client, queueURL, 1)
+		if len(got) != 1 {
+			t.Fatalf("entrega %d: mensagem deveria continuar na origem sem DLQ", i)
+		}
+		if count := got[0].Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]; count != strconv.Itoa(i) {
+			t.Fatalf("entrega %d: ApproximateReceiveCount %s", i, count)
+		}
+		clock.Advance(2 * time.Second)
+	}
+	if client.ArrivedMessages("missing-dlq") != nil {
+		t.Fatal("a DLQ inexistente não deveria ser criada")
+	}
+}
+
+func TestMemorySQSFIFOGroupOrder(t *testing.T) {
+	client, _ := newTestMemorySQS()
+	queueURL := client.DefineQueue("jobs.fifo", map[string]string{string(types.QueueAttributeNameContentBasedDeduplication): "true"})
+	sendTestMessage(t, client, queueURL, "A1", "A")
+	sendTestMessage(t, client, queueURL, "A2", "A")
+	sendTestMessage(t, client, queueURL, "B1", "B")
+	// Duplicada dentro da janela de deduplicação: aceita, mas não enfileirada
+	sendTestMessage(t, client, queueURL, "A1", "A")
+
+	first := receiveTestMessages(t, client, queueURL, 1)
+	if !reflect.DeepEqual(bodiesOf(first), []string{"A1"}) {
+		t.Fatalf("primeira recepção: obtido %v", bodiesOf(first))
+	}
+
+	// A1 em processamento bloqueia A2, mas não o grupo B
+	if got := receiveTestMessages(t, client, queueURL, 10); !reflect.DeepEqual(bodiesOf(got), []string{"B1"}) {
+		t.Fatalf("segunda recepção: obtido %v", bodiesOf(got))
+	}
+
+	if _, err := client.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{QueueUrl: aws.String(queueURL), ReceiptHandle: first[0].ReceiptHandle}); err != nil {
+		t.Fatalf("DeleteMessage: %v", err)
+	}
+	if got := receiveTestMessages(t, client, queueURL, 10); !reflect.DeepEqual(bodiesOf(got), []string{"A2"}) {
+		t.Fatalf("após remover A1: obtido %v", bodiesOf(got))
+	}
+}
+
+func TestSQSProviderInvalidatesDeletedQueue(t *testing.T) {
+	client := NewMemorySQSClient()
+	p := NewSQSProviderWithClient(client, nil)
+	ctx := context.Background()
+
+	queueURL := client.DefineQueue("jobs", nil)
+	if err := p.SendMessage(ctx, "jobs", "x"); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+	if _, err := client.DeleteQueue(ctx, &sqs.DeleteQueueInput{QueueUrl: aws.String(queueURL)}); err != nil {
+		t.Fatalf("DeleteQueue: %v", err)
+	}
+
+	// A falha invalida o cache, e a URL volta a ser consultada
+	if err := p.SendMessage(ctx, "jobs", "x"); !isQueueDoesNotExist(err) {
+		t.Fatalf("envio para fila removida: esperado QueueDoesNotExist, obtido %v", err)
+	}
+	if _, err := p.QueueURL(ctx, "jobs"); !isQueueDoesNotExist(err) {
+		t.Fatalf("QueueURL após remoção: esperado QueueDoesNotExist, obtido %v", err)
+	}
+
+	client.DefineQueue("jobs", nil)
+	if err := p.SendMessage(ctx, "jobs", "y"); err != nil {
+		t.Fatalf("SendMessage após recriar a fila: %v", err)
+	}
+}
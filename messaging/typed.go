@@ -0,0 +1,241 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+)
+
+// PoisonHandler recebe as mensagens cujo corpo não pôde ser desserializado.
+// Cabe ao handler removê-las, movê-las ou deixá-las voltar à fila.
+type PoisonHandler func(ctx context.Context, queueName string, msg ReceivedMessage, err error)
+
+// TypedMessage é uma mensagem recebida com o corpo desserializado
+type TypedMessage[T any] struct {
+	ReceivedMessage
+	Value T
+}
+
+// codecSettings guarda o registro de codecs, a configuração por fila, o
+// registro de schemas e o handler de mensagens inválidas de um provedor
+type codecSettings struct {
+	mu       sync.RWMutex
+	registry *CodecRegistry
+	configs  map[string]CodecConfig
+	poison   PoisonHandler
+	schemas  schemaHook
+}
+
+// SetCodecRegistry substitui o registro de codecs do provedor (padrão DefaultCodecs)
+func (p *SQSProvider) SetCodecRegistry(registry *CodecRegistry) {
+	p.codecs.mu.Lock()
+	defer p.codecs.mu.Unlock()
+	p.codecs.registry = registry
+}
+
+// SetSchemaRegistry ativa a validação por JSON Schema no envio e na recepção.
+// No envio, mensagens com tipo conhecido (SendOptions.MessageType,
+// MessageTyper ou RegisterType) são validadas contra a versão atual e levam
+// os atributos de tipo e versão; na recepção, DecodeMessage e Receive validam
+// e aplicam os upcasters. Erros de validação no envio retornam
+// SchemaValidationError; na recepção a mensagem vai para o PoisonHandler.
+// SNSProvider, EventBridgeProvider e KinesisProvider validam o envio da mesma forma.
+func (p *SQSProvider) SetSchemaRegistry(schemas *SchemaRegistry) {
+	p.codecs.schemas.set(schemas)
+}
+
+// SetCodec define o tipo de conteúdo e a compressão das mensagens enviadas
+// para a fila. Filas sem configuração continuam recebendo JSON sem atributos
+// de conteúdo. SendOptions.ContentType e ContentEncoding têm precedência.
+func (p *SQSProvider) SetCodec(queueName string, config CodecConfig) {
+	p.codecs.mu.Lock()
+	defer p.codecs.mu.Unlock()
+	if p.codecs.configs == nil {
+		p.codecs.configs = make(map[string]CodecConfig)
+	}
+	p.codecs.configs[queueBaseName(queueName)] = config
+}
+
+// SetPoisonHandler define o destino das mensagens que falham na
+// desserialização em Receive. Sem handler, elas são registradas no log e
+// voltam à fila após o tempo de visibilidade, até irem para a DLQ.
+func (p *SQSProvider) SetPoisonHandler(handler PoisonHandler) {
+	p.codecs.mu.Lock()
+	defer p.codecs.mu.Unlock()
+	p.codecs.poison = handler
+}
+
+// codecRegistry retorna o registro de codecs do provedor
+func (p *SQSProvider) codecRegistry() *CodecRegistry {
+	p.codecs.mu.RLock()
+	defer p.codecs.mu.RUnlock()
+	if p.codecs.registry != nil {
+		return p.codecs.registry
+	}
+	return DefaultCodecs
+}
+
+// encodeMessage serializa a mensagem conforme a configuração da fila e as
+// opções de envio, validando-a contra o schema do seu tipo quando há um
+// SchemaRegistry, e acrescenta os atributos de conteúdo e de schema em opts
+func (p *SQSProvider) encodeMessage(queueName string, message interface{}, opts *SendOptions) ([]byte, error) {
+	p.codecs.mu.RLock()
+	config := p.codecs.configs[queueBaseName(queueName)]
+	p.codecs.mu.RUnlock()
+	if opts.ContentType != "" {
+		config.ContentType = opts.ContentType
+	}
+	if opts.ContentEncoding != "" {
+		config.ContentEncoding = opts.ContentEncoding
+	}
+
+	extra, err := p.codecs.schemas.validate(message, opts.MessageType, config.ContentType)
+	if err != nil {
+		return nil, err
+	}
+
+	var body []byte
+	if config == (CodecConfig{}) {
+		if body, err = json.Marshal(message); err != nil {
+			return nil, fmt.Errorf("erro ao serializar mensagem: %w", err)
+		}
+	} else {
+		encoded, contentAttributes, err := p.codecRegistry().Encode(message, config)
+		if err != nil {
+			return nil, err
+		}
+		body = encoded
+		if extra == nil {
+			extra = contentAttributes
+		} else {
+			for k, v := range contentAttributes {
+				extra[k] = v
+			}
+		}
+	}
+
+	if len(extra) > 0 {
+		attributes := make(map[string]MessageAttribute, len(opts.Attributes)+len(extra))
+		for k, v := range opts.Attributes {
+			attributes[k] = v
+		}
+		for k, v := range extra {
+			attributes[k] = v
+		}
+		opts.Attributes = attributes
+	}
+	return body, nil
+}
+
+// DecodeMessage desserializa o corpo da mensagem em v conforme os atributos
+// de conteúdo. Com um SchemaRegistry, mensagens JSON com MessageTypeAttribute
+// de um tipo registrado são validadas e migradas até a versão atual antes da
+// desserialização; as falhas retornam SchemaValidationError ou UpcastError.
+func (p *SQSProvider) DecodeMessage(msg ReceivedMessage, v interface{}) error {
+	schemas := p.codecs.schemas.registry()
+	registry := p.codecRegistry()
+	messageType := msg.Attributes[MessageTypeAttribute].StringValue
+	contentType := msg.Attributes[ContentTypeAttribute].StringValue
+	if schemas == nil || messageType == "" || (contentType != "" && mediaType(contentType) != ContentTypeJSON) {
+		return registry.Decode(msg.Body, msg.Attributes, v)
+	}
+	if _, ok := schemas.CurrentVersion(messageType); !ok {
+		// Tipos sem schema registrado não são validados
+		return registry.Decode(msg.Body, msg.Attributes, v)
+	}
+
+	version, err := messageVersion(messageType, msg.Attributes)
+	if err != nil {
+		return err
+	}
+	var data json.RawMessage
+	if err := registry.Decode(msg.Body, msg.Attributes, &data); err != nil {
+		return err
+	}
+	migrated, _, err := schemas.Upcast(messageType, version, data)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(migrated, v); err != nil {
+		return fmt.Errorf("erro ao desserializar mensagem %s: %w", messageType, err)
+	}
+	return nil
+}
+
+// Receive recebe mensagens e desserializa os corpos em T. Mensagens que não
+// podem ser desserializadas vão para o PoisonHandler do provedor e não fazem
+// parte do resultado; as demais não são afetadas. Para mensagens protobuf, T
+// deve ser o tipo ponteiro gerado (por exemplo *pb.Pedido).
+func Receive[T any](ctx context.Context, p *SQSProvider, queueName string, opts ReceiveOptions) ([]TypedMessage[T], error) {
+	received, err := p.ReceiveMessagesWithOptions(ctx, queueName, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]TypedMessage[T], 0, len(received))
+	for _, msg := range received {
+		value, target := newDecodeTarget[T]()
+		if err := p.DecodeMessage(msg, target); err != nil {
+			p.handlePoison(ctx, queueName, msg, err)
+			continue
+		}
+		messages = append(messages, TypedMessage[T]{ReceivedMessage: msg, Value: *value})
+	}
+	return messages, nil
+}
+
+// newDecodeTarget cria o destino da desserialização. Quando T é um ponteiro,
+// o valor apontado é alocado, para que codecs como o protobuf recebam a
+// mensagem e não um ponteiro nulo.
+func newDecodeTarget[T any]() (*T, interface{}) {
+	value := new(T)
+	if t := reflect.TypeOf(*value); t != nil && t.Kind() == reflect.Ptr {
+		*value = reflect.New(t.Elem()).Interface().(T)
+		return value, *value
+	}
+	return value, value
+}
+
+// handlePoison repassa a mensagem inválida ao PoisonHandler ou registra no log
+func (p *SQSProvider) handlePoison(ctx context.Context, queueName string, msg ReceivedMessage, err error) {
+	p.codecs.mu.RLock()
+	handler := p.codecs.poison
+	p.codecs.mu.RUnlock()
+
+	if handler == nil {
+		log.Printf("Mensagem %s da fila %s não pôde ser desserializada: %v", msg.ID, queueName, err)
+		return
+	}
+	handler(ctx, queueName, msg, err)
+}
+
+// PoisonToQueue cria um PoisonHandler que move a mensagem, com corpo e
+// atributos originais, para a fila informada e a remove da fila de origem.
+// O motivo da falha vai no atributo "poisonReason".
+func (p *SQSProvider) PoisonToQueue(destination string) PoisonHandler {
+	return func(ctx context.Context, queueName string, msg ReceivedMessage, decodeErr error) {
+		attributes := make(map[string]MessageAttribute, len(msg.Attributes)+1)
+		for k, v := range msg.Attributes {
+			attributes[k] = v
+		}
+		attributes["poisonReason"] = StringAttribute(decodeErr.Error())
+
+		opts := SendOptions{Attributes: attributes}
+		if IsFIFOQueue(destination) {
+			opts.MessageGroupID = msg.SystemAttributes[SystemAttributeMessageGroupID]
+			opts.DeduplicationID = msg.ID
+		}
+		if _, err := p.sendBody(ctx, destination, nil, msg.Body, opts); err != nil {
+			log.Printf("Erro ao mover mensagem inválida %s para %s: %v", msg.ID, destination, err)
+			return
+		}
+		if err := p.DeleteMessage(ctx, queueName, msg.ReceiptHandle); err != nil {
+			log.Printf("Erro ao remover mensagem inválida %s da fila %s: %v", msg.ID, queueName, err)
+			return
+		}
+		log.Printf("Mensagem inválida %s movida de %s para %s", msg.ID, queueName, destination)
+	}
+}
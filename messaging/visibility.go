@@ -0,0 +1,161 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// Limites de visibilidade do SQS
+const (
+	// MaxVisibilityTimeout é o tempo máximo que uma mensagem pode ficar
+	// invisível, contado a partir do recebimento (12 horas)
+	MaxVisibilityTimeout = 12 * time.Hour
+
+	// DefaultHeartbeatTimeout é o tempo de visibilidade aplicado a cada extensão
+	DefaultHeartbeatTimeout int32 = 30
+)
+
+// HeartbeatOptions configura a extensão automática do tempo de visibilidade
+type HeartbeatOptions struct {
+	// VisibilityTimeout é o tempo, em segundos, aplicado a cada extensão
+	// (padrão 30)
+	VisibilityTimeout int32
+	// Interval é o intervalo entre extensões (padrão: metade de VisibilityTimeout)
+	Interval time.Duration
+	// MaxExtension limita o tempo total de invisibilidade desde o início do
+	// processamento (padrão e máximo: 12 horas)
+	MaxExtension time.Duration
+	// ReleaseOnFailure torna a mensagem visível novamente quando o handler
+	// falha, em vez de esperar o fim do tempo de visibilidade
+	ReleaseOnFailure bool
+	// ReleaseDelay é o tempo, em segundos, até a mensagem liberada voltar a
+	// ser entregue (0 = imediatamente)
+	ReleaseDelay int32
+}
+
+// withDefaults preenche os valores padrão
+func (o HeartbeatOptions) withDefaults() HeartbeatOptions {
+	if o.VisibilityTimeout <= 0 {
+		o.VisibilityTimeout = DefaultHeartbeatTimeout
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Duration(o.VisibilityTimeout) * time.Second / 2
+	}
+	if o.MaxExtension <= 0 || o.MaxExtension > MaxVisibilityTimeout {
+		o.MaxExtension = MaxVisibilityTimeout
+	}
+	return o
+}
+
+// ChangeVisibility altera o tempo de visibilidade de uma mensagem recebida.
+// Zero torna a mensagem visível imediatamente.
+func (p *SQSProvider) ChangeVisibility(ctx context.Context, queueName string, receiptHandle string, timeoutSeconds int32) error {
+	queueURL, err := p.queues.resolve(ctx, queueName)
+	if err != nil {
+		return err
+	}
+
+	handle, _ := splitReceiptHandle(receiptHandle)
+	_, err = p.client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          &queueURL,
+		ReceiptHandle:     &handle,
+		VisibilityTimeout: timeoutSeconds,
+	})
+	if err != nil {
+		p.checkQueueError(queueName, err)
+		return fmt.Errorf("erro ao alterar visibilidade da mensagem: %w", err)
+	}
+	return nil
+}
+
+// WithHeartbeat executa fn mantendo a mensagem invisível enquanto ela roda,
+// chamando ChangeMessageVisibility periodicamente até o limite de
+// MaxExtension. Se fn falhar e ReleaseOnFailure estiver ativo, a mensagem é
+// liberada com ReleaseDelay. Retorna o erro de fn.
+func (p *SQSProvider) WithHeartbeat(ctx context.Context, queueName string, msg coreinterfaces.Message, opts HeartbeatOptions, fn func(ctx context.Context) error) error {
+	opts = opts.withDefaults()
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		p.heartbeat(ctx, queueName, msg, opts, stop)
+	}()
+
+	err := fn(ctx)
+	close(stop)
+	<-done
+
+	if err != nil && opts.ReleaseOnFailure {
+		// A liberação deve ocorrer mesmo se o contexto foi cancelado
+		releaseCtx := context.WithoutCancel(ctx)
+		if releaseErr := p.ChangeVisibility(releaseCtx, queueName, msg.ReceiptHandle, opts.ReleaseDelay); releaseErr != nil {
+			log.Printf("Erro ao liberar mensagem %s da fila %s: %v", msg.ID, queueName, releaseErr)
+		}
+	}
+
+	return err
+}
+
+// HeartbeatHandler envolve um handler com WithHeartbeat, para uso no
+// consumidor ou com mensagens obtidas por ReceiveMessages
+func (p *SQSProvider) HeartbeatHandler(queueName string, opts HeartbeatOptions, handler MessageHandler) MessageHandler {
+	return func(ctx context.Context, msg coreinterfaces.Message) error {
+		return p.WithHeartbeat(ctx, queueName, msg, opts, func(ctx context.Context) error {
+			return handler(ctx, msg)
+		})
+	}
+}
+
+// heartbeat estende a visibilidade a cada intervalo até stop ser fechado, o
+// limite total ser atingido ou o receipt handle deixar de ser válido
+func (p *SQSProvider) heartbeat(ctx context.Context, queueName string, msg coreinterfaces.Message, opts HeartbeatOptions, stop <-chan struct{}) {
+	start := time.Now()
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// O novo prazo é contado a partir de agora e não pode ultrapassar o
+		// limite total desde o início
+		timeout := opts.VisibilityTimeout
+		remaining := opts.MaxExtension - time.Since(start)
+		if remaining < time.Duration(timeout)*time.Second {
+			timeout = int32(remaining / time.Second)
+		}
+		if timeout <= 0 {
+			log.Printf("Extensão máxima de visibilidade atingida para a mensagem %s da fila %s", msg.ID, queueName)
+			return
+		}
+
+		if err := p.ChangeVisibility(ctx, queueName, msg.ReceiptHandle, timeout); err != nil {
+			if isReceiptHandleGone(err) {
+				log.Printf("Mensagem %s da fila %s não está mais em processamento, heartbeat encerrado: %v", msg.ID, queueName, err)
+				return
+			}
+			log.Printf("Erro no heartbeat da mensagem %s da fila %s: %v", msg.ID, queueName, err)
+		}
+	}
+}
+
+// isReceiptHandleGone informa se o erro indica receipt handle expirado ou
+// mensagem que não está mais em processamento
+func isReceiptHandleGone(err error) bool {
+	var invalid *types.ReceiptHandleIsInvalid
+	var notInflight *types.MessageNotInflight
+	return errors.As(err, &invalid) || errors.As(err, &notInflight)
+}
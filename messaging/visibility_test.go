@@ -0,0 +1,160 @@
+package messaging
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// visibilityRecorder registra os tempos pedidos em ChangeMessageVisibility
+type visibilityRecorder struct {
+	*MemorySQSClient
+	mu       sync.Mutex
+	timeouts []int32
+}
+
+func (c *visibilityRecorder) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	c.mu.Lock()
+	c.timeouts = append(c.timeouts, params.VisibilityTimeout)
+	c.mu.Unlock()
+	return c.MemorySQSClient.ChangeMessageVisibility(ctx, params, optFns...)
+}
+
+// calls retorna uma cópia dos tempos registrados
+func (c *visibilityRecorder) calls() []int32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]int32(nil), c.timeouts...)
+}
+
+// newHeartbeatTest cria um provedor sobre o registrador e recebe uma
+// mensagem da fila "jobs"
+func newHeartbeatTest(t *testing.T) (*SQSProvider, *visibilityRecorder, coreinterfaces.Message) {
+	t.Helper()
+	memory, _ := newTestMemorySQS()
+	queueURL := memory.DefineQueue("jobs", nil)
+	sendTestMessage(t, memory, queueURL, "job", "")
+
+	client := &visibilityRecorder{MemorySQSClient: memory}
+	p := NewSQSProviderWithClient(client, nil)
+	messages, err := p.ReceiveMessages(context.Background(), "jobs", 1)
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("ReceiveMessages: %v (%d mensagens)", err, len(messages))
+	}
+	return p, client, messages[0]
+}
+
+func TestWithHeartbeatExtendsWhileRunning(t *testing.T) {
+	p, client, msg := newHeartbeatTest(t)
+	opts := HeartbeatOptions{VisibilityTimeout: 30, Interval: 10 * time.Millisecond}
+
+	err := p.WithHeartbeat(context.Background(), "jobs", msg, opts, func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithHeartbeat: %v", err)
+	}
+
+	calls := client.calls()
+	if len(calls) < 2 {
+		t.Fatalf("esperadas extensões periódicas, obtidas %v", calls)
+	}
+	for _, timeout := range calls {
+		if timeout != 30 {
+			t.Fatalf("extensão com tempo inesperado: %v", calls)
+		}
+	}
+
+	// Após o retorno o heartbeat não estende mais a mensagem
+	time.Sleep(50 * time.Millisecond)
+	if after := client.calls(); len(after) != len(calls) {
+		t.Fatalf("extensões após o fim do processamento: %v", after)
+	}
+}
+
+func TestWithHeartbeatStopsAtMaxExtension(t *testing.T) {
+	p, client, msg := newHeartbeatTest(t)
+	// Com prazo de 1 segundo, as extensões param quando resta menos que isso
+	opts := HeartbeatOptions{VisibilityTimeout: 1, Interval: 20 * time.Millisecond, MaxExtension: 1100 * time.Millisecond}
+
+	var atLimit []int32
+	err := p.WithHeartbeat(context.Background(), "jobs", msg, opts, func(ctx context.Context) error {
+		time.Sleep(300 * time.Millisecond)
+		atLimit = client.calls()
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithHeartbeat: %v", err)
+	}
+
+	if len(atLimit) == 0 {
+		t.Fatal("nenhuma extensão antes do limite")
+	}
+	if calls := client.calls(); len(calls) != len(atLimit) {
+		t.Fatalf("extensões após MaxExtension: antes %v, depois %v", atLimit, calls)
+	}
+}
+
+func TestWithHeartbeatReleasesOnFailure(t *testing.T) {
+	failure := errors.New("falha simulada")
+	for _, release := range []bool{false, true} {
+		p, client, msg := newHeartbeatTest(t)
+		opts := HeartbeatOptions{Interval: time.Hour, ReleaseOnFailure: release, ReleaseDelay: 5}
+
+		err := p.WithHeartbeat(context.Background(), "jobs", msg, opts, func(ctx context.Context) error {
+			return failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("WithHeartbeat deveria retornar o erro do handler, obtido %v", err)
+		}
+
+		calls := client.calls()
+		if release && (len(calls) != 1 || calls[0] != 5) {
+			t.Fatalf("liberação com ReleaseDelay 5: obtido %v", calls)
+		}
+		if !release && len(calls) != 0 {
+			t.Fatalf("sem ReleaseOnFailure a mensagem não deveria ser liberada: %v", calls)
+		}
+	}
+}
+
+func TestWithHeartbeatStopsWhenMessageIsGone(t *testing.T) {
+	p, client, msg := newHeartbeatTest(t)
+	opts := HeartbeatOptions{VisibilityTimeout: 30, Interval: 10 * time.Millisecond}
+
+	var afterDelete []int32
+	err := p.WithHeartbeat(context.Background(), "jobs", msg, opts, func(ctx context.Context) error {
+		if err := p.DeleteMessage(ctx, "jobs", msg.ReceiptHandle); err != nil {
+			return err
+		}
+		time.Sleep(50 * time.Millisecond)
+		afterDelete = client.calls()
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithHeartbeat: %v", err)
+	}
+
+	// A primeira extensão falha com ReceiptHandleIsInvalid e encerra o heartbeat
+	if len(afterDelete) != 1 {
+		t.Fatalf("esperada uma única tentativa após a remoção, obtidas %v", afterDelete)
+	}
+	if calls := client.calls(); len(calls) != 1 {
+		t.Fatalf("heartbeat continuou após a remoção: %v", calls)
+	}
+}
+
+func TestHeartbeatOptionsDefaults(t *testing.T) {
+	opts := HeartbeatOptions{MaxExtension: 24 * time.Hour}.withDefaults()
+	if opts.VisibilityTimeout != DefaultHeartbeatTimeout || opts.Interval != 15*time.Second || opts.MaxExtension != MaxVisibilityTimeout {
+		t.Fatalf("valores padrão: obtido %+v", opts)
+	}
+}
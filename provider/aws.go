@@ -10,9 +10,10 @@ import (
 
 // Provider implementa a interface coreinterfaces.CloudProvider para AWS
 type Provider struct {
-	config aws.Config
-	region string
-	local  bool
+	config   aws.Config
+	region   string
+	local    bool
+	endpoint string
 }
 
 // NewProvider cria um novo provedor AWS
@@ -54,9 +55,10 @@ func NewProvider() (*Provider, error) {
 	cfg.Region = region
 
 	return &Provider{
-		config: cfg,
-		region: region,
-		local:  local,
+		config:   cfg,
+		region:   region,
+		local:    local,
+		endpoint: endpoint,
 	}, nil
 }
 
@@ -78,4 +80,9 @@ func (p *Provider) GetConfig() interface{} {
 // IsLocal verifica se está em ambiente local
 func (p *Provider) IsLocal() bool {
 	return p.local
+}
+
+// GetEndpoint retorna o endpoint personalizado usado em ambiente local
+func (p *Provider) GetEndpoint() string {
+	return p.endpoint
 }
\ No newline at end of file
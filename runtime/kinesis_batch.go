@@ -0,0 +1,44 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// WrapKinesis adapta um handler genérico para lotes do Kinesis, chamando o
+// handler uma vez por registro de usuário, já desagregado, na ordem do shard.
+// Na primeira falha o processamento para e o número de sequência do registro
+// é devolvido em batchItemFailures, para que a Lambda retome o shard a partir
+// dele; o mapeamento deve ter ReportBatchItemFailures habilitado. Em registros
+// agregados pela KPL a retomada volta ao início do agregado.
+func (r *LambdaRuntime) WrapKinesis(handler coreinterfaces.GenericHandler) func(context.Context, events.KinesisEvent) (events.KinesisEventResponse, error) {
+	return func(ctx context.Context, kinesisEvent events.KinesisEvent) (events.KinesisEventResponse, error) {
+		response := events.KinesisEventResponse{BatchItemFailures: []events.KinesisBatchItemFailure{}}
+		for _, event := range adapter.ConvertKinesisRecords(kinesisEvent) {
+			if err := handleKinesisRecord(ctx, handler, event); err != nil {
+				sequenceNumber, _ := event.Metadata["sequenceNumber"].(string)
+				log.Printf("Erro ao processar registro %s do Kinesis: %v", sequenceNumber, err)
+				response.BatchItemFailures = append(response.BatchItemFailures, events.KinesisBatchItemFailure{ItemIdentifier: sequenceNumber})
+				break
+			}
+		}
+		return response, nil
+	}
+}
+
+// handleKinesisRecord executa o handler, convertendo pânicos em erro
+func handleKinesisRecord(ctx context.Context, handler coreinterfaces.GenericHandler, event coreinterfaces.Event) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("pânico no handler: %v", p)
+		}
+	}()
+
+	_, err = handler.Handle(ctx, event)
+	return err
+}
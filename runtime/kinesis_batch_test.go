@@ -0,0 +1,66 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// kinesisRecord monta um registro simples do Kinesis
+func kinesisRecord(sequence, data string) events.KinesisEventRecord {
+	return events.KinesisEventRecord{
+		EventID:     "shardId-000000000000:" + sequence,
+		EventSource: "aws:kinesis",
+		Kinesis:     events.KinesisRecord{PartitionKey: "k", SequenceNumber: sequence, Data: []byte(data)},
+	}
+}
+
+func TestWrapKinesisStopsAtFirstFailure(t *testing.T) {
+	var handled []string
+	handler := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		handled = append(handled, string(event.Data))
+		if string(event.Data) == "dois" {
+			return nil, errors.New("falha simulada")
+		}
+		return nil, nil
+	})
+
+	r := &LambdaRuntime{}
+	response, err := r.WrapKinesis(handler)(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+		kinesisRecord("1", "um"),
+		kinesisRecord("2", "dois"),
+		kinesisRecord("3", "três"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O shard é retomado a partir do registro com falha; os seguintes não são processados
+	if !reflect.DeepEqual(handled, []string{"um", "dois"}) {
+		t.Fatalf("registros processados: %v", handled)
+	}
+	want := []events.KinesisBatchItemFailure{{ItemIdentifier: "2"}}
+	if !reflect.DeepEqual(response.BatchItemFailures, want) {
+		t.Fatalf("batchItemFailures: obtido %+v", response.BatchItemFailures)
+	}
+}
+
+func TestWrapKinesisRecoversPanic(t *testing.T) {
+	handler := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		panic("pânico simulado")
+	})
+
+	response, err := (&LambdaRuntime{}).WrapKinesis(handler)(context.Background(), events.KinesisEvent{Records: []events.KinesisEventRecord{
+		kinesisRecord("7", "um"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(response.BatchItemFailures) != 1 || response.BatchItemFailures[0].ItemIdentifier != "7" {
+		t.Fatalf("batchItemFailures: obtido %+v", response.BatchItemFailures)
+	}
+}
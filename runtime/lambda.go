@@ -43,12 +43,25 @@ func (r *LambdaRuntime) Start(handler interface{}) error {
 	return nil
 }
 
-// Wrap adapta um handler genérico para o formato AWS Lambda
+// Wrap adapta um handler genérico para o formato AWS Lambda. Lotes SQS
+// chamam o handler uma vez por registro, em sequência, e retornam
+// SQSEventResponse com os registros que falharam em batchItemFailures, para
+// que apenas eles voltem à fila. O mapeamento da fila deve ter
+// ReportBatchItemFailures habilitado: sem ele a Lambda ignora a resposta e
+// remove também os registros que falharam. Para processar registros em
+// paralelo, use WrapSQSWithOptions. Lotes do Kinesis chamam o handler uma
+// única vez, com o primeiro registro em Data e o lote inteiro em
+// Metadata["records"]; para um evento por registro, use WrapKinesis.
 func (r *LambdaRuntime) Wrap(handler coreinterfaces.GenericHandler) interface{} {
 	log.Println("Adaptando handler genérico para AWS Lambda")
 	return func(ctx context.Context, event json.RawMessage) (interface{}, error) {
 		log.Printf("Recebido evento Lambda: %s", string(event))
 		
+		// Lotes SQS são processados por registro, com falhas parciais
+		if sqsEvent, ok := decodeSQSEvent(event); ok {
+			return processSQSBatch(ctx, handler, r.parseSQSRecord, sqsEvent, SQSBatchOptions{Workers: 1}), nil
+		}
+		
 		// Converter evento AWS para evento genérico
 		genericEvent, err := r.ParseEvent(ctx, event)
 		if err != nil {
@@ -68,7 +81,8 @@ func (r *LambdaRuntime) Wrap(handler coreinterfaces.GenericHandler) interface{}
 		// Se a resposta já for do tipo Response, formatá-la
 		if resp, ok := response.(*coreinterfaces.Response); ok {
 			log.Printf("Formatando resposta: StatusCode=%d", resp.StatusCode)
-			return r.FormatResponse(ctx, resp), nil
+			// O formato segue a origem: API Gateway REST ou HTTP API, Function URL ou ALB
+			return adapter.ConvertToHTTPResponse(*genericEvent, resp)
 		}
 		
 		// Caso contrário, retornar a resposta como está
@@ -0,0 +1,208 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/messaging"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// handlerFunc adapta uma função à interface GenericHandler
+type handlerFunc func(ctx context.Context, event coreinterfaces.Event) (interface{}, error)
+
+func (f handlerFunc) Handle(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+	return f(ctx, event)
+}
+
+// sqsTestEvent monta um lote SQS no formato entregue à Lambda
+func sqsTestEvent(t *testing.T, records ...events.SQSMessage) json.RawMessage {
+	t.Helper()
+	for i := range records {
+		records[i].EventSource = "aws:sqs"
+		if records[i].EventSourceARN == "" {
+			records[i].EventSourceARN = "arn:aws:sqs:us-east-1:111111111111:requests"
+		}
+	}
+	raw, err := json.Marshal(events.SQSEvent{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// stringAttribute monta um atributo de mensagem do tipo String
+func stringAttribute(value string) events.SQSMessageAttribute {
+	return events.SQSMessageAttribute{StringValue: &value, DataType: "String"}
+}
+
+// requestRecord monta uma requisição com os atributos de resposta
+func requestRecord(replyURL string) events.SQSMessage {
+	return events.SQSMessage{
+		MessageId: "m-1",
+		Body:      "ping",
+		MessageAttributes: map[string]events.SQSMessageAttribute{
+			messaging.ReplyToAttribute:       stringAttribute(replyURL),
+			messaging.CorrelationIDAttribute: stringAttribute("c-1"),
+		},
+	}
+}
+
+// expectReply verifica a resposta recebida na fila replies
+func expectReply(t *testing.T, sqsProvider *messaging.SQSProvider, body string) {
+	t.Helper()
+	received, err := sqsProvider.ReceiveMessagesWithOptions(context.Background(), "replies", messaging.ReceiveOptions{MaxMessages: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(received) != 1 {
+		t.Fatalf("esperada uma resposta, obtidas %d", len(received))
+	}
+	if got := received[0].Attributes[messaging.CorrelationIDAttribute].StringValue; got != "c-1" {
+		t.Fatalf("correlationId da resposta: obtido %q", got)
+	}
+	if got := string(received[0].Body); got != body {
+		t.Fatalf("corpo da resposta: obtido %s", got)
+	}
+}
+
+func TestParseEventRepliesToSQSRequest(t *testing.T) {
+	client := messaging.NewMemorySQSClient()
+	replyURL := client.DefineQueue("replies", nil)
+	sqsProvider := messaging.NewSQSProviderWithClient(client, nil)
+
+	r := &LambdaRuntime{}
+	event, err := r.ParseEvent(context.Background(), sqsTestEvent(t, requestRecord(replyURL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sqsProvider.ReplyToEvent(context.Background(), *event, "pong", nil); err != nil {
+		t.Fatalf("ReplyToEvent: %v", err)
+	}
+	expectReply(t, sqsProvider, `"pong"`)
+}
+
+func TestWrapRepliesToSQSRequest(t *testing.T) {
+	client := messaging.NewMemorySQSClient()
+	replyURL := client.DefineQueue("replies", nil)
+	sqsProvider := messaging.NewSQSProviderWithClient(client, nil)
+
+	handler := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		return nil, sqsProvider.ReplyToEvent(ctx, event, map[string]string{"echo": string(event.Data)}, nil)
+	})
+
+	r := &LambdaRuntime{}
+	wrapped := r.Wrap(handler).(func(context.Context, json.RawMessage) (interface{}, error))
+	if _, err := wrapped(context.Background(), sqsTestEvent(t, requestRecord(replyURL))); err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	expectReply(t, sqsProvider, `{"echo":"ping"}`)
+}
+
+// failingHandler falha os registros cujo corpo é "fail"
+var failingHandler = handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+	if string(event.Data) == "fail" {
+		return nil, errors.New("falha simulada")
+	}
+	return nil, nil
+})
+
+func TestWrapReportsSQSBatchItemFailures(t *testing.T) {
+	var handled []string
+	handler := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		handled = append(handled, string(event.Data))
+		return failingHandler(ctx, event)
+	})
+
+	r := &LambdaRuntime{}
+	wrapped := r.Wrap(handler).(func(context.Context, json.RawMessage) (interface{}, error))
+	raw := sqsTestEvent(t,
+		events.SQSMessage{MessageId: "m-1", Body: "ok"},
+		events.SQSMessage{MessageId: "m-2", Body: "fail"},
+		events.SQSMessage{MessageId: "m-3", Body: "ok"},
+	)
+	response, err := wrapped(context.Background(), raw)
+	if err != nil {
+		t.Fatalf("Wrap não deveria falhar o lote inteiro: %v", err)
+	}
+
+	// Apenas o registro com falha volta à fila, e os registros são processados em sequência
+	failures := response.(events.SQSEventResponse).BatchItemFailures
+	if len(failures) != 1 || failures[0].ItemIdentifier != "m-2" {
+		t.Fatalf("BatchItemFailures: obtido %v", failures)
+	}
+	if !reflect.DeepEqual(handled, []string{"ok", "fail", "ok"}) {
+		t.Fatalf("registros processados: %v", handled)
+	}
+}
+
+func TestWrapSQSReportsBatchItemFailures(t *testing.T) {
+	r := &LambdaRuntime{}
+	var sqsEvent events.SQSEvent
+	if err := json.Unmarshal(sqsTestEvent(t,
+		events.SQSMessage{MessageId: "m-1", Body: "ok"},
+		events.SQSMessage{MessageId: "m-2", Body: "fail"},
+	), &sqsEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := r.WrapSQS(failingHandler)(context.Background(), sqsEvent)
+	if err != nil {
+		t.Fatalf("WrapSQS: %v", err)
+	}
+	if failures := response.BatchItemFailures; len(failures) != 1 || failures[0].ItemIdentifier != "m-2" {
+		t.Fatalf("BatchItemFailures: obtido %v", failures)
+	}
+}
+
+func TestWrapAndWrapSQSDeliverSameEvent(t *testing.T) {
+	// Os registros podem ser processados em paralelo: os eventos são guardados por messageId
+	var mu sync.Mutex
+	got := make(map[string]coreinterfaces.Event)
+	recorder := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		got[event.Metadata["messageId"].(string)] = event
+		return nil, nil
+	})
+
+	s3Body := `{"Records":[{"eventSource":"aws:s3","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"b"},"object":{"key":"k"}}}]}`
+	raw := sqsTestEvent(t,
+		events.SQSMessage{MessageId: "m-1", Body: "texto", Attributes: map[string]string{"SentTimestamp": "1700000000000"}},
+		events.SQSMessage{MessageId: "m-2", Body: s3Body, Attributes: map[string]string{"SentTimestamp": "1700000000000"}},
+	)
+	var sqsEvent events.SQSEvent
+	if err := json.Unmarshal(raw, &sqsEvent); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &LambdaRuntime{}
+	wrapped := r.Wrap(recorder).(func(context.Context, json.RawMessage) (interface{}, error))
+	if _, err := wrapped(context.Background(), raw); err != nil {
+		t.Fatalf("Wrap: %v", err)
+	}
+	fromWrap := got
+	got = make(map[string]coreinterfaces.Event)
+	if _, err := r.WrapSQS(recorder)(context.Background(), sqsEvent); err != nil {
+		t.Fatalf("WrapSQS: %v", err)
+	}
+
+	if len(fromWrap) != 2 || len(got) != 2 {
+		t.Fatalf("eventos entregues: Wrap=%d, WrapSQS=%d", len(fromWrap), len(got))
+	}
+	if !reflect.DeepEqual(fromWrap["m-1"], got["m-1"]) {
+		t.Fatalf("mensagem comum:\nWrap:    %+v\nWrapSQS: %+v", fromWrap["m-1"], got["m-1"])
+	}
+	if got["m-1"].Source != "queue" || got["m-1"].Type != "sqs.message" {
+		t.Fatalf("mensagem comum: Source=%s, Type=%s", got["m-1"].Source, got["m-1"].Type)
+	}
+	// A notificação S3 recebe um ID gerado a cada conversão; o restante coincide
+	if fromWrap["m-2"].Type != "s3.object.created" || got["m-2"].Type != "s3.object.created" {
+		t.Fatalf("S3 via SQS: Wrap=%s, WrapSQS=%s", fromWrap["m-2"].Type, got["m-2"].Type)
+	}
+}
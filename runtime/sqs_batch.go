@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	"github.com/silviomfa/go-cloud-aws/messaging"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// DefaultSQSBatchWorkers é o número padrão de registros processados ao mesmo tempo
+const DefaultSQSBatchWorkers = 10
+
+// SQSBatchOptions configura o processamento de lotes SQS na Lambda
+type SQSBatchOptions struct {
+	// Workers é o número máximo de registros processados ao mesmo tempo (padrão 10)
+	Workers int
+	// ErrorHandler é chamado quando o handler falha ou entra em pânico em um
+	// registro. Se nil, o erro é registrado no log.
+	ErrorHandler func(record events.SQSMessage, err error)
+}
+
+// sqsRecordConverter converte um registro SQS em evento genérico
+type sqsRecordConverter func(ctx context.Context, record events.SQSMessage) (coreinterfaces.Event, error)
+
+// WrapSQSWithOptions adapta um handler genérico para lotes SQS, chamando o
+// handler uma vez por registro, com o mesmo evento que Wrap entregaria. Os
+// registros que falharem são devolvidos em batchItemFailures, para que apenas
+// eles voltem à fila; o mapeamento da Lambda deve ter ReportBatchItemFailures
+// habilitado. Em filas FIFO os registros de um mesmo grupo são processados em
+// sequência e, após uma falha, os seguintes do grupo são devolvidos sem
+// processamento.
+func (r *LambdaRuntime) WrapSQSWithOptions(handler coreinterfaces.GenericHandler, options SQSBatchOptions) func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+	return func(ctx context.Context, sqsEvent events.SQSEvent) (events.SQSEventResponse, error) {
+		return processSQSBatch(ctx, handler, r.parseSQSRecord, sqsEvent, options), nil
+	}
+}
+
+// parseSQSRecord converte um registro pelo adaptador, reconhecendo
+// notificações S3 e eventos do EventBridge entregues pela fila. Nas demais
+// mensagens Data recebe o corpo do registro.
+func (r *LambdaRuntime) parseSQSRecord(ctx context.Context, record events.SQSMessage) (coreinterfaces.Event, error) {
+	return adapter.ConvertSQSRecord(record), nil
+}
+
+// processSQSBatch executa o handler para cada registro do lote, com no
+// máximo options.Workers ao mesmo tempo, e retorna os registros que falharam
+func processSQSBatch(ctx context.Context, handler coreinterfaces.GenericHandler, convert sqsRecordConverter, sqsEvent events.SQSEvent, options SQSBatchOptions) events.SQSEventResponse {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = DefaultSQSBatchWorkers
+	}
+
+	records := sqsEvent.Records
+	failed := make([]bool, len(records))
+	slots := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for _, job := range sqsJobs(records) {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(job []int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			for i, index := range job {
+				record := records[index]
+				if err := handleSQSRecord(ctx, handler, convert, record); err != nil {
+					reportSQSError(options, record, err)
+					// Em grupos FIFO os registros seguintes também voltam à fila
+					for _, rest := range job[i:] {
+						failed[rest] = true
+					}
+					return
+				}
+			}
+		}(job)
+	}
+	wg.Wait()
+
+	response := events.SQSEventResponse{BatchItemFailures: []events.SQSBatchItemFailure{}}
+	for i, record := range records {
+		if failed[i] {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: record.MessageId})
+		}
+	}
+
+	if len(response.BatchItemFailures) > 0 {
+		log.Printf("Lote SQS: %d de %d registros falharam", len(response.BatchItemFailures), len(records))
+	}
+	return response
+}
+
+// sqsJobs divide o lote em unidades de trabalho: uma por registro em filas
+// padrão e uma por grupo em filas FIFO, mantendo a ordem dentro do grupo
+func sqsJobs(records []events.SQSMessage) [][]int {
+	var jobs [][]int
+	groups := make(map[string]int)
+	for i, record := range records {
+		if !messaging.IsFIFOQueue(record.EventSourceARN) {
+			jobs = append(jobs, []int{i})
+			continue
+		}
+
+		group := record.Attributes["MessageGroupId"]
+		g, ok := groups[group]
+		if !ok {
+			g = len(jobs)
+			groups[group] = g
+			jobs = append(jobs, nil)
+		}
+		jobs[g] = append(jobs[g], i)
+	}
+	return jobs
+}
+
+// handleSQSRecord converte o registro e executa o handler, convertendo pânicos em erro
+func handleSQSRecord(ctx context.Context, handler coreinterfaces.GenericHandler, convert sqsRecordConverter, record events.SQSMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("pânico no handler: %v", p)
+		}
+	}()
+
+	event, err := convert(ctx, record)
+	if err != nil {
+		return fmt.Errorf("erro ao converter registro: %w", err)
+	}
+	_, err = handler.Handle(ctx, event)
+	return err
+}
+
+// reportSQSError repassa a falha ao ErrorHandler ou registra no log
+func reportSQSError(options SQSBatchOptions, record events.SQSMessage, err error) {
+	if options.ErrorHandler != nil {
+		options.ErrorHandler(record, err)
+		return
+	}
+	log.Printf("Erro ao processar mensagem %s de %s: %v", record.MessageId, record.EventSourceARN, err)
+}
+
+// decodeSQSEvent reconhece um lote SQS pelo eventSource dos registros
+func decodeSQSEvent(event json.RawMessage) (events.SQSEvent, bool) {
+	var probe struct {
+		Records []struct {
+			EventSource string `json:"eventSource"`
+		} `json:"Records"`
+	}
+	if err := json.Unmarshal(event, &probe); err != nil || len(probe.Records) == 0 || probe.Records[0].EventSource != "aws:sqs" {
+		return events.SQSEvent{}, false
+	}
+
+	var sqsEvent events.SQSEvent
+	if err := json.Unmarshal(event, &sqsEvent); err != nil {
+		log.Printf("Erro ao decodificar evento SQS: %v", err)
+		return events.SQSEvent{}, false
+	}
+	return sqsEvent, true
+}
@@ -0,0 +1,140 @@
+package runtime
+
+import (
+	"context"
+	"log"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// WrapTyped adapta um handler genérico para um tipo de evento específico da
+// AWS. convert monta o evento genérico a partir do evento recebido e format
+// converte o retorno do handler na resposta esperada pela Lambda. O resultado
+// pode ser passado diretamente para Start.
+func WrapTyped[In, Out any](
+	handler coreinterfaces.GenericHandler,
+	convert func(ctx context.Context, event In) (coreinterfaces.Event, error),
+	format func(ctx context.Context, response interface{}) (Out, error),
+) func(ctx context.Context, event In) (Out, error) {
+	return func(ctx context.Context, event In) (Out, error) {
+		var zero Out
+
+		genericEvent, err := convert(ctx, event)
+		if err != nil {
+			log.Printf("Erro ao converter evento: %v", err)
+			return zero, err
+		}
+		log.Printf("Evento convertido: ID=%s, Type=%s, Source=%s", genericEvent.ID, genericEvent.Type, genericEvent.Source)
+
+		response, err := handler.Handle(ctx, genericEvent)
+		if err != nil {
+			log.Printf("Erro no handler: %v", err)
+			return zero, err
+		}
+
+		return format(ctx, response)
+	}
+}
+
+// WrapAPIGateway adapta um handler genérico para requisições do API Gateway
+// (REST). O retorno do handler é convertido por adapter.ConvertToAPIGatewayResponse.
+func (r *LambdaRuntime) WrapAPIGateway(handler coreinterfaces.GenericHandler) func(context.Context, events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return WrapTyped(handler,
+		func(ctx context.Context, request events.APIGatewayProxyRequest) (coreinterfaces.Event, error) {
+			return adapter.ConvertAPIGatewayEvent(request), nil
+		},
+		func(ctx context.Context, response interface{}) (events.APIGatewayProxyResponse, error) {
+			return adapter.ConvertToAPIGatewayResponse(response)
+		},
+	)
+}
+
+// WrapHTTPAPI adapta um handler genérico para requisições do API Gateway
+// HTTP API (formato 2.0). O retorno do handler é convertido por
+// adapter.ConvertToAPIGatewayV2Response.
+func (r *LambdaRuntime) WrapHTTPAPI(handler coreinterfaces.GenericHandler) func(context.Context, events.APIGatewayV2HTTPRequest) (events.APIGatewayV2HTTPResponse, error) {
+	return WrapTyped(handler,
+		func(ctx context.Context, request events.APIGatewayV2HTTPRequest) (coreinterfaces.Event, error) {
+			return adapter.ConvertAPIGatewayV2Event(request), nil
+		},
+		func(ctx context.Context, response interface{}) (events.APIGatewayV2HTTPResponse, error) {
+			return adapter.ConvertToAPIGatewayV2Response(response)
+		},
+	)
+}
+
+// WrapFunctionURL adapta um handler genérico para requisições de uma Lambda
+// Function URL. O retorno do handler é convertido por
+// adapter.ConvertToFunctionURLResponse.
+func (r *LambdaRuntime) WrapFunctionURL(handler coreinterfaces.GenericHandler) func(context.Context, events.LambdaFunctionURLRequest) (events.LambdaFunctionURLResponse, error) {
+	return WrapTyped(handler,
+		func(ctx context.Context, request events.LambdaFunctionURLRequest) (coreinterfaces.Event, error) {
+			return adapter.ConvertFunctionURLEvent(request), nil
+		},
+		func(ctx context.Context, response interface{}) (events.LambdaFunctionURLResponse, error) {
+			return adapter.ConvertToFunctionURLResponse(response)
+		},
+	)
+}
+
+// WrapALB adapta um handler genérico para requisições de um target group do
+// ALB. A resposta usa multiValueHeaders quando a requisição indica que a
+// opção está habilitada no target group.
+func (r *LambdaRuntime) WrapALB(handler coreinterfaces.GenericHandler) func(context.Context, events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+	return func(ctx context.Context, request events.ALBTargetGroupRequest) (events.ALBTargetGroupResponse, error) {
+		event := adapter.ConvertALBEvent(request)
+		multiValue, _ := event.Metadata["multiValueHeadersEnabled"].(bool)
+		wrapped := WrapTyped(handler,
+			func(ctx context.Context, request events.ALBTargetGroupRequest) (coreinterfaces.Event, error) {
+				return event, nil
+			},
+			func(ctx context.Context, response interface{}) (events.ALBTargetGroupResponse, error) {
+				return adapter.ConvertToALBResponse(response, multiValue)
+			},
+		)
+		return wrapped(ctx, request)
+	}
+}
+
+// WrapSQS adapta um handler genérico para lotes SQS, com um evento por
+// registro e falhas parciais, conforme WrapSQSWithOptions com as opções padrão
+func (r *LambdaRuntime) WrapSQS(handler coreinterfaces.GenericHandler) func(context.Context, events.SQSEvent) (events.SQSEventResponse, error) {
+	return r.WrapSQSWithOptions(handler, SQSBatchOptions{})
+}
+
+// WrapS3 adapta um handler genérico para notificações S3. O retorno do
+// handler é descartado.
+func (r *LambdaRuntime) WrapS3(handler coreinterfaces.GenericHandler) func(context.Context, events.S3Event) error {
+	wrapped := WrapTyped(handler,
+		func(ctx context.Context, s3Event events.S3Event) (coreinterfaces.Event, error) {
+			return adapter.ConvertS3Event(s3Event), nil
+		},
+		discardResponse,
+	)
+	return func(ctx context.Context, s3Event events.S3Event) error {
+		_, err := wrapped(ctx, s3Event)
+		return err
+	}
+}
+
+// WrapEventBridge adapta um handler genérico para eventos do EventBridge. O
+// handler recebe o detail-type em Type e o detail em Data; o retorno é descartado.
+func (r *LambdaRuntime) WrapEventBridge(handler coreinterfaces.GenericHandler) func(context.Context, events.CloudWatchEvent) error {
+	wrapped := WrapTyped(handler,
+		func(ctx context.Context, cwEvent events.CloudWatchEvent) (coreinterfaces.Event, error) {
+			return adapter.ConvertEventBridgeEvent(cwEvent), nil
+		},
+		discardResponse,
+	)
+	return func(ctx context.Context, cwEvent events.CloudWatchEvent) error {
+		_, err := wrapped(ctx, cwEvent)
+		return err
+	}
+}
+
+// discardResponse ignora o retorno de handlers de eventos sem resposta
+func discardResponse(ctx context.Context, response interface{}) (struct{}, error) {
+	return struct{}{}, nil
+}
@@ -0,0 +1,191 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/silviomfa/go-cloud-aws/adapter"
+	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
+)
+
+// recordingHandler guarda o evento recebido e retorna a resposta informada
+func recordingHandler(received *coreinterfaces.Event, response interface{}) coreinterfaces.GenericHandler {
+	return handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		*received = event
+		return response, nil
+	})
+}
+
+func TestWrapTypedPropagatesErrors(t *testing.T) {
+	convertErr := errors.New("evento inválido")
+	handlerErr := errors.New("falha simulada")
+	var formatted bool
+	format := func(ctx context.Context, response interface{}) (string, error) {
+		formatted = true
+		return response.(string), nil
+	}
+
+	failingConvert := WrapTyped(handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		t.Fatal("handler chamado após falha na conversão")
+		return nil, nil
+	}), func(ctx context.Context, in int) (coreinterfaces.Event, error) {
+		return coreinterfaces.Event{}, convertErr
+	}, format)
+	if _, err := failingConvert(context.Background(), 1); !errors.Is(err, convertErr) {
+		t.Fatalf("erro de conversão: obtido %v", err)
+	}
+
+	failingHandler := WrapTyped(handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		return nil, handlerErr
+	}), func(ctx context.Context, in int) (coreinterfaces.Event, error) {
+		return coreinterfaces.Event{}, nil
+	}, format)
+	if _, err := failingHandler(context.Background(), 1); !errors.Is(err, handlerErr) {
+		t.Fatalf("erro do handler: obtido %v", err)
+	}
+	if formatted {
+		t.Fatal("resposta formatada após falha")
+	}
+}
+
+func TestWrapAPIGateway(t *testing.T) {
+	var received coreinterfaces.Event
+	handler := recordingHandler(&received, map[string]string{"ok": "sim"})
+
+	response, err := (&LambdaRuntime{}).WrapAPIGateway(handler)(context.Background(), events.APIGatewayProxyRequest{
+		HTTPMethod:     "POST",
+		Path:           "/pedidos",
+		Body:           `{"n":1}`,
+		RequestContext: events.APIGatewayProxyRequestContext{RequestID: "req-1"},
+	})
+	if err != nil {
+		t.Fatalf("WrapAPIGateway: %v", err)
+	}
+	if received.ID != "req-1" || string(received.Data) != `{"n":1}` || received.Metadata["httpMethod"] != "POST" {
+		t.Fatalf("evento: obtido %+v", received)
+	}
+	if response.StatusCode != 200 || response.Body != `{"ok":"sim"}` || response.Headers["Content-Type"] != "application/json" {
+		t.Fatalf("resposta: obtida %+v", response)
+	}
+}
+
+func TestWrapHTTPAPI(t *testing.T) {
+	var received coreinterfaces.Event
+	handler := recordingHandler(&received, &coreinterfaces.Response{
+		StatusCode: 201,
+		Headers:    map[string]string{"Set-Cookie": "a=1" + adapter.HeaderValueSeparator + "b=2"},
+		Body:       []byte("criado"),
+	})
+
+	request := events.APIGatewayV2HTTPRequest{RawPath: "/pedidos", Body: "x"}
+	request.RequestContext.RequestID = "req-2"
+	request.RequestContext.HTTP.Method = "PUT"
+	response, err := (&LambdaRuntime{}).WrapHTTPAPI(handler)(context.Background(), request)
+	if err != nil {
+		t.Fatalf("WrapHTTPAPI: %v", err)
+	}
+	if received.Metadata["httpFormat"] != adapter.HTTPFormatAPIGatewayV2 || received.Metadata["httpMethod"] != "PUT" {
+		t.Fatalf("evento: obtido %+v", received.Metadata)
+	}
+	if response.StatusCode != 201 || response.Body != "criado" || !reflect.DeepEqual(response.Cookies, []string{"a=1", "b=2"}) {
+		t.Fatalf("resposta: obtida %+v", response)
+	}
+}
+
+func TestWrapFunctionURL(t *testing.T) {
+	var received coreinterfaces.Event
+	handler := recordingHandler(&received, "olá")
+
+	request := events.LambdaFunctionURLRequest{RawPath: "/"}
+	request.RequestContext.DomainName = "abc.lambda-url.us-east-1.on.aws"
+	response, err := (&LambdaRuntime{}).WrapFunctionURL(handler)(context.Background(), request)
+	if err != nil {
+		t.Fatalf("WrapFunctionURL: %v", err)
+	}
+	if received.Metadata["httpFormat"] != adapter.HTTPFormatFunctionURL {
+		t.Fatalf("evento: obtido %+v", received.Metadata)
+	}
+	if response.StatusCode != 200 || response.Body != "olá" || response.Headers["Content-Type"] != "text/plain; charset=utf-8" {
+		t.Fatalf("resposta: obtida %+v", response)
+	}
+}
+
+func TestWrapALBUsesRequestHeaderMode(t *testing.T) {
+	response := &coreinterfaces.Response{
+		StatusCode: 404,
+		Headers:    map[string]string{"X-Valor": "a" + adapter.HeaderValueSeparator + "b"},
+	}
+	var received coreinterfaces.Event
+	wrapped := (&LambdaRuntime{}).WrapALB(recordingHandler(&received, response))
+
+	single, err := wrapped(context.Background(), events.ALBTargetGroupRequest{
+		HTTPMethod: "GET",
+		Headers:    map[string]string{"x-amzn-trace-id": "Root=1-abc"},
+	})
+	if err != nil {
+		t.Fatalf("WrapALB: %v", err)
+	}
+	if received.ID != "Root=1-abc" || single.StatusDescription != "404 Not Found" || single.Headers["X-Valor"] != "a, b" || single.MultiValueHeaders != nil {
+		t.Fatalf("resposta sem cabeçalhos multivalorados: obtida %+v", single)
+	}
+
+	multi, err := wrapped(context.Background(), events.ALBTargetGroupRequest{
+		HTTPMethod:        "GET",
+		MultiValueHeaders: map[string][]string{"accept": {"*/*"}},
+	})
+	if err != nil {
+		t.Fatalf("WrapALB: %v", err)
+	}
+	if !reflect.DeepEqual(multi.MultiValueHeaders["X-Valor"], []string{"a", "b"}) || multi.Headers != nil {
+		t.Fatalf("resposta com cabeçalhos multivalorados: obtida %+v", multi)
+	}
+}
+
+func TestWrapS3(t *testing.T) {
+	var received coreinterfaces.Event
+	handler := recordingHandler(&received, "ignorado")
+
+	var s3Event events.S3Event
+	raw := `{"Records":[{"eventSource":"aws:s3","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"relatorios"},"object":{"key":"2024.csv"}}}]}`
+	if err := json.Unmarshal([]byte(raw), &s3Event); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&LambdaRuntime{}).WrapS3(handler)(context.Background(), s3Event); err != nil {
+		t.Fatalf("WrapS3: %v", err)
+	}
+	if received.Source != "storage" || received.Metadata["bucket"] != "relatorios" || received.Metadata["key"] != "2024.csv" {
+		t.Fatalf("evento: obtido %+v", received)
+	}
+
+	failing := handlerFunc(func(ctx context.Context, event coreinterfaces.Event) (interface{}, error) {
+		return nil, errors.New("falha simulada")
+	})
+	if err := (&LambdaRuntime{}).WrapS3(failing)(context.Background(), s3Event); err == nil {
+		t.Fatal("erro do handler deveria ser retornado")
+	}
+}
+
+func TestWrapEventBridge(t *testing.T) {
+	var received coreinterfaces.Event
+	handler := recordingHandler(&received, nil)
+
+	err := (&LambdaRuntime{}).WrapEventBridge(handler)(context.Background(), events.CloudWatchEvent{
+		ID:         "evt-1",
+		Source:     "pedidos",
+		DetailType: "PedidoCriado",
+		Detail:     json.RawMessage(`{"n":1,"` + adapter.EventBridgeSchemaKey + `":{"messageType":"pedido"}}`),
+	})
+	if err != nil {
+		t.Fatalf("WrapEventBridge: %v", err)
+	}
+	if received.ID != "evt-1" || received.Type != "PedidoCriado" || string(received.Data) != `{"n":1}` {
+		t.Fatalf("evento: obtido %+v (Data=%s)", received, received.Data)
+	}
+	if attributes, _ := received.Metadata["messageAttributes"].(map[string]string); attributes["messageType"] != "pedido" {
+		t.Fatalf("atributos do schema: obtido %v", received.Metadata["messageAttributes"])
+	}
+}
@@ -7,16 +7,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"crypto/sha256"
+	"net/url"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	coreinterfaces "github.com/silviomfa/go-cloud-core/pkg/interfaces"
 	"github.com/silviomfa/go-cloud-aws/provider"
 )
 
+// S3API define as operações do cliente S3 utilizadas pelo provedor.
+// É satisfeita por *s3.Client e permite substituir o cliente por
+// implementações locais em testes e desenvolvimento offline.
+type S3API interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+	HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error)
+	CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error)
+	DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error)
+	ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error)
+	GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+	PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error)
+	DeleteObjectTagging(ctx context.Context, params *s3.DeleteObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectTaggingOutput, error)
+}
+
 // S3Provider implementa a interface coreinterfaces.StorageProvider para S3
 type S3Provider struct {
-	client   *s3.Client
+	client   S3API
 	provider *provider.Provider
 }
 
@@ -40,6 +58,18 @@ func NewS3Provider(cloudProvider coreinterfaces.CloudProvider) (*S3Provider, err
 	}, nil
 }
 
+// NewS3ProviderWithClient cria um provedor S3 a partir de um cliente já configurado
+func NewS3ProviderWithClient(client S3API) *S3Provider {
+	return &S3Provider{
+		client: client,
+	}
+}
+
+// Client retorna o cliente S3 utilizado pelo provedor
+func (p *S3Provider) Client() S3API {
+	return p.client
+}
+
 // GetName retorna o nome do provedor
 func (p *S3Provider) GetName() string {
 	return "AWS-S3"
@@ -86,10 +116,14 @@ func (p *S3Provider) GetItem(ctx context.Context, bucketName string, key map[str
 }
 
 // PutItem insere um objeto no S3
-// Para S3, o item deve ser um mapa com "Key" e "Content"
+// Para S3, o item deve ser um mapa com "Key" e "Content"; opcionalmente
+// "ContentType" (string), "Metadata" e "Tags" (map[string]string)
 func (p *S3Provider) PutItem(ctx context.Context, bucketName string, item interface{}) error {
 	var key string
 	var content []byte
+	var contentType *string
+	var metadata map[string]string
+	var tagging *string
 	
 	// Verificar o tipo do item
 	switch v := item.(type) {
@@ -122,6 +156,23 @@ func (p *S3Provider) PutItem(ctx context.Context, bucketName string, item interf
 				return fmt.Errorf("erro ao serializar conteúdo: %w", err)
 			}
 		}
+		
+		// Extrair tipo de conteúdo e metadados opcionais
+		if ct, ok := v["ContentType"].(string); ok && ct != "" {
+			contentType = aws.String(ct)
+		}
+		switch m := v["Metadata"].(type) {
+		case map[string]string:
+			metadata = m
+		case map[string]interface{}:
+			metadata = make(map[string]string, len(m))
+			for k, val := range m {
+				metadata[k] = fmt.Sprint(val)
+			}
+		}
+		if tags, ok := v["Tags"].(map[string]string); ok && len(tags) > 0 {
+			tagging = aws.String(encodeTagging(tags))
+		}
 	default:
 		// Se não for um mapa, serializar o item inteiro como JSON
 		var err error
@@ -139,8 +190,11 @@ func (p *S3Provider) PutItem(ctx context.Context, bucketName string, item interf
 	// Inserir objeto no S3
 	_, err := p.client.PutObject(ctx, &s3.PutObjectInput{
 		Bucket: aws.String(bucketName),
-		Key:    aws.String(key),
-		Body:   bytes.NewReader(content),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(content),
+		ContentType: contentType,
+		Metadata:    metadata,
+		Tagging:     tagging,
 	})
 	return err
 }
@@ -166,6 +220,72 @@ func (p *S3Provider) DeleteItem(ctx context.Context, bucketName string, key map[
 	return err
 }
 
+// GetObjectTags retorna as tags de um objeto
+func (p *S3Provider) GetObjectTags(ctx context.Context, bucketName string, key string) (map[string]string, error) {
+	output, err := p.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter tags do objeto: %w", err)
+	}
+	
+	tags := make(map[string]string, len(output.TagSet))
+	for _, tag := range output.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// PutObjectTags substitui o conjunto de tags de um objeto
+func (p *S3Provider) PutObjectTags(ctx context.Context, bucketName string, key string, tags map[string]string) error {
+	_, err := p.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucketName),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: toS3Tags(tags)},
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao gravar tags do objeto: %w", err)
+	}
+	return nil
+}
+
+// DeleteObjectTags remove todas as tags de um objeto
+func (p *S3Provider) DeleteObjectTags(ctx context.Context, bucketName string, key string) error {
+	_, err := p.client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao remover tags do objeto: %w", err)
+	}
+	return nil
+}
+
+// toS3Tags converte um mapa de tags para o formato do SDK, ordenado por chave
+func toS3Tags(tags map[string]string) []types.Tag {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	
+	result := make([]types.Tag, len(keys))
+	for i, k := range keys {
+		result[i] = types.Tag{Key: aws.String(k), Value: aws.String(tags[k])}
+	}
+	return result
+}
+
+// encodeTagging codifica tags no formato de query string usado por PutObject
+func encodeTagging(tags map[string]string) string {
+	values := url.Values{}
+	for k, v := range tags {
+		values.Set(k, v)
+	}
+	return values.Encode()
+}
+
 // Query lista objetos no S3 com um prefixo
 func (p *S3Provider) Query(ctx context.Context, bucketName string, keyCondition string, values map[string]interface{}) ([]map[string]interface{}, error) {
 	// Para S3, keyCondition é interpretado como um prefixo
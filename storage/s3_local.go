@@ -0,0 +1,554 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Garantir em tempo de compilação que o cliente implementa as interfaces do S3
+var (
+	_ S3API       = (*LocalS3Client)(nil)
+	_ S3BucketAPI = (*LocalS3Client)(nil)
+)
+
+// localMetaDir é o diretório, dentro de cada bucket, que guarda os metadados dos objetos
+const localMetaDir = ".s3meta"
+
+// localObjectMeta são os metadados persistidos ao lado de cada objeto
+type localObjectMeta struct {
+	ETag        string            `json:"etag"`
+	ContentType string            `json:"contentType,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+// LocalS3Client implementa S3API sobre um diretório local. Cada bucket é um
+// subdiretório da raiz e cada objeto é um arquivo no caminho da sua chave, de
+// modo que o conteúdo pode ser inspecionado com ferramentas comuns. ETag, tipo
+// de conteúdo, metadados e tags ficam em arquivos JSON em ".s3meta". Uma chave
+// não pode ser ao mesmo tempo um objeto e o prefixo de outras chaves ("a" e
+// "a/b"). As configurações de bucket são mantidas apenas em memória.
+type LocalS3Client struct {
+	memoryBucketConfig
+
+	mu   sync.RWMutex
+	root string
+}
+
+// NewLocalS3Client cria um cliente S3 que armazena os objetos no diretório informado
+func NewLocalS3Client(root string) (*LocalS3Client, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("erro ao criar diretório de armazenamento local: %w", err)
+	}
+	return &LocalS3Client{root: root}, nil
+}
+
+// objectPath retorna o caminho do arquivo de um objeto, validando a chave
+func (c *LocalS3Client) objectPath(bucket, key string) (string, error) {
+	if bucket == "" || strings.ContainsAny(bucket, `/\`) || bucket == "." || bucket == ".." {
+		return "", fmt.Errorf("nome de bucket inválido: %q", bucket)
+	}
+
+	name := strings.TrimSuffix(key, "/")
+	if name == "" || !fs.ValidPath(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("chave inválida para armazenamento local: %q", key)
+	}
+	if name == localMetaDir || strings.HasPrefix(name, localMetaDir+"/") {
+		return "", fmt.Errorf("chave reservada para armazenamento local: %q", key)
+	}
+
+	return filepath.Join(c.root, bucket, filepath.FromSlash(name)), nil
+}
+
+// maxMetaName é o tamanho máximo do nome de um arquivo de metadados; chaves
+// mais longas usam o hash no nome
+const maxMetaName = 200
+
+// metaPath retorna o caminho do arquivo de metadados de um objeto. Os arquivos
+// ficam todos em ".s3meta", com a chave escapada no nome (as barras viram
+// "%2F"), de modo que chaves distintas nunca compartilham o arquivo nem
+// impedem a criação umas das outras. Chaves cujo nome escapado é longo demais
+// usam "%sha256-" seguido do hash, forma que o escape nunca produz.
+func (c *LocalS3Client) metaPath(bucket, key string) string {
+	name := url.PathEscape(key)
+	if len(name)+len(".json") > maxMetaName {
+		name = fmt.Sprintf("%%sha256-%x", sha256.Sum256([]byte(key)))
+	}
+	return filepath.Join(c.root, bucket, localMetaDir, name+".json")
+}
+
+// readMeta lê os metadados de um objeto. Arquivos criados fora do cliente não
+// possuem metadados, então o ETag é calculado a partir do conteúdo.
+func (c *LocalS3Client) readMeta(bucket, key, objectPath string) (*localObjectMeta, error) {
+	data, err := os.ReadFile(c.metaPath(bucket, key))
+	if err == nil {
+		var meta localObjectMeta
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return nil, fmt.Errorf("erro ao ler metadados do objeto %s: %w", key, err)
+		}
+		return &meta, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("erro ao ler metadados do objeto %s: %w", key, err)
+	}
+
+	content, err := os.ReadFile(objectPath)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler objeto %s: %w", key, err)
+	}
+	return &localObjectMeta{
+		ETag:        fmt.Sprintf("\"%x\"", md5.Sum(content)),
+		ContentType: "binary/octet-stream",
+	}, nil
+}
+
+// stat retorna as informações de um objeto existente
+func (c *LocalS3Client) stat(bucket, key string) (string, os.FileInfo, *localObjectMeta, error) {
+	p, err := c.objectPath(bucket, key)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	info, err := os.Stat(p)
+	notFound := &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", key))}
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, nil, notFound
+	}
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("erro ao acessar objeto %s: %w", key, err)
+	}
+
+	// Um marcador só existe se o diretório tiver metadados; diretórios
+	// intermediários de outras chaves não são objetos
+	if strings.HasSuffix(key, "/") != info.IsDir() {
+		return "", nil, nil, notFound
+	}
+	if info.IsDir() {
+		if _, err := os.Stat(c.metaPath(bucket, key)); err != nil {
+			return "", nil, nil, notFound
+		}
+	}
+
+	meta, err := c.readMeta(bucket, key, p)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return p, info, meta, nil
+}
+
+// write grava o conteúdo e os metadados de um objeto
+func (c *LocalS3Client) write(bucket, key string, data []byte, meta *localObjectMeta) error {
+	p, err := c.objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasSuffix(key, "/") {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			return fmt.Errorf("erro ao criar marcador de diretório %s: %w", key, err)
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return fmt.Errorf("erro ao criar diretório do objeto %s: %w", key, err)
+		}
+		if err := writeFileAtomic(p, data); err != nil {
+			return fmt.Errorf("erro ao gravar objeto %s: %w", key, err)
+		}
+	}
+
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar metadados do objeto %s: %w", key, err)
+	}
+	mp := c.metaPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(mp), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de metadados: %w", err)
+	}
+	if err := writeFileAtomic(mp, metaData); err != nil {
+		return fmt.Errorf("erro ao gravar metadados do objeto %s: %w", key, err)
+	}
+	return nil
+}
+
+// GetObject retorna o conteúdo de um objeto, respeitando o cabeçalho Range
+func (c *LocalS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bucket, key := aws.ToString(params.Bucket), aws.ToString(params.Key)
+	p, info, meta, err := c.stat(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if !info.IsDir() {
+		if data, err = os.ReadFile(p); err != nil {
+			return nil, fmt.Errorf("erro ao ler objeto %s: %w", key, err)
+		}
+	}
+	if params.Range != nil {
+		start, end, err := parseByteRange(aws.ToString(params.Range), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		data = data[start : end+1]
+	}
+
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(meta.ContentType),
+		ETag:          aws.String(meta.ETag),
+		LastModified:  aws.Time(info.ModTime().UTC().Truncate(time.Second)),
+		Metadata:      copyMetadata(meta.Metadata),
+	}, nil
+}
+
+// PutObject grava um objeto, calculando o ETag como o MD5 do conteúdo
+func (c *LocalS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	var data []byte
+	if params.Body != nil {
+		var err error
+		data, err = io.ReadAll(params.Body)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler conteúdo do objeto: %w", err)
+		}
+	}
+
+	// Marcadores de diretório são gravados como diretórios e não guardam conteúdo
+	if strings.HasSuffix(aws.ToString(params.Key), "/") {
+		data = nil
+	}
+
+	tags, err := parseTagging(params.Tagging)
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &localObjectMeta{
+		ETag:        fmt.Sprintf("\"%x\"", md5.Sum(data)),
+		ContentType: aws.ToString(params.ContentType),
+		Metadata:    copyMetadata(params.Metadata),
+		Tags:        tags,
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = "binary/octet-stream"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.write(aws.ToString(params.Bucket), aws.ToString(params.Key), data, meta); err != nil {
+		return nil, err
+	}
+
+	return &s3.PutObjectOutput{ETag: aws.String(meta.ETag)}, nil
+}
+
+// HeadObject retorna os metadados de um objeto
+func (c *LocalS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key := aws.ToString(params.Key)
+	_, info, meta, err := c.stat(aws.ToString(params.Bucket), key)
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, &types.NotFound{Message: noSuchKey.Message}
+		}
+		return nil, err
+	}
+
+	size := info.Size()
+	if info.IsDir() {
+		size = 0
+	}
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(size),
+		ContentType:   aws.String(meta.ContentType),
+		ETag:          aws.String(meta.ETag),
+		LastModified:  aws.Time(info.ModTime().UTC().Truncate(time.Second)),
+		Metadata:      copyMetadata(meta.Metadata),
+	}, nil
+}
+
+// CopyObject copia um objeto; CopySource deve estar no formato "bucket/chave"
+func (c *LocalS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	source, err := url.PathUnescape(aws.ToString(params.CopySource))
+	if err != nil {
+		return nil, fmt.Errorf("origem de cópia inválida: %w", err)
+	}
+	srcBucket, srcKey, ok := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("origem de cópia inválida: %s", source)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, info, meta, err := c.stat(srcBucket, srcKey)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if !info.IsDir() {
+		if data, err = os.ReadFile(p); err != nil {
+			return nil, fmt.Errorf("erro ao ler objeto %s: %w", srcKey, err)
+		}
+	}
+
+	if params.MetadataDirective == types.MetadataDirectiveReplace {
+		meta.Metadata = copyMetadata(params.Metadata)
+		if params.ContentType != nil {
+			meta.ContentType = aws.ToString(params.ContentType)
+		}
+	}
+
+	bucket, key := aws.ToString(params.Bucket), aws.ToString(params.Key)
+	if err := c.write(bucket, key, data, meta); err != nil {
+		return nil, err
+	}
+	_, dstInfo, _, err := c.stat(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &s3.CopyObjectOutput{
+		CopyObjectResult: &types.CopyObjectResult{
+			ETag:         aws.String(meta.ETag),
+			LastModified: aws.Time(dstInfo.ModTime().UTC().Truncate(time.Second)),
+		},
+	}, nil
+}
+
+// DeleteObject remove um objeto e os diretórios que ficarem vazios;
+// remover um objeto inexistente não é erro
+func (c *LocalS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bucket, key := aws.ToString(params.Bucket), aws.ToString(params.Key)
+	p, info, _, err := c.stat(bucket, key)
+	if err != nil {
+		var noSuchKey *types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return &s3.DeleteObjectOutput{}, nil
+		}
+		return nil, err
+	}
+
+	if err := os.Remove(c.metaPath(bucket, key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("erro ao remover metadados do objeto %s: %w", key, err)
+	}
+	if !info.IsDir() {
+		if err := os.Remove(p); err != nil {
+			return nil, fmt.Errorf("erro ao remover objeto %s: %w", key, err)
+		}
+	}
+
+	dataDir := p
+	if !info.IsDir() {
+		dataDir = filepath.Dir(p)
+	}
+	c.pruneDirs(bucket, filepath.Join(c.root, bucket), dataDir)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// pruneDirs remove diretórios vazios a partir de dir até a base, parando
+// em diretórios que ainda são marcadores
+func (c *LocalS3Client) pruneDirs(bucket, base, dir string) {
+	for dir != base && strings.HasPrefix(dir, base) {
+		rel, err := filepath.Rel(filepath.Join(c.root, bucket), dir)
+		if err == nil {
+			if _, err := os.Stat(c.metaPath(bucket, filepath.ToSlash(rel)+"/")); err == nil {
+				return
+			}
+		}
+		// os.Remove falha em diretórios não vazios, o que encerra a subida
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// GetObjectTagging retorna as tags de um objeto
+func (c *LocalS3Client) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	_, _, meta, err := c.stat(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if err != nil {
+		return nil, err
+	}
+	return &s3.GetObjectTaggingOutput{TagSet: toS3Tags(meta.Tags)}, nil
+}
+
+// PutObjectTagging substitui as tags de um objeto
+func (c *LocalS3Client) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	tags, err := tagsFromInput(params.Tagging)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.updateMeta(aws.ToString(params.Bucket), aws.ToString(params.Key), func(meta *localObjectMeta) {
+		meta.Tags = tags
+	}); err != nil {
+		return nil, err
+	}
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+// DeleteObjectTagging remove as tags de um objeto
+func (c *LocalS3Client) DeleteObjectTagging(ctx context.Context, params *s3.DeleteObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectTaggingOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.updateMeta(aws.ToString(params.Bucket), aws.ToString(params.Key), func(meta *localObjectMeta) {
+		meta.Tags = nil
+	}); err != nil {
+		return nil, err
+	}
+	return &s3.DeleteObjectTaggingOutput{}, nil
+}
+
+// updateMeta altera apenas os metadados de um objeto existente
+func (c *LocalS3Client) updateMeta(bucket, key string, update func(meta *localObjectMeta)) error {
+	_, _, meta, err := c.stat(bucket, key)
+	if err != nil {
+		return err
+	}
+	update(meta)
+
+	metaData, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar metadados do objeto %s: %w", key, err)
+	}
+	mp := c.metaPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(mp), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório de metadados: %w", err)
+	}
+	if err := writeFileAtomic(mp, metaData); err != nil {
+		return fmt.Errorf("erro ao gravar metadados do objeto %s: %w", key, err)
+	}
+	return nil
+}
+
+// ListObjectsV2 lista objetos por prefixo, com suporte a delimitador e paginação
+func (c *LocalS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	bucket := aws.ToString(params.Bucket)
+	bucketDir := filepath.Join(c.root, bucket)
+
+	var keys []string
+	err := filepath.WalkDir(bucketDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if p == bucketDir {
+			return nil
+		}
+
+		rel, err := filepath.Rel(bucketDir, p)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == localMetaDir {
+				return filepath.SkipDir
+			}
+			key += "/"
+			if _, err := os.Stat(c.metaPath(bucket, key)); err != nil {
+				return nil
+			}
+		} else if strings.HasPrefix(path.Base(key), ".s3tmp-") {
+			return nil
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar objetos locais: %w", err)
+	}
+	sort.Strings(keys)
+
+	var describeErr error
+	output := listKeys(params, keys, func(key string) types.Object {
+		_, info, meta, err := c.stat(bucket, key)
+		if err != nil {
+			describeErr = err
+			return types.Object{Key: aws.String(key)}
+		}
+		size := info.Size()
+		if info.IsDir() {
+			size = 0
+		}
+		return types.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(size),
+			ETag:         aws.String(meta.ETag),
+			LastModified: aws.Time(info.ModTime().UTC().Truncate(time.Second)),
+			StorageClass: types.ObjectStorageClassStandard,
+		}
+	})
+	if describeErr != nil {
+		return nil, describeErr
+	}
+
+	return output, nil
+}
+
+// NewLocalS3Provider cria um provedor S3 que armazena os objetos no diretório informado
+func NewLocalS3Provider(root string) (*S3Provider, error) {
+	client, err := NewLocalS3Client(root)
+	if err != nil {
+		return nil, err
+	}
+	return NewS3ProviderWithClient(client), nil
+}
+
+// writeFileAtomic grava um arquivo por meio de um temporário renomeado
+func writeFileAtomic(p string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".s3tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
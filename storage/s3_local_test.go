@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// newTestLocalS3Provider cria um provedor local em um diretório temporário
+func newTestLocalS3Provider(t *testing.T) (*S3Provider, string) {
+	t.Helper()
+	dir := t.TempDir()
+	p, err := NewLocalS3Provider(dir)
+	if err != nil {
+		t.Fatalf("NewLocalS3Provider: %v", err)
+	}
+	return p, dir
+}
+
+func TestLocalS3RoundTrip(t *testing.T) {
+	p, _ := newTestLocalS3Provider(t)
+	testS3BackendRoundTrip(t, p)
+}
+
+func TestLocalS3Pagination(t *testing.T) {
+	p, _ := newTestLocalS3Provider(t)
+	testS3BackendPagination(t, p)
+}
+
+func TestLocalS3MetadataSidecar(t *testing.T) {
+	ctx := context.Background()
+	p, dir := newTestLocalS3Provider(t)
+
+	err := p.PutItem(ctx, "bucket", map[string]interface{}{
+		"Key":         "reports/2024.csv",
+		"Content":     "a,b\n1,2\n",
+		"ContentType": "text/csv",
+		"Metadata":    map[string]interface{}{"rows": 2},
+		"Tags":        map[string]string{"env": "test"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// O conteúdo fica no caminho da chave, legível por ferramentas comuns
+	content, err := os.ReadFile(filepath.Join(dir, "bucket", "reports", "2024.csv"))
+	if err != nil || string(content) != "a,b\n1,2\n" {
+		t.Fatalf("arquivo do objeto: obtido %q, %v", content, err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "bucket", localMetaDir, "reports%2F2024.csv.json"))
+	if err != nil {
+		t.Fatalf("arquivo de metadados ausente: %v", err)
+	}
+	var meta localObjectMeta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if meta.ContentType != "text/csv" {
+		t.Fatalf("ContentType persistido: obtido %q", meta.ContentType)
+	}
+	if !reflect.DeepEqual(meta.Metadata, map[string]string{"rows": "2"}) {
+		t.Fatalf("Metadata persistido: obtido %v", meta.Metadata)
+	}
+	if !reflect.DeepEqual(meta.Tags, map[string]string{"env": "test"}) {
+		t.Fatalf("Tags persistidas: obtido %v", meta.Tags)
+	}
+
+	// Um novo cliente sobre o mesmo diretório lê os metadados do disco
+	reopened, err := NewLocalS3Client(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := reopened.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("reports/2024.csv"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(head.ContentType) != "text/csv" || head.Metadata["rows"] != "2" {
+		t.Fatalf("HeadObject após reabrir: %q %v", aws.ToString(head.ContentType), head.Metadata)
+	}
+	if aws.ToString(head.ETag) != meta.ETag {
+		t.Fatalf("ETag: obtido %s, persistido %s", aws.ToString(head.ETag), meta.ETag)
+	}
+
+	// A listagem não expõe o diretório de metadados
+	results, err := p.Query(ctx, "bucket", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["Key"] != "reports/2024.csv" {
+		t.Fatalf("Query: obtido %v", results)
+	}
+
+	if err := p.DeleteItem(ctx, "bucket", map[string]interface{}{"Key": "reports/2024.csv"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bucket", localMetaDir, "reports%2F2024.csv.json")); !os.IsNotExist(err) {
+		t.Fatalf("metadados não removidos com o objeto: %v", err)
+	}
+}
+
+func TestLocalS3FileWithoutSidecar(t *testing.T) {
+	ctx := context.Background()
+	p, dir := newTestLocalS3Provider(t)
+
+	// Arquivos criados fora do cliente são objetos sem metadados
+	if err := os.MkdirAll(filepath.Join(dir, "bucket"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bucket", "manual.txt"), []byte("manual"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var data []byte
+	if err := p.GetItem(ctx, "bucket", map[string]interface{}{"Key": "manual.txt"}, &data); err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "manual" {
+		t.Fatalf("GetItem: obtido %q", data)
+	}
+
+	head, err := p.Client().HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("manual.txt"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(head.ETag) == "" || aws.ToString(head.ContentType) != "binary/octet-stream" {
+		t.Fatalf("HeadObject sem metadados: ETag=%q ContentType=%q", aws.ToString(head.ETag), aws.ToString(head.ContentType))
+	}
+}
+
+func TestLocalS3RejectsEscapingKeys(t *testing.T) {
+	p, _ := newTestLocalS3Provider(t)
+	for _, key := range []string{"../escape.txt", "a/../../b", localMetaDir + "/x"} {
+		err := p.PutItem(context.Background(), "bucket", map[string]interface{}{"Key": key, "Content": "x"})
+		if err == nil {
+			t.Fatalf("PutItem(%q) deveria falhar", key)
+		}
+	}
+}
+
+func TestLocalS3SidecarsDoNotCollide(t *testing.T) {
+	ctx := context.Background()
+	p, _ := newTestLocalS3Provider(t)
+	client := p.Client()
+
+	put := func(key, contentType string) {
+		t.Helper()
+		_, err := client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String("bucket"),
+			Key:         aws.String(key),
+			Body:        strings.NewReader(""),
+			ContentType: aws.String(contentType),
+		})
+		if err != nil {
+			t.Fatalf("PutObject(%q): %v", key, err)
+		}
+	}
+	head := func(key string) string {
+		t.Helper()
+		output, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String(key)})
+		if err != nil {
+			t.Fatalf("HeadObject(%q): %v", key, err)
+		}
+		return aws.ToString(output.ContentType)
+	}
+
+	// O marcador "foo/" e a chave "foo/.dir" têm metadados próprios; a chave
+	// "a" não impede "a.json/x", nem chaves longas se confundem
+	long := strings.Repeat("x/", 150) + "fim"
+	keys := map[string]string{
+		"foo/":     "application/x-directory",
+		"foo/.dir": "text/plain",
+		"a":        "text/a",
+		"a.json/x": "text/x",
+		long:       "text/long",
+	}
+	for _, key := range []string{"foo/", "foo/.dir", "a", "a.json/x", long} {
+		put(key, keys[key])
+	}
+	for key, contentType := range keys {
+		if got := head(key); got != contentType {
+			t.Fatalf("ContentType de %q: obtido %q, esperado %q", key, got, contentType)
+		}
+	}
+
+	if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String("bucket"), Key: aws.String("foo/.dir")}); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+	if got := head("foo/"); got != keys["foo/"] {
+		t.Fatalf("marcador foo/ após remover foo/.dir: ContentType %q", got)
+	}
+}
@@ -0,0 +1,606 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// Garantir em tempo de compilação que o cliente implementa as interfaces do S3
+var (
+	_ S3API       = (*MemoryS3Client)(nil)
+	_ S3BucketAPI = (*MemoryS3Client)(nil)
+)
+
+// memoryObject representa um objeto armazenado em memória
+type memoryObject struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+	contentType  string
+	metadata     map[string]string
+	tags         map[string]string
+}
+
+// MemoryS3Client implementa S3API e S3BucketAPI mantendo os objetos e as
+// configurações de bucket em memória. Os buckets são criados automaticamente
+// na primeira escrita.
+type MemoryS3Client struct {
+	memoryBucketConfig
+
+	mu      sync.RWMutex
+	buckets map[string]map[string]*memoryObject
+	now     func() time.Time
+}
+
+// NewMemoryS3Client cria um novo cliente S3 em memória
+func NewMemoryS3Client() *MemoryS3Client {
+	return &MemoryS3Client{
+		buckets: make(map[string]map[string]*memoryObject),
+		now:     time.Now,
+	}
+}
+
+// NewMemoryS3Provider cria um provedor S3 que mantém os objetos em memória
+func NewMemoryS3Provider() *S3Provider {
+	return NewS3ProviderWithClient(NewMemoryS3Client())
+}
+
+// object retorna um objeto do bucket ou nil se não existir
+func (c *MemoryS3Client) object(bucket, key string) *memoryObject {
+	objects, ok := c.buckets[bucket]
+	if !ok {
+		return nil
+	}
+	return objects[key]
+}
+
+// GetObject retorna o conteúdo de um objeto, respeitando o cabeçalho Range
+func (c *MemoryS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	c.mu.RLock()
+	obj := c.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	c.mu.RUnlock()
+	if obj == nil {
+		return nil, &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", aws.ToString(params.Key)))}
+	}
+
+	data := obj.data
+	if params.Range != nil {
+		start, end, err := parseByteRange(aws.ToString(params.Range), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		data = data[start : end+1]
+	}
+
+	return &s3.GetObjectOutput{
+		Body:          io.NopCloser(bytes.NewReader(data)),
+		ContentLength: aws.Int64(int64(len(data))),
+		ContentType:   aws.String(obj.contentType),
+		ETag:          aws.String(obj.etag),
+		LastModified:  aws.Time(obj.lastModified),
+		Metadata:      copyMetadata(obj.metadata),
+	}, nil
+}
+
+// PutObject grava um objeto, calculando o ETag como o MD5 do conteúdo
+func (c *MemoryS3Client) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	var data []byte
+	if params.Body != nil {
+		var err error
+		data, err = io.ReadAll(params.Body)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler conteúdo do objeto: %w", err)
+		}
+	}
+
+	tags, err := parseTagging(params.Tagging)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := &memoryObject{
+		data:         data,
+		etag:         fmt.Sprintf("\"%x\"", md5.Sum(data)),
+		lastModified: c.now().UTC().Truncate(time.Second),
+		contentType:  aws.ToString(params.ContentType),
+		metadata:     copyMetadata(params.Metadata),
+		tags:         tags,
+	}
+	if obj.contentType == "" {
+		obj.contentType = "binary/octet-stream"
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	bucket := aws.ToString(params.Bucket)
+	if _, ok := c.buckets[bucket]; !ok {
+		c.buckets[bucket] = make(map[string]*memoryObject)
+	}
+	c.buckets[bucket][aws.ToString(params.Key)] = obj
+
+	return &s3.PutObjectOutput{ETag: aws.String(obj.etag)}, nil
+}
+
+// HeadObject retorna os metadados de um objeto
+func (c *MemoryS3Client) HeadObject(ctx context.Context, params *s3.HeadObjectInput, optFns ...func(*s3.Options)) (*s3.HeadObjectOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	obj := c.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if obj == nil {
+		return nil, &types.NotFound{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", aws.ToString(params.Key)))}
+	}
+
+	return &s3.HeadObjectOutput{
+		ContentLength: aws.Int64(int64(len(obj.data))),
+		ContentType:   aws.String(obj.contentType),
+		ETag:          aws.String(obj.etag),
+		LastModified:  aws.Time(obj.lastModified),
+		Metadata:      copyMetadata(obj.metadata),
+	}, nil
+}
+
+// CopyObject copia um objeto; CopySource deve estar no formato "bucket/chave"
+func (c *MemoryS3Client) CopyObject(ctx context.Context, params *s3.CopyObjectInput, optFns ...func(*s3.Options)) (*s3.CopyObjectOutput, error) {
+	source, err := url.PathUnescape(aws.ToString(params.CopySource))
+	if err != nil {
+		return nil, fmt.Errorf("origem de cópia inválida: %w", err)
+	}
+	srcBucket, srcKey, ok := strings.Cut(strings.TrimPrefix(source, "/"), "/")
+	if !ok {
+		return nil, fmt.Errorf("origem de cópia inválida: %s", source)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	src := c.object(srcBucket, srcKey)
+	if src == nil {
+		return nil, &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", srcKey))}
+	}
+
+	dst := *src
+	dst.lastModified = c.now().UTC().Truncate(time.Second)
+	dst.metadata = copyMetadata(src.metadata)
+	dst.tags = copyTags(src.tags)
+	if params.MetadataDirective == types.MetadataDirectiveReplace {
+		dst.metadata = copyMetadata(params.Metadata)
+		if params.ContentType != nil {
+			dst.contentType = aws.ToString(params.ContentType)
+		}
+	}
+
+	bucket := aws.ToString(params.Bucket)
+	if _, ok := c.buckets[bucket]; !ok {
+		c.buckets[bucket] = make(map[string]*memoryObject)
+	}
+	c.buckets[bucket][aws.ToString(params.Key)] = &dst
+
+	return &s3.CopyObjectOutput{
+		CopyObjectResult: &types.CopyObjectResult{
+			ETag:         aws.String(dst.etag),
+			LastModified: aws.Time(dst.lastModified),
+		},
+	}, nil
+}
+
+// DeleteObject remove um objeto; remover um objeto inexistente não é erro
+func (c *MemoryS3Client) DeleteObject(ctx context.Context, params *s3.DeleteObjectInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if objects, ok := c.buckets[aws.ToString(params.Bucket)]; ok {
+		delete(objects, aws.ToString(params.Key))
+	}
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// GetObjectTagging retorna as tags de um objeto
+func (c *MemoryS3Client) GetObjectTagging(ctx context.Context, params *s3.GetObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	obj := c.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if obj == nil {
+		return nil, &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", aws.ToString(params.Key)))}
+	}
+	return &s3.GetObjectTaggingOutput{TagSet: toS3Tags(obj.tags)}, nil
+}
+
+// PutObjectTagging substitui as tags de um objeto
+func (c *MemoryS3Client) PutObjectTagging(ctx context.Context, params *s3.PutObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.PutObjectTaggingOutput, error) {
+	tags, err := tagsFromInput(params.Tagging)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj := c.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if obj == nil {
+		return nil, &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", aws.ToString(params.Key)))}
+	}
+	obj.tags = tags
+	return &s3.PutObjectTaggingOutput{}, nil
+}
+
+// DeleteObjectTagging remove as tags de um objeto
+func (c *MemoryS3Client) DeleteObjectTagging(ctx context.Context, params *s3.DeleteObjectTaggingInput, optFns ...func(*s3.Options)) (*s3.DeleteObjectTaggingOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	obj := c.object(aws.ToString(params.Bucket), aws.ToString(params.Key))
+	if obj == nil {
+		return nil, &types.NoSuchKey{Message: aws.String(fmt.Sprintf("objeto %s não encontrado", aws.ToString(params.Key)))}
+	}
+	obj.tags = nil
+	return &s3.DeleteObjectTaggingOutput{}, nil
+}
+
+// ListObjectsV2 lista objetos por prefixo, com suporte a delimitador e paginação
+func (c *MemoryS3Client) ListObjectsV2(ctx context.Context, params *s3.ListObjectsV2Input, optFns ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	objects := c.buckets[aws.ToString(params.Bucket)]
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return listKeys(params, keys, func(key string) types.Object {
+		obj := objects[key]
+		return types.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(int64(len(obj.data))),
+			ETag:         aws.String(obj.etag),
+			LastModified: aws.Time(obj.lastModified),
+			StorageClass: types.ObjectStorageClassStandard,
+		}
+	}), nil
+}
+
+// listKeys aplica a semântica de ListObjectsV2 (prefixo, delimitador,
+// StartAfter, ContinuationToken e MaxKeys) sobre chaves já ordenadas
+func listKeys(params *s3.ListObjectsV2Input, keys []string, describe func(key string) types.Object) *s3.ListObjectsV2Output {
+	prefix := aws.ToString(params.Prefix)
+	delimiter := aws.ToString(params.Delimiter)
+	maxKeys := int(aws.ToInt32(params.MaxKeys))
+	if maxKeys <= 0 || maxKeys > 1000 {
+		maxKeys = 1000
+	}
+
+	after := aws.ToString(params.StartAfter)
+	if params.ContinuationToken != nil {
+		after = aws.ToString(params.ContinuationToken)
+	}
+
+	output := &s3.ListObjectsV2Output{
+		Name:        params.Bucket,
+		Prefix:      params.Prefix,
+		Delimiter:   params.Delimiter,
+		MaxKeys:     aws.Int32(int32(maxKeys)),
+		IsTruncated: aws.Bool(false),
+	}
+
+	count := 0
+	last := ""
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) || key <= after {
+			continue
+		}
+
+		// Chaves que contêm o delimitador após o prefixo são agrupadas
+		entry := key
+		isPrefix := false
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				entry = key[:len(prefix)+i+len(delimiter)]
+				isPrefix = true
+			}
+		}
+		// Ao retomar a partir de um prefixo comum, todas as chaves do grupo são puladas
+		if isPrefix && entry <= after {
+			continue
+		}
+		if isPrefix && last == entry {
+			continue
+		}
+
+		if count == maxKeys {
+			output.IsTruncated = aws.Bool(true)
+			output.NextContinuationToken = aws.String(last)
+			break
+		}
+
+		if isPrefix {
+			output.CommonPrefixes = append(output.CommonPrefixes, types.CommonPrefix{Prefix: aws.String(entry)})
+			last = entry
+		} else {
+			output.Contents = append(output.Contents, describe(key))
+			last = key
+		}
+		count++
+	}
+
+	output.KeyCount = aws.Int32(int32(count))
+
+	return output
+}
+
+// parseByteRange interpreta cabeçalhos Range no formato "bytes=inicio-[fim]"
+func parseByteRange(header string, size int64) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok {
+		return 0, 0, fmt.Errorf("range inválido: %s", header)
+	}
+	startStr, endStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("range inválido: %s", header)
+	}
+
+	var start, end int64
+	var err error
+	if startStr == "" {
+		// Sufixo: últimos N bytes
+		n, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("range inválido: %s", header)
+		}
+		start, end = size-n, size-1
+		if start < 0 {
+			start = 0
+		}
+	} else {
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("range inválido: %s", header)
+		}
+		end = size - 1
+		if endStr != "" {
+			if end, err = strconv.ParseInt(endStr, 10, 64); err != nil {
+				return 0, 0, fmt.Errorf("range inválido: %s", header)
+			}
+			if end > size-1 {
+				end = size - 1
+			}
+		}
+	}
+
+	if start >= size || start > end {
+		return 0, 0, fmt.Errorf("range não satisfatório: %s", header)
+	}
+	return start, end, nil
+}
+
+// copyMetadata copia um mapa de metadados, retornando nil para mapas vazios
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		result[strings.ToLower(k)] = v
+	}
+	return result
+}
+
+// parseTagging interpreta o parâmetro Tagging de PutObject ("k1=v1&k2=v2")
+func parseTagging(tagging *string) (map[string]string, error) {
+	if tagging == nil || *tagging == "" {
+		return nil, nil
+	}
+	values, err := url.ParseQuery(*tagging)
+	if err != nil {
+		return nil, fmt.Errorf("tags inválidas: %w", err)
+	}
+	tags := make(map[string]string, len(values))
+	for k, v := range values {
+		tags[k] = v[0]
+	}
+	return tags, nil
+}
+
+// tagsFromInput converte o conjunto de tags do SDK em mapa, validando o limite do S3
+func tagsFromInput(tagging *types.Tagging) (map[string]string, error) {
+	if tagging == nil || len(tagging.TagSet) == 0 {
+		return nil, nil
+	}
+	if len(tagging.TagSet) > 10 {
+		return nil, &smithy.GenericAPIError{Code: "BadRequest", Message: "objetos aceitam no máximo 10 tags"}
+	}
+	tags := make(map[string]string, len(tagging.TagSet))
+	for _, tag := range tagging.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+	return tags, nil
+}
+
+// copyTags copia um mapa de tags
+func copyTags(tags map[string]string) map[string]string {
+	if len(tags) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(tags))
+	for k, v := range tags {
+		result[k] = v
+	}
+	return result
+}
+
+// memoryBucketConfig implementa S3BucketAPI em memória. É compartilhado pelos
+// clientes locais; no LocalS3Client as configurações não são persistidas.
+type memoryBucketConfig struct {
+	configMu   sync.Mutex
+	lifecycle  map[string][]types.LifecycleRule
+	versioning map[string]types.BucketVersioningStatus
+	cors       map[string][]types.CORSRule
+	pab        map[string]*types.PublicAccessBlockConfiguration
+	encryption map[string]*types.ServerSideEncryptionConfiguration
+}
+
+// init inicializa os mapas sob demanda; deve ser chamado com configMu bloqueado
+func (b *memoryBucketConfig) init() {
+	if b.lifecycle == nil {
+		b.lifecycle = make(map[string][]types.LifecycleRule)
+		b.versioning = make(map[string]types.BucketVersioningStatus)
+		b.cors = make(map[string][]types.CORSRule)
+		b.pab = make(map[string]*types.PublicAccessBlockConfiguration)
+		b.encryption = make(map[string]*types.ServerSideEncryptionConfiguration)
+	}
+}
+
+// GetBucketLifecycleConfiguration retorna as regras de ciclo de vida do bucket
+func (b *memoryBucketConfig) GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	rules, ok := b.lifecycle[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: errCodeNoLifecycle, Message: "ciclo de vida não configurado"}
+	}
+	return &s3.GetBucketLifecycleConfigurationOutput{Rules: rules}, nil
+}
+
+// PutBucketLifecycleConfiguration substitui as regras de ciclo de vida do bucket
+func (b *memoryBucketConfig) PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error) {
+	if params.LifecycleConfiguration == nil || len(params.LifecycleConfiguration.Rules) == 0 {
+		return nil, &smithy.GenericAPIError{Code: "MalformedXML", Message: "ciclo de vida sem regras"}
+	}
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	b.lifecycle[aws.ToString(params.Bucket)] = params.LifecycleConfiguration.Rules
+	return &s3.PutBucketLifecycleConfigurationOutput{}, nil
+}
+
+// DeleteBucketLifecycle remove as regras de ciclo de vida do bucket
+func (b *memoryBucketConfig) DeleteBucketLifecycle(ctx context.Context, params *s3.DeleteBucketLifecycleInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketLifecycleOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	delete(b.lifecycle, aws.ToString(params.Bucket))
+	return &s3.DeleteBucketLifecycleOutput{}, nil
+}
+
+// GetBucketVersioning retorna o estado de versionamento do bucket
+func (b *memoryBucketConfig) GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	return &s3.GetBucketVersioningOutput{Status: b.versioning[aws.ToString(params.Bucket)]}, nil
+}
+
+// PutBucketVersioning altera o estado de versionamento do bucket
+func (b *memoryBucketConfig) PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error) {
+	if params.VersioningConfiguration == nil {
+		return nil, &smithy.GenericAPIError{Code: "MalformedXML", Message: "configuração de versionamento ausente"}
+	}
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	b.versioning[aws.ToString(params.Bucket)] = params.VersioningConfiguration.Status
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+// GetBucketCors retorna as regras de CORS do bucket
+func (b *memoryBucketConfig) GetBucketCors(ctx context.Context, params *s3.GetBucketCorsInput, optFns ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	rules, ok := b.cors[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: errCodeNoCORS, Message: "CORS não configurado"}
+	}
+	return &s3.GetBucketCorsOutput{CORSRules: rules}, nil
+}
+
+// PutBucketCors substitui as regras de CORS do bucket
+func (b *memoryBucketConfig) PutBucketCors(ctx context.Context, params *s3.PutBucketCorsInput, optFns ...func(*s3.Options)) (*s3.PutBucketCorsOutput, error) {
+	if params.CORSConfiguration == nil || len(params.CORSConfiguration.CORSRules) == 0 {
+		return nil, &smithy.GenericAPIError{Code: "MalformedXML", Message: "CORS sem regras"}
+	}
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	b.cors[aws.ToString(params.Bucket)] = params.CORSConfiguration.CORSRules
+	return &s3.PutBucketCorsOutput{}, nil
+}
+
+// DeleteBucketCors remove as regras de CORS do bucket
+func (b *memoryBucketConfig) DeleteBucketCors(ctx context.Context, params *s3.DeleteBucketCorsInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketCorsOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	delete(b.cors, aws.ToString(params.Bucket))
+	return &s3.DeleteBucketCorsOutput{}, nil
+}
+
+// GetPublicAccessBlock retorna o bloqueio de acesso público do bucket
+func (b *memoryBucketConfig) GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	pab, ok := b.pab[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: errCodeNoPublicAccessBlock, Message: "bloqueio de acesso público não configurado"}
+	}
+	return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: pab}, nil
+}
+
+// PutPublicAccessBlock substitui o bloqueio de acesso público do bucket
+func (b *memoryBucketConfig) PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	b.pab[aws.ToString(params.Bucket)] = params.PublicAccessBlockConfiguration
+	return &s3.PutPublicAccessBlockOutput{}, nil
+}
+
+// DeletePublicAccessBlock remove o bloqueio de acesso público do bucket
+func (b *memoryBucketConfig) DeletePublicAccessBlock(ctx context.Context, params *s3.DeletePublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.DeletePublicAccessBlockOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	delete(b.pab, aws.ToString(params.Bucket))
+	return &s3.DeletePublicAccessBlockOutput{}, nil
+}
+
+// GetBucketEncryption retorna a criptografia padrão do bucket
+func (b *memoryBucketConfig) GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	enc, ok := b.encryption[aws.ToString(params.Bucket)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: errCodeNoEncryption, Message: "criptografia não configurada"}
+	}
+	return &s3.GetBucketEncryptionOutput{ServerSideEncryptionConfiguration: enc}, nil
+}
+
+// PutBucketEncryption substitui a criptografia padrão do bucket
+func (b *memoryBucketConfig) PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	b.encryption[aws.ToString(params.Bucket)] = params.ServerSideEncryptionConfiguration
+	return &s3.PutBucketEncryptionOutput{}, nil
+}
+
+// DeleteBucketEncryption remove a criptografia padrão do bucket
+func (b *memoryBucketConfig) DeleteBucketEncryption(ctx context.Context, params *s3.DeleteBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketEncryptionOutput, error) {
+	b.configMu.Lock()
+	defer b.configMu.Unlock()
+	b.init()
+	delete(b.encryption, aws.ToString(params.Bucket))
+	return &s3.DeleteBucketEncryptionOutput{}, nil
+}
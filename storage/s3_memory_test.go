@@ -0,0 +1,191 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// testS3BackendRoundTrip verifica o ciclo PutItem/GetItem/DeleteItem de um
+// backend, incluindo tipo de conteúdo e metadados
+func testS3BackendRoundTrip(t *testing.T, p *S3Provider) {
+	ctx := context.Background()
+
+	err := p.PutItem(ctx, "bucket", map[string]interface{}{
+		"Key":         "docs/item.json",
+		"Content":     map[string]interface{}{"name": "alpha", "count": 2},
+		"ContentType": "application/json",
+		"Metadata":    map[string]string{"owner": "team-a"},
+	})
+	if err != nil {
+		t.Fatalf("PutItem: %v", err)
+	}
+
+	var item struct {
+		Name  string `json:"name"`
+		Count int    `json:"count"`
+	}
+	if err := p.GetItem(ctx, "bucket", map[string]interface{}{"Key": "docs/item.json"}, &item); err != nil {
+		t.Fatalf("GetItem: %v", err)
+	}
+	if item.Name != "alpha" || item.Count != 2 {
+		t.Fatalf("GetItem: conteúdo inesperado %+v", item)
+	}
+
+	var raw []byte
+	if err := p.PutItem(ctx, "bucket", map[string]interface{}{"Key": "raw.bin", "Content": []byte{0, 1, 2}}); err != nil {
+		t.Fatalf("PutItem binário: %v", err)
+	}
+	if err := p.GetItem(ctx, "bucket", map[string]interface{}{"Key": "raw.bin"}, &raw); err != nil {
+		t.Fatalf("GetItem binário: %v", err)
+	}
+	if !reflect.DeepEqual(raw, []byte{0, 1, 2}) {
+		t.Fatalf("GetItem binário: obtido %v", raw)
+	}
+
+	head, err := p.Client().HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("docs/item.json"),
+	})
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	if aws.ToString(head.ContentType) != "application/json" {
+		t.Fatalf("ContentType: obtido %q", aws.ToString(head.ContentType))
+	}
+	if !reflect.DeepEqual(head.Metadata, map[string]string{"owner": "team-a"}) {
+		t.Fatalf("Metadata: obtido %v", head.Metadata)
+	}
+
+	if err := p.DeleteItem(ctx, "bucket", map[string]interface{}{"Key": "docs/item.json"}); err != nil {
+		t.Fatalf("DeleteItem: %v", err)
+	}
+	err = p.GetItem(ctx, "bucket", map[string]interface{}{"Key": "docs/item.json"}, &item)
+	var noSuchKey *types.NoSuchKey
+	if !errors.As(err, &noSuchKey) {
+		t.Fatalf("GetItem após DeleteItem: esperado NoSuchKey, obtido %v", err)
+	}
+
+	// Remover um objeto inexistente não é erro, como no S3
+	if err := p.DeleteItem(ctx, "bucket", map[string]interface{}{"Key": "docs/item.json"}); err != nil {
+		t.Fatalf("DeleteItem repetido: %v", err)
+	}
+}
+
+// testS3BackendPagination verifica a paginação de ListObjectsV2 e o Query por prefixo
+func testS3BackendPagination(t *testing.T, p *S3Provider) {
+	ctx := context.Background()
+
+	var want []string
+	for i := 0; i < 7; i++ {
+		key := fmt.Sprintf("logs/%02d.txt", i)
+		want = append(want, key)
+		if err := p.PutItem(ctx, "bucket", map[string]interface{}{"Key": key, "Content": key}); err != nil {
+			t.Fatalf("PutItem(%s): %v", key, err)
+		}
+	}
+	if err := p.PutItem(ctx, "bucket", map[string]interface{}{"Key": "other/x.txt", "Content": "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	paginator := s3.NewListObjectsV2Paginator(p.Client(), &s3.ListObjectsV2Input{
+		Bucket:  aws.String("bucket"),
+		Prefix:  aws.String("logs/"),
+		MaxKeys: aws.Int32(3),
+	})
+	var got []string
+	pages := 0
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			t.Fatalf("NextPage: %v", err)
+		}
+		pages++
+		if len(page.Contents) > 3 {
+			t.Fatalf("página com %d objetos, limite 3", len(page.Contents))
+		}
+		for _, obj := range page.Contents {
+			got = append(got, aws.ToString(obj.Key))
+		}
+	}
+	if pages != 3 {
+		t.Fatalf("esperadas 3 páginas, obtidas %d", pages)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("chaves listadas: obtido %v, esperado %v", got, want)
+	}
+
+	output, err := p.Client().ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String("bucket"),
+		Delimiter: aws.String("/"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prefixes []string
+	for _, cp := range output.CommonPrefixes {
+		prefixes = append(prefixes, aws.ToString(cp.Prefix))
+	}
+	if !reflect.DeepEqual(prefixes, []string{"logs/", "other/"}) {
+		t.Fatalf("CommonPrefixes: obtido %v", prefixes)
+	}
+
+	results, err := p.Query(ctx, "bucket", "other/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0]["Key"] != "other/x.txt" {
+		t.Fatalf("Query: obtido %v", results)
+	}
+}
+
+func TestMemoryS3RoundTrip(t *testing.T) {
+	testS3BackendRoundTrip(t, NewMemoryS3Provider())
+}
+
+func TestMemoryS3Pagination(t *testing.T) {
+	testS3BackendPagination(t, NewMemoryS3Provider())
+}
+
+func TestMemoryS3RangeAndCopy(t *testing.T) {
+	ctx := context.Background()
+	client := NewMemoryS3Client()
+	putTestObject(t, client, "bucket", "src.txt", "0123456789")
+
+	output, err := client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("src.txt"),
+		Range:  aws.String("bytes=2-4"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 8)
+	n, _ := output.Body.Read(data)
+	output.Body.Close()
+	if string(data[:n]) != "234" {
+		t.Fatalf("Range: obtido %q", data[:n])
+	}
+
+	if _, err := client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String("bucket"),
+		Key:        aws.String("dst.txt"),
+		CopySource: aws.String("bucket/src.txt"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	src, _ := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("src.txt")})
+	dst, err := client.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String("bucket"), Key: aws.String("dst.txt")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToString(src.ETag) != aws.ToString(dst.ETag) {
+		t.Fatalf("ETag da cópia difere: %s != %s", aws.ToString(src.ETag), aws.ToString(dst.ETag))
+	}
+}
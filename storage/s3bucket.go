@@ -0,0 +1,513 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+)
+
+// S3BucketAPI define as operações de configuração de bucket utilizadas pelo
+// BucketConfigManager. É satisfeita por *s3.Client e pelos clientes locais.
+type S3BucketAPI interface {
+	GetBucketLifecycleConfiguration(ctx context.Context, params *s3.GetBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.GetBucketLifecycleConfigurationOutput, error)
+	PutBucketLifecycleConfiguration(ctx context.Context, params *s3.PutBucketLifecycleConfigurationInput, optFns ...func(*s3.Options)) (*s3.PutBucketLifecycleConfigurationOutput, error)
+	DeleteBucketLifecycle(ctx context.Context, params *s3.DeleteBucketLifecycleInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketLifecycleOutput, error)
+	GetBucketVersioning(ctx context.Context, params *s3.GetBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.GetBucketVersioningOutput, error)
+	PutBucketVersioning(ctx context.Context, params *s3.PutBucketVersioningInput, optFns ...func(*s3.Options)) (*s3.PutBucketVersioningOutput, error)
+	GetBucketCors(ctx context.Context, params *s3.GetBucketCorsInput, optFns ...func(*s3.Options)) (*s3.GetBucketCorsOutput, error)
+	PutBucketCors(ctx context.Context, params *s3.PutBucketCorsInput, optFns ...func(*s3.Options)) (*s3.PutBucketCorsOutput, error)
+	DeleteBucketCors(ctx context.Context, params *s3.DeleteBucketCorsInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketCorsOutput, error)
+	GetPublicAccessBlock(ctx context.Context, params *s3.GetPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.GetPublicAccessBlockOutput, error)
+	PutPublicAccessBlock(ctx context.Context, params *s3.PutPublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.PutPublicAccessBlockOutput, error)
+	DeletePublicAccessBlock(ctx context.Context, params *s3.DeletePublicAccessBlockInput, optFns ...func(*s3.Options)) (*s3.DeletePublicAccessBlockOutput, error)
+	GetBucketEncryption(ctx context.Context, params *s3.GetBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.GetBucketEncryptionOutput, error)
+	PutBucketEncryption(ctx context.Context, params *s3.PutBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.PutBucketEncryptionOutput, error)
+	DeleteBucketEncryption(ctx context.Context, params *s3.DeleteBucketEncryptionInput, optFns ...func(*s3.Options)) (*s3.DeleteBucketEncryptionOutput, error)
+}
+
+// Garantir em tempo de compilação que o cliente do SDK implementa as interfaces
+var (
+	_ S3API       = (*s3.Client)(nil)
+	_ S3BucketAPI = (*s3.Client)(nil)
+)
+
+// Códigos de erro retornados pelo S3 quando uma configuração não existe
+const (
+	errCodeNoLifecycle         = "NoSuchLifecycleConfiguration"
+	errCodeNoCORS              = "NoSuchCORSConfiguration"
+	errCodeNoPublicAccessBlock = "NoSuchPublicAccessBlockConfiguration"
+	errCodeNoEncryption        = "ServerSideEncryptionConfigurationNotFoundError"
+)
+
+// BucketConfig é a configuração declarativa de um bucket. Campos nil não são
+// gerenciados; um slice vazio (não nil) remove a configuração correspondente,
+// assim como PublicAccessBlock e Encryption com Remove definido.
+type BucketConfig struct {
+	Versioning        *bool
+	Lifecycle         []LifecycleRule
+	CORS              []CORSRule
+	PublicAccessBlock *PublicAccessBlock
+	Encryption        *BucketEncryption
+}
+
+// LifecycleRule é uma regra de ciclo de vida filtrada por prefixo e tags
+type LifecycleRule struct {
+	ID                              string
+	Enabled                         bool
+	Prefix                          string
+	Tags                            map[string]string
+	ExpirationDays                  int32
+	NoncurrentVersionExpirationDays int32
+	AbortIncompleteUploadDays       int32
+	Transitions                     []LifecycleTransition
+}
+
+// LifecycleTransition move objetos para outra classe de armazenamento após N dias
+type LifecycleTransition struct {
+	Days         int32
+	StorageClass string
+}
+
+// CORSRule é uma regra de CORS do bucket
+type CORSRule struct {
+	ID             string
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposeHeaders  []string
+	MaxAgeSeconds  int32
+}
+
+// PublicAccessBlock é o bloqueio de acesso público do bucket
+type PublicAccessBlock struct {
+	BlockPublicAcls       bool
+	IgnorePublicAcls      bool
+	BlockPublicPolicy     bool
+	RestrictPublicBuckets bool
+	// Remove remove o bloqueio do bucket; os demais campos são ignorados
+	Remove bool
+}
+
+// BucketEncryption é a criptografia padrão do bucket ("AES256" ou "aws:kms")
+type BucketEncryption struct {
+	Algorithm        string
+	KMSKeyID         string
+	BucketKeyEnabled bool
+	// Remove remove a criptografia padrão do bucket; os demais campos são ignorados
+	Remove bool
+}
+
+// BucketConfigChange descreve uma diferença entre a configuração desejada e a atual
+type BucketConfigChange struct {
+	Section string // versioning, lifecycle, cors, publicAccessBlock ou encryption
+	Action  string // put ou delete
+	Current interface{}
+	Desired interface{}
+}
+
+// String formata a mudança para exibição
+func (c BucketConfigChange) String() string {
+	return fmt.Sprintf("%s %s: %+v -> %+v", c.Action, c.Section, c.Current, c.Desired)
+}
+
+// BucketConfigManager lê, compara e aplica configurações declarativas de buckets
+type BucketConfigManager struct {
+	client S3BucketAPI
+}
+
+// NewBucketConfigManager cria um gerenciador a partir do cliente do provedor S3
+func NewBucketConfigManager(p *S3Provider) (*BucketConfigManager, error) {
+	client, ok := p.client.(S3BucketAPI)
+	if !ok {
+		return nil, fmt.Errorf("cliente S3 não suporta configuração de bucket")
+	}
+	return &BucketConfigManager{client: client}, nil
+}
+
+// Read lê a configuração atual do bucket. Configurações ausentes são
+// retornadas como slices vazios ou ponteiros nil.
+func (m *BucketConfigManager) Read(ctx context.Context, bucketName string) (*BucketConfig, error) {
+	config := &BucketConfig{
+		Lifecycle: []LifecycleRule{},
+		CORS:      []CORSRule{},
+	}
+
+	versioning, err := m.client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucketName)})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao obter versionamento do bucket: %w", err)
+	}
+	config.Versioning = aws.Bool(versioning.Status == types.BucketVersioningStatusEnabled)
+
+	lifecycle, err := m.client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucketName)})
+	if err != nil && !isAPIErrorCode(err, errCodeNoLifecycle) {
+		return nil, fmt.Errorf("erro ao obter ciclo de vida do bucket: %w", err)
+	}
+	if err == nil {
+		for _, rule := range lifecycle.Rules {
+			config.Lifecycle = append(config.Lifecycle, fromS3LifecycleRule(rule))
+		}
+	}
+
+	cors, err := m.client.GetBucketCors(ctx, &s3.GetBucketCorsInput{Bucket: aws.String(bucketName)})
+	if err != nil && !isAPIErrorCode(err, errCodeNoCORS) {
+		return nil, fmt.Errorf("erro ao obter CORS do bucket: %w", err)
+	}
+	if err == nil {
+		for _, rule := range cors.CORSRules {
+			config.CORS = append(config.CORS, CORSRule{
+				ID:             aws.ToString(rule.ID),
+				AllowedOrigins: rule.AllowedOrigins,
+				AllowedMethods: rule.AllowedMethods,
+				AllowedHeaders: rule.AllowedHeaders,
+				ExposeHeaders:  rule.ExposeHeaders,
+				MaxAgeSeconds:  aws.ToInt32(rule.MaxAgeSeconds),
+			})
+		}
+	}
+
+	pab, err := m.client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucketName)})
+	if err != nil && !isAPIErrorCode(err, errCodeNoPublicAccessBlock) {
+		return nil, fmt.Errorf("erro ao obter bloqueio de acesso público do bucket: %w", err)
+	}
+	if err == nil && pab.PublicAccessBlockConfiguration != nil {
+		c := pab.PublicAccessBlockConfiguration
+		config.PublicAccessBlock = &PublicAccessBlock{
+			BlockPublicAcls:       aws.ToBool(c.BlockPublicAcls),
+			IgnorePublicAcls:      aws.ToBool(c.IgnorePublicAcls),
+			BlockPublicPolicy:     aws.ToBool(c.BlockPublicPolicy),
+			RestrictPublicBuckets: aws.ToBool(c.RestrictPublicBuckets),
+		}
+	}
+
+	encryption, err := m.client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucketName)})
+	if err != nil && !isAPIErrorCode(err, errCodeNoEncryption) {
+		return nil, fmt.Errorf("erro ao obter criptografia do bucket: %w", err)
+	}
+	if err == nil && encryption.ServerSideEncryptionConfiguration != nil {
+		for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+			if rule.ApplyServerSideEncryptionByDefault == nil {
+				continue
+			}
+			config.Encryption = &BucketEncryption{
+				Algorithm:        string(rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm),
+				KMSKeyID:         aws.ToString(rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID),
+				BucketKeyEnabled: aws.ToBool(rule.BucketKeyEnabled),
+			}
+			break
+		}
+	}
+
+	return config, nil
+}
+
+// Diff compara a configuração desejada com a atual do bucket
+func (m *BucketConfigManager) Diff(ctx context.Context, bucketName string, desired *BucketConfig) ([]BucketConfigChange, error) {
+	current, err := m.Read(ctx, bucketName)
+	if err != nil {
+		return nil, err
+	}
+	return diffBucketConfig(current, desired), nil
+}
+
+// Apply aplica as diferenças entre a configuração desejada e a atual,
+// retornando as mudanças executadas
+func (m *BucketConfigManager) Apply(ctx context.Context, bucketName string, desired *BucketConfig) ([]BucketConfigChange, error) {
+	changes, err := m.Diff(ctx, bucketName, desired)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, change := range changes {
+		log.Printf("Aplicando configuração do bucket %s: %s", bucketName, change)
+		if err := m.applyChange(ctx, bucketName, desired, change); err != nil {
+			return changes[:i], fmt.Errorf("erro ao aplicar %s do bucket %s: %w", change.Section, bucketName, err)
+		}
+	}
+
+	return changes, nil
+}
+
+// applyChange executa uma mudança de configuração
+func (m *BucketConfigManager) applyChange(ctx context.Context, bucketName string, desired *BucketConfig, change BucketConfigChange) error {
+	bucket := aws.String(bucketName)
+	var err error
+
+	switch change.Section {
+	case "versioning":
+		status := types.BucketVersioningStatusSuspended
+		if aws.ToBool(desired.Versioning) {
+			status = types.BucketVersioningStatusEnabled
+		}
+		_, err = m.client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+			Bucket:                  bucket,
+			VersioningConfiguration: &types.VersioningConfiguration{Status: status},
+		})
+
+	case "lifecycle":
+		if change.Action == "delete" {
+			_, err = m.client.DeleteBucketLifecycle(ctx, &s3.DeleteBucketLifecycleInput{Bucket: bucket})
+			break
+		}
+		rules := make([]types.LifecycleRule, len(desired.Lifecycle))
+		for i, rule := range desired.Lifecycle {
+			rules[i] = toS3LifecycleRule(rule)
+		}
+		_, err = m.client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+			Bucket:                 bucket,
+			LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+		})
+
+	case "cors":
+		if change.Action == "delete" {
+			_, err = m.client.DeleteBucketCors(ctx, &s3.DeleteBucketCorsInput{Bucket: bucket})
+			break
+		}
+		rules := make([]types.CORSRule, len(desired.CORS))
+		for i, rule := range desired.CORS {
+			rules[i] = types.CORSRule{
+				AllowedOrigins: rule.AllowedOrigins,
+				AllowedMethods: rule.AllowedMethods,
+				AllowedHeaders: rule.AllowedHeaders,
+				ExposeHeaders:  rule.ExposeHeaders,
+			}
+			if rule.ID != "" {
+				rules[i].ID = aws.String(rule.ID)
+			}
+			if rule.MaxAgeSeconds > 0 {
+				rules[i].MaxAgeSeconds = aws.Int32(rule.MaxAgeSeconds)
+			}
+		}
+		_, err = m.client.PutBucketCors(ctx, &s3.PutBucketCorsInput{
+			Bucket:            bucket,
+			CORSConfiguration: &types.CORSConfiguration{CORSRules: rules},
+		})
+
+	case "publicAccessBlock":
+		if change.Action == "delete" {
+			_, err = m.client.DeletePublicAccessBlock(ctx, &s3.DeletePublicAccessBlockInput{Bucket: bucket})
+			break
+		}
+		pab := desired.PublicAccessBlock
+		_, err = m.client.PutPublicAccessBlock(ctx, &s3.PutPublicAccessBlockInput{
+			Bucket: bucket,
+			PublicAccessBlockConfiguration: &types.PublicAccessBlockConfiguration{
+				BlockPublicAcls:       aws.Bool(pab.BlockPublicAcls),
+				IgnorePublicAcls:      aws.Bool(pab.IgnorePublicAcls),
+				BlockPublicPolicy:     aws.Bool(pab.BlockPublicPolicy),
+				RestrictPublicBuckets: aws.Bool(pab.RestrictPublicBuckets),
+			},
+		})
+
+	case "encryption":
+		if change.Action == "delete" {
+			_, err = m.client.DeleteBucketEncryption(ctx, &s3.DeleteBucketEncryptionInput{Bucket: bucket})
+			break
+		}
+		enc := desired.Encryption
+		byDefault := &types.ServerSideEncryptionByDefault{SSEAlgorithm: types.ServerSideEncryption(enc.Algorithm)}
+		if enc.KMSKeyID != "" {
+			byDefault.KMSMasterKeyID = aws.String(enc.KMSKeyID)
+		}
+		_, err = m.client.PutBucketEncryption(ctx, &s3.PutBucketEncryptionInput{
+			Bucket: bucket,
+			ServerSideEncryptionConfiguration: &types.ServerSideEncryptionConfiguration{
+				Rules: []types.ServerSideEncryptionRule{{
+					ApplyServerSideEncryptionByDefault: byDefault,
+					BucketKeyEnabled:                   aws.Bool(enc.BucketKeyEnabled),
+				}},
+			},
+		})
+
+	default:
+		err = fmt.Errorf("seção de configuração desconhecida: %s", change.Section)
+	}
+
+	return err
+}
+
+// diffBucketConfig calcula as mudanças necessárias, ignorando seções não gerenciadas
+func diffBucketConfig(current, desired *BucketConfig) []BucketConfigChange {
+	var changes []BucketConfigChange
+
+	// O versionamento não pode ser desativado depois de ativado, apenas suspenso;
+	// um bucket nunca versionado já equivale a "false"
+	if desired.Versioning != nil && aws.ToBool(desired.Versioning) != aws.ToBool(current.Versioning) {
+		changes = append(changes, BucketConfigChange{Section: "versioning", Action: "put", Current: aws.ToBool(current.Versioning), Desired: aws.ToBool(desired.Versioning)})
+	}
+
+	if desired.Lifecycle != nil {
+		cur, des := normalizeLifecycle(current.Lifecycle), normalizeLifecycle(desired.Lifecycle)
+		if !reflect.DeepEqual(cur, des) {
+			action := "put"
+			if len(des) == 0 {
+				action = "delete"
+			}
+			changes = append(changes, BucketConfigChange{Section: "lifecycle", Action: action, Current: cur, Desired: des})
+		}
+	}
+
+	if desired.CORS != nil {
+		cur, des := normalizeCORS(current.CORS), normalizeCORS(desired.CORS)
+		if !reflect.DeepEqual(cur, des) {
+			action := "put"
+			if len(des) == 0 {
+				action = "delete"
+			}
+			changes = append(changes, BucketConfigChange{Section: "cors", Action: action, Current: cur, Desired: des})
+		}
+	}
+
+	switch {
+	case desired.PublicAccessBlock == nil:
+	case desired.PublicAccessBlock.Remove:
+		if current.PublicAccessBlock != nil {
+			changes = append(changes, BucketConfigChange{Section: "publicAccessBlock", Action: "delete", Current: current.PublicAccessBlock})
+		}
+	case !reflect.DeepEqual(current.PublicAccessBlock, desired.PublicAccessBlock):
+		changes = append(changes, BucketConfigChange{Section: "publicAccessBlock", Action: "put", Current: current.PublicAccessBlock, Desired: desired.PublicAccessBlock})
+	}
+
+	switch {
+	case desired.Encryption == nil:
+	case desired.Encryption.Remove:
+		if current.Encryption != nil {
+			changes = append(changes, BucketConfigChange{Section: "encryption", Action: "delete", Current: current.Encryption})
+		}
+	case !reflect.DeepEqual(current.Encryption, desired.Encryption):
+		changes = append(changes, BucketConfigChange{Section: "encryption", Action: "put", Current: current.Encryption, Desired: desired.Encryption})
+	}
+
+	return changes
+}
+
+// normalizeLifecycle ordena as regras por ID e remove diferenças irrelevantes
+// entre valores nil e vazios, para que a comparação seja estável
+func normalizeLifecycle(rules []LifecycleRule) []LifecycleRule {
+	result := make([]LifecycleRule, len(rules))
+	for i, rule := range rules {
+		if len(rule.Tags) == 0 {
+			rule.Tags = nil
+		}
+		if len(rule.Transitions) == 0 {
+			rule.Transitions = nil
+		}
+		result[i] = rule
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// normalizeCORS ordena as regras por ID e normaliza listas vazias
+func normalizeCORS(rules []CORSRule) []CORSRule {
+	result := make([]CORSRule, len(rules))
+	for i, rule := range rules {
+		for _, list := range []*[]string{&rule.AllowedOrigins, &rule.AllowedMethods, &rule.AllowedHeaders, &rule.ExposeHeaders} {
+			if len(*list) == 0 {
+				*list = nil
+			}
+		}
+		result[i] = rule
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// toS3LifecycleRule converte uma regra declarativa para o formato do SDK
+func toS3LifecycleRule(rule LifecycleRule) types.LifecycleRule {
+	result := types.LifecycleRule{
+		ID:     aws.String(rule.ID),
+		Status: types.ExpirationStatusDisabled,
+	}
+	if rule.Enabled {
+		result.Status = types.ExpirationStatusEnabled
+	}
+
+	switch {
+	case len(rule.Tags) == 0:
+		result.Filter = &types.LifecycleRuleFilterMemberPrefix{Value: rule.Prefix}
+	case len(rule.Tags) == 1 && rule.Prefix == "":
+		tag := toS3Tags(rule.Tags)[0]
+		result.Filter = &types.LifecycleRuleFilterMemberTag{Value: tag}
+	default:
+		and := types.LifecycleRuleAndOperator{Tags: toS3Tags(rule.Tags)}
+		if rule.Prefix != "" {
+			and.Prefix = aws.String(rule.Prefix)
+		}
+		result.Filter = &types.LifecycleRuleFilterMemberAnd{Value: and}
+	}
+
+	if rule.ExpirationDays > 0 {
+		result.Expiration = &types.LifecycleExpiration{Days: aws.Int32(rule.ExpirationDays)}
+	}
+	if rule.NoncurrentVersionExpirationDays > 0 {
+		result.NoncurrentVersionExpiration = &types.NoncurrentVersionExpiration{NoncurrentDays: aws.Int32(rule.NoncurrentVersionExpirationDays)}
+	}
+	if rule.AbortIncompleteUploadDays > 0 {
+		result.AbortIncompleteMultipartUpload = &types.AbortIncompleteMultipartUpload{DaysAfterInitiation: aws.Int32(rule.AbortIncompleteUploadDays)}
+	}
+	for _, t := range rule.Transitions {
+		result.Transitions = append(result.Transitions, types.Transition{
+			Days:         aws.Int32(t.Days),
+			StorageClass: types.TransitionStorageClass(t.StorageClass),
+		})
+	}
+
+	return result
+}
+
+// fromS3LifecycleRule converte uma regra do SDK para o formato declarativo
+func fromS3LifecycleRule(rule types.LifecycleRule) LifecycleRule {
+	result := LifecycleRule{
+		ID:      aws.ToString(rule.ID),
+		Enabled: rule.Status == types.ExpirationStatusEnabled,
+		Prefix:  aws.ToString(rule.Prefix),
+	}
+
+	tags := make(map[string]string)
+	switch f := rule.Filter.(type) {
+	case *types.LifecycleRuleFilterMemberPrefix:
+		result.Prefix = f.Value
+	case *types.LifecycleRuleFilterMemberTag:
+		tags[aws.ToString(f.Value.Key)] = aws.ToString(f.Value.Value)
+	case *types.LifecycleRuleFilterMemberAnd:
+		result.Prefix = aws.ToString(f.Value.Prefix)
+		for _, tag := range f.Value.Tags {
+			tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+		}
+	}
+	if len(tags) > 0 {
+		result.Tags = tags
+	}
+
+	if rule.Expiration != nil {
+		result.ExpirationDays = aws.ToInt32(rule.Expiration.Days)
+	}
+	if rule.NoncurrentVersionExpiration != nil {
+		result.NoncurrentVersionExpirationDays = aws.ToInt32(rule.NoncurrentVersionExpiration.NoncurrentDays)
+	}
+	if rule.AbortIncompleteMultipartUpload != nil {
+		result.AbortIncompleteUploadDays = aws.ToInt32(rule.AbortIncompleteMultipartUpload.DaysAfterInitiation)
+	}
+	for _, t := range rule.Transitions {
+		result.Transitions = append(result.Transitions, LifecycleTransition{
+			Days:         aws.ToInt32(t.Days),
+			StorageClass: string(t.StorageClass),
+		})
+	}
+
+	return result
+}
+
+// isAPIErrorCode informa se o erro é um erro de API do S3 com o código informado
+func isAPIErrorCode(err error, code string) bool {
+	var apiErr smithy.APIError
+	return errors.As(err, &apiErr) && apiErr.ErrorCode() == code
+}
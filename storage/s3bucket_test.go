@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// newTestBucketConfigManager cria um gerenciador sobre o cliente em memória
+func newTestBucketConfigManager(t *testing.T) *BucketConfigManager {
+	t.Helper()
+	m, err := NewBucketConfigManager(NewMemoryS3Provider())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// changeSections resume as mudanças como "ação seção"
+func changeSections(changes []BucketConfigChange) []string {
+	var result []string
+	for _, c := range changes {
+		result = append(result, c.Action+" "+c.Section)
+	}
+	return result
+}
+
+// fullBucketConfig é uma configuração com todas as seções gerenciadas
+func fullBucketConfig() *BucketConfig {
+	return &BucketConfig{
+		Versioning: aws.Bool(true),
+		Lifecycle: []LifecycleRule{
+			{ID: "tmp", Enabled: true, Prefix: "tmp/", ExpirationDays: 7},
+			{ID: "archive", Enabled: true, Tags: map[string]string{"class": "cold"}, Transitions: []LifecycleTransition{{Days: 30, StorageClass: "GLACIER"}}},
+			{ID: "mixed", Prefix: "logs/", Tags: map[string]string{"a": "1", "b": "2"}, NoncurrentVersionExpirationDays: 10, AbortIncompleteUploadDays: 2},
+		},
+		CORS: []CORSRule{
+			{ID: "web", AllowedOrigins: []string{"https://example.com"}, AllowedMethods: []string{"GET", "PUT"}, MaxAgeSeconds: 300},
+		},
+		PublicAccessBlock: &PublicAccessBlock{BlockPublicAcls: true, IgnorePublicAcls: true, BlockPublicPolicy: true, RestrictPublicBuckets: true},
+		Encryption:        &BucketEncryption{Algorithm: "aws:kms", KMSKeyID: "alias/data", BucketKeyEnabled: true},
+	}
+}
+
+func TestBucketConfigApplyIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	m := newTestBucketConfigManager(t)
+
+	changes, err := m.Apply(ctx, "bucket", fullBucketConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"put versioning", "put lifecycle", "put cors", "put publicAccessBlock", "put encryption"}
+	if got := changeSections(changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("mudanças aplicadas: obtido %v, esperado %v", got, want)
+	}
+
+	// A configuração lida do bucket corresponde à desejada, sem novas diferenças
+	changes, err = m.Diff(ctx, "bucket", fullBucketConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Diff após Apply não está vazio: %v", changes)
+	}
+
+	current, err := m.Read(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(current.Encryption, fullBucketConfig().Encryption) {
+		t.Fatalf("criptografia lida: %+v", current.Encryption)
+	}
+}
+
+func TestBucketConfigNilSectionsAreUnmanaged(t *testing.T) {
+	ctx := context.Background()
+	m := newTestBucketConfigManager(t)
+	if _, err := m.Apply(ctx, "bucket", fullBucketConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := m.Diff(ctx, "bucket", &BucketConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("seções nil não deveriam gerar mudanças: %v", changes)
+	}
+}
+
+func TestBucketConfigRemovesSections(t *testing.T) {
+	ctx := context.Background()
+	m := newTestBucketConfigManager(t)
+	if _, err := m.Apply(ctx, "bucket", fullBucketConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	removal := &BucketConfig{
+		Versioning:        aws.Bool(false),
+		Lifecycle:         []LifecycleRule{},
+		CORS:              []CORSRule{},
+		PublicAccessBlock: &PublicAccessBlock{Remove: true},
+		Encryption:        &BucketEncryption{Remove: true},
+	}
+	changes, err := m.Apply(ctx, "bucket", removal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"put versioning", "delete lifecycle", "delete cors", "delete publicAccessBlock", "delete encryption"}
+	if got := changeSections(changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("mudanças aplicadas: obtido %v, esperado %v", got, want)
+	}
+
+	current, err := m.Read(ctx, "bucket")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.ToBool(current.Versioning) || len(current.Lifecycle) != 0 || len(current.CORS) != 0 {
+		t.Fatalf("configuração não removida: %+v", current)
+	}
+	if current.PublicAccessBlock != nil || current.Encryption != nil {
+		t.Fatalf("bloqueio ou criptografia não removidos: %+v %+v", current.PublicAccessBlock, current.Encryption)
+	}
+
+	// Remover o que já não existe não gera mudanças
+	changes, err = m.Diff(ctx, "bucket", removal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("Diff após remoção não está vazio: %v", changes)
+	}
+}
+
+func TestBucketConfigDiffDetectsRuleChanges(t *testing.T) {
+	ctx := context.Background()
+	m := newTestBucketConfigManager(t)
+	if _, err := m.Apply(ctx, "bucket", fullBucketConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A ordem das regras não importa; apenas o conteúdo
+	desired := fullBucketConfig()
+	desired.Lifecycle[0], desired.Lifecycle[2] = desired.Lifecycle[2], desired.Lifecycle[0]
+	changes, err := m.Diff(ctx, "bucket", desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("reordenar regras gerou mudanças: %v", changes)
+	}
+
+	desired.Lifecycle[1].ExpirationDays = 14
+	desired.PublicAccessBlock.BlockPublicPolicy = false
+	changes, err = m.Diff(ctx, "bucket", desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"put lifecycle", "put publicAccessBlock"}
+	if got := changeSections(changes); !reflect.DeepEqual(got, want) {
+		t.Fatalf("mudanças detectadas: obtido %v, esperado %v", got, want)
+	}
+}
@@ -0,0 +1,713 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Garantir em tempo de compilação que S3FS implementa as interfaces de io/fs
+var (
+	_ fs.FS         = (*S3FS)(nil)
+	_ fs.ReadDirFS  = (*S3FS)(nil)
+	_ fs.StatFS     = (*S3FS)(nil)
+	_ fs.GlobFS     = (*S3FS)(nil)
+	_ fs.ReadFileFS = (*S3FS)(nil)
+)
+
+// S3FS expõe um prefixo de um bucket S3 como um sistema de arquivos somente leitura.
+// Os diretórios são derivados das listagens com delimitador "/", portanto não
+// precisam existir como objetos; marcadores de diretório ("chave/") são aceitos.
+type S3FS struct {
+	provider *S3Provider
+	bucket   string
+	prefix   string
+	ctx      context.Context
+}
+
+// NewS3FS cria um sistema de arquivos sobre o prefixo informado do bucket
+func NewS3FS(p *S3Provider, bucketName string, prefix string) *S3FS {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	return &S3FS{
+		provider: p,
+		bucket:   bucketName,
+		prefix:   prefix,
+		ctx:      context.Background(),
+	}
+}
+
+// WithContext retorna uma cópia do sistema de arquivos que usa o contexto
+// informado nas chamadas ao S3
+func (f *S3FS) WithContext(ctx context.Context) *S3FS {
+	clone := *f
+	clone.ctx = ctx
+	return &clone
+}
+
+// key converte um nome do sistema de arquivos em chave do S3
+func (f *S3FS) key(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name
+}
+
+// dirPrefix retorna o prefixo de listagem de um diretório
+func (f *S3FS) dirPrefix(name string) string {
+	if name == "." {
+		return f.prefix
+	}
+	return f.prefix + name + "/"
+}
+
+// Open abre um arquivo ou diretório
+func (f *S3FS) Open(name string) (fs.File, error) {
+	info, err := f.stat("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &s3Dir{fsys: f, name: name, info: info}, nil
+	}
+	return &s3File{fsys: f, key: f.key(name), info: info}, nil
+}
+
+// Stat retorna as informações de um arquivo ou diretório
+func (f *S3FS) Stat(name string) (fs.FileInfo, error) {
+	info, err := f.stat("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// stat resolve um nome verificando primeiro o objeto e depois o prefixo de diretório
+func (f *S3FS) stat(op string, name string) (*s3FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return newS3DirInfo("."), nil
+	}
+
+	head, err := f.provider.client.HeadObject(f.ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(name)),
+	})
+	if err == nil {
+		return &s3FileInfo{
+			name:    path.Base(name),
+			size:    aws.ToInt64(head.ContentLength),
+			modTime: aws.ToTime(head.LastModified),
+			etag:    aws.ToString(head.ETag),
+		}, nil
+	}
+	if !isS3NotFound(err) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	// Não é um objeto: verificar se existe algum objeto sob o prefixo
+	output, err := f.provider.client.ListObjectsV2(f.ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(f.bucket),
+		Prefix:  aws.String(f.dirPrefix(name)),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	if len(output.Contents) == 0 && len(output.CommonPrefixes) == 0 {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return newS3DirInfo(path.Base(name)), nil
+}
+
+// ReadFile lê o conteúdo completo de um arquivo
+func (f *S3FS) ReadFile(name string) ([]byte, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("é um diretório")}
+	}
+
+	output, err := f.provider.client.GetObject(f.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.key(name)),
+	})
+	if err != nil {
+		if isS3NotFound(err) {
+			if info, statErr := f.stat("readfile", name); statErr == nil && info.IsDir() {
+				return nil, &fs.PathError{Op: "readfile", Path: name, Err: errors.New("é um diretório")}
+			}
+			return nil, &fs.PathError{Op: "readfile", Path: name, Err: fs.ErrNotExist}
+		}
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	defer output.Body.Close()
+
+	return io.ReadAll(output.Body)
+}
+
+// ReadDir lista as entradas de um diretório ordenadas por nome
+func (f *S3FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	entries, err := f.readDir(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 && name != "." {
+		// Um diretório vazio só existe se houver marcador; caso contrário o nome pode ser um arquivo
+		info, err := f.stat("readdir", name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("não é um diretório")}
+		}
+	}
+
+	return entries, nil
+}
+
+// readDir executa a listagem com delimitador de um diretório
+func (f *S3FS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := f.dirPrefix(name)
+	paginator := s3.NewListObjectsV2Paginator(f.provider.client, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(f.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+
+	var entries []fs.DirEntry
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(f.ctx)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+
+		for _, cp := range page.CommonPrefixes {
+			dirName := strings.TrimSuffix(strings.TrimPrefix(aws.ToString(cp.Prefix), prefix), "/")
+			if dirName == "" {
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(newS3DirInfo(dirName)))
+		}
+
+		for _, obj := range page.Contents {
+			fileName := strings.TrimPrefix(aws.ToString(obj.Key), prefix)
+			// Ignorar o próprio marcador de diretório
+			if fileName == "" {
+				continue
+			}
+			entries = append(entries, fs.FileInfoToDirEntry(&s3FileInfo{
+				name:    fileName,
+				size:    aws.ToInt64(obj.Size),
+				modTime: aws.ToTime(obj.LastModified),
+				etag:    aws.ToString(obj.ETag),
+			}))
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	return entries, nil
+}
+
+// Glob retorna os nomes que correspondem ao padrão, com a mesma semântica de fs.Glob.
+// Apenas os diretórios que correspondem aos segmentos do padrão são listados.
+func (f *S3FS) Glob(pattern string) ([]string, error) {
+	// Validar o padrão mesmo que nenhum diretório seja listado
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	if !hasGlobMeta(pattern) {
+		if _, err := f.Stat(pattern); err != nil {
+			return nil, nil
+		}
+		return []string{pattern}, nil
+	}
+
+	dir, file := path.Split(pattern)
+	dir = strings.TrimSuffix(dir, "/")
+	if dir == "" {
+		dir = "."
+	}
+
+	if !hasGlobMeta(dir) {
+		return f.globDir(dir, file, nil)
+	}
+
+	dirs, err := f.Glob(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, d := range dirs {
+		matches, err = f.globDir(d, file, matches)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
+}
+
+// globDir acrescenta a matches as entradas de dir que correspondem ao padrão
+func (f *S3FS) globDir(dir, pattern string, matches []string) ([]string, error) {
+	entries, err := f.readDir(dir)
+	if err != nil {
+		return matches, nil
+	}
+
+	for _, entry := range entries {
+		matched, err := path.Match(pattern, entry.Name())
+		if err != nil {
+			return matches, err
+		}
+		if matched {
+			matches = append(matches, path.Join(dir, entry.Name()))
+		}
+	}
+	return matches, nil
+}
+
+// hasGlobMeta informa se o caminho contém caracteres especiais de padrão
+func hasGlobMeta(p string) bool {
+	return strings.ContainsAny(p, `*?[\`)
+}
+
+// isS3NotFound informa se o erro indica objeto inexistente
+func isS3NotFound(err error) bool {
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	return errors.As(err, &noSuchKey) || errors.As(err, &notFound)
+}
+
+// s3FileInfo implementa fs.FileInfo para objetos e diretórios do S3
+type s3FileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	etag    string
+	isDir   bool
+}
+
+// newS3DirInfo cria as informações de um diretório derivado de prefixo
+func newS3DirInfo(name string) *s3FileInfo {
+	return &s3FileInfo{name: name, isDir: true}
+}
+
+func (i *s3FileInfo) Name() string       { return i.name }
+func (i *s3FileInfo) Size() int64        { return i.size }
+func (i *s3FileInfo) ModTime() time.Time { return i.modTime }
+func (i *s3FileInfo) IsDir() bool        { return i.isDir }
+
+// Sys retorna o ETag do objeto, ou nil para diretórios
+func (i *s3FileInfo) Sys() interface{} {
+	if i.isDir {
+		return nil
+	}
+	return i.etag
+}
+
+// Mode retorna permissões somente leitura
+func (i *s3FileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// s3File implementa fs.File sobre um objeto do S3. A leitura é feita em
+// streaming; Seek e ReadAt usam requisições com cabeçalho Range.
+type s3File struct {
+	fsys   *S3FS
+	key    string
+	info   *s3FileInfo
+	body   io.ReadCloser
+	offset int64
+	closed bool
+}
+
+// Stat retorna as informações do arquivo
+func (f *s3File) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Read lê a partir da posição atual, abrindo o corpo do objeto sob demanda
+func (f *s3File) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrClosed}
+	}
+	if f.offset >= f.info.size {
+		return 0, io.EOF
+	}
+
+	if f.body == nil {
+		input := &s3.GetObjectInput{
+			Bucket: aws.String(f.fsys.bucket),
+			Key:    aws.String(f.key),
+		}
+		if f.offset > 0 {
+			input.Range = aws.String(fmt.Sprintf("bytes=%d-", f.offset))
+		}
+
+		output, err := f.fsys.provider.client.GetObject(f.fsys.ctx, input)
+		if err != nil {
+			return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: err}
+		}
+		f.body = output.Body
+	}
+
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+// Seek altera a posição de leitura; o corpo atual é descartado
+func (f *s3File) Seek(offset int64, whence int) (int64, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrClosed}
+	}
+
+	var next int64
+	switch whence {
+	case io.SeekStart:
+		next = offset
+	case io.SeekCurrent:
+		next = f.offset + offset
+	case io.SeekEnd:
+		next = f.info.size + offset
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if next < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+
+	if next != f.offset && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.offset = next
+	return next, nil
+}
+
+// ReadAt lê len(p) bytes a partir de off com uma requisição Range
+func (f *s3File) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrClosed}
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: fs.ErrInvalid}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if off >= f.info.size {
+		return 0, io.EOF
+	}
+
+	output, err := f.fsys.provider.client.GetObject(f.fsys.ctx, &s3.GetObjectInput{
+		Bucket: aws.String(f.fsys.bucket),
+		Key:    aws.String(f.key),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", off, off+int64(len(p))-1)),
+	})
+	if err != nil {
+		return 0, &fs.PathError{Op: "read", Path: f.info.name, Err: err}
+	}
+	defer output.Body.Close()
+
+	n, err := io.ReadFull(output.Body, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Close libera o corpo do objeto, se aberto
+func (f *s3File) Close() error {
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.info.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+// s3Dir implementa fs.ReadDirFile para diretórios derivados de prefixo
+type s3Dir struct {
+	fsys    *S3FS
+	name    string
+	info    fs.FileInfo
+	entries []fs.DirEntry
+	loaded  bool
+	offset  int
+}
+
+// Stat retorna as informações do diretório
+func (d *s3Dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+// Read não é suportado em diretórios
+func (d *s3Dir) Read(p []byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: errors.New("é um diretório")}
+}
+
+// Close fecha o diretório
+func (d *s3Dir) Close() error {
+	return nil
+}
+
+// ReadDir retorna até n entradas do diretório, seguindo a semântica de fs.ReadDirFile
+func (d *s3Dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if !d.loaded {
+		entries, err := d.fsys.readDir(d.name)
+		if err != nil {
+			return nil, err
+		}
+		d.entries = entries
+		d.loaded = true
+	}
+
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	d.offset += n
+	return remaining[:n], nil
+}
+
+// WritableS3FS estende S3FS com operações de escrita
+type WritableS3FS struct {
+	*S3FS
+}
+
+// NewWritableS3FS cria um sistema de arquivos gravável sobre o prefixo informado
+func NewWritableS3FS(p *S3Provider, bucketName string, prefix string) *WritableS3FS {
+	return &WritableS3FS{S3FS: NewS3FS(p, bucketName, prefix)}
+}
+
+// WithContext retorna uma cópia do sistema de arquivos que usa o contexto informado
+func (f *WritableS3FS) WithContext(ctx context.Context) *WritableS3FS {
+	return &WritableS3FS{S3FS: f.S3FS.WithContext(ctx)}
+}
+
+// Create cria ou substitui um arquivo. O conteúdo é enviado ao S3 no Close.
+func (f *WritableS3FS) Create(name string) (*S3FileWriter, error) {
+	if !fs.ValidPath(name) || name == "." {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrInvalid}
+	}
+	return &S3FileWriter{fsys: f, name: name}, nil
+}
+
+// WriteFile grava o conteúdo completo de um arquivo
+func (f *WritableS3FS) WriteFile(name string, data []byte) error {
+	w, err := f.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// Mkdir cria um marcador de diretório ("nome/") para permitir diretórios vazios
+func (f *WritableS3FS) Mkdir(name string) error {
+	if !fs.ValidPath(name) || name == "." {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, err := f.Stat(name); err == nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: fs.ErrExist}
+	}
+
+	_, err := f.provider.client.PutObject(f.ctx, &s3.PutObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(f.dirPrefix(name)),
+		Body:   bytes.NewReader(nil),
+	})
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Remove remove um arquivo ou um diretório vazio
+func (f *WritableS3FS) Remove(name string) error {
+	info, err := f.stat("remove", name)
+	if err != nil {
+		return err
+	}
+	if name == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrInvalid}
+	}
+
+	key := f.key(name)
+	if info.IsDir() {
+		entries, err := f.readDir(name)
+		if err != nil {
+			return err
+		}
+		if len(entries) > 0 {
+			return &fs.PathError{Op: "remove", Path: name, Err: errors.New("diretório não está vazio")}
+		}
+		key = f.dirPrefix(name)
+	}
+
+	_, err = f.provider.client.DeleteObject(f.ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return nil
+}
+
+// Rename renomeia um arquivo ou diretório. Como o S3 não possui renomeação
+// atômica, cada objeto é copiado e depois removido da origem.
+func (f *WritableS3FS) Rename(oldName, newName string) error {
+	if !fs.ValidPath(newName) || newName == "." {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrInvalid}
+	}
+	info, err := f.stat("rename", oldName)
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+	if oldName == "." {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrInvalid}
+	}
+
+	if !info.IsDir() {
+		if err := f.moveObject(f.key(oldName), f.key(newName)); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+		}
+		return nil
+	}
+
+	// Diretório: mover todos os objetos sob o prefixo, inclusive o marcador
+	oldPrefix := f.dirPrefix(oldName)
+	newPrefix := f.dirPrefix(newName)
+	paginator := s3.NewListObjectsV2Paginator(f.provider.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(f.bucket),
+		Prefix: aws.String(oldPrefix),
+	})
+
+	var keys []string
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(f.ctx)
+		if err != nil {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+		}
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.ToString(obj.Key))
+		}
+	}
+
+	for _, key := range keys {
+		if err := f.moveObject(key, newPrefix+strings.TrimPrefix(key, oldPrefix)); err != nil {
+			return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+		}
+	}
+	return nil
+}
+
+// moveObject copia um objeto para a nova chave e remove o original
+func (f *WritableS3FS) moveObject(oldKey, newKey string) error {
+	_, err := f.provider.client.CopyObject(f.ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(f.bucket),
+		Key:        aws.String(newKey),
+		CopySource: aws.String(copySource(f.bucket, oldKey)),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao copiar objeto %s: %w", oldKey, err)
+	}
+
+	_, err = f.provider.client.DeleteObject(f.ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(f.bucket),
+		Key:    aws.String(oldKey),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao remover objeto %s: %w", oldKey, err)
+	}
+	return nil
+}
+
+// copySource monta o valor de CopySource codificado para URL
+func copySource(bucketName, key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return bucketName + "/" + strings.Join(segments, "/")
+}
+
+// S3FileWriter acumula o conteúdo de um arquivo e o envia ao S3 no Close
+type S3FileWriter struct {
+	fsys   *WritableS3FS
+	name   string
+	buf    bytes.Buffer
+	closed bool
+}
+
+// Write acrescenta dados ao arquivo
+func (w *S3FileWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, &fs.PathError{Op: "write", Path: w.name, Err: fs.ErrClosed}
+	}
+	return w.buf.Write(p)
+}
+
+// Close envia o conteúdo acumulado ao S3
+func (w *S3FileWriter) Close() error {
+	if w.closed {
+		return &fs.PathError{Op: "close", Path: w.name, Err: fs.ErrClosed}
+	}
+	w.closed = true
+
+	_, err := w.fsys.provider.client.PutObject(w.fsys.ctx, &s3.PutObjectInput{
+		Bucket: aws.String(w.fsys.bucket),
+		Key:    aws.String(w.fsys.key(w.name)),
+		Body:   bytes.NewReader(w.buf.Bytes()),
+	})
+	if err != nil {
+		return &fs.PathError{Op: "close", Path: w.name, Err: err}
+	}
+	return nil
+}
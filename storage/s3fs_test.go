@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// putTestObject grava um objeto diretamente no cliente em memória
+func putTestObject(t *testing.T, client S3API, bucket, key, content string) {
+	t.Helper()
+	_, err := client.PutObject(context.Background(), &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader([]byte(content)),
+	})
+	if err != nil {
+		t.Fatalf("PutObject(%s): %v", key, err)
+	}
+}
+
+func TestS3FSPassesFSTest(t *testing.T) {
+	client := NewMemoryS3Client()
+	putTestObject(t, client, "bucket", "root/a.txt", "alpha")
+	putTestObject(t, client, "bucket", "root/dir/b.txt", "bravo")
+	putTestObject(t, client, "bucket", "root/dir/sub/c.json", `{"c":true}`)
+	putTestObject(t, client, "bucket", "root/empty/", "")
+	putTestObject(t, client, "bucket", "outside.txt", "fora do prefixo")
+
+	fsys := NewS3FS(NewS3ProviderWithClient(client), "bucket", "root")
+	if err := fstest.TestFS(fsys, "a.txt", "dir/b.txt", "dir/sub/c.json", "empty"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestS3FSNotExist(t *testing.T) {
+	fsys := NewS3FS(NewS3ProviderWithClient(NewMemoryS3Client()), "bucket", "")
+
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Open de arquivo inexistente: esperado fs.ErrNotExist, obtido %v", err)
+	}
+	if _, err := fsys.Open("../escape"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Open de caminho inválido: esperado fs.ErrInvalid, obtido %v", err)
+	}
+}
+
+func TestS3FSSeekAndReadAt(t *testing.T) {
+	client := NewMemoryS3Client()
+	putTestObject(t, client, "bucket", "file.txt", "0123456789")
+	fsys := NewS3FS(NewS3ProviderWithClient(client), "bucket", "")
+
+	f, err := fsys.Open("file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	seeker := f.(io.ReadSeeker)
+	if _, err := seeker.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	rest, err := io.ReadAll(seeker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "456789" {
+		t.Fatalf("leitura após Seek: esperado 456789, obtido %q", rest)
+	}
+
+	buf := make([]byte, 3)
+	n, err := f.(io.ReaderAt).ReadAt(buf, 7)
+	if err != nil || n != 3 || string(buf) != "789" {
+		t.Fatalf("ReadAt: obtido %q, %d, %v", buf[:n], n, err)
+	}
+}
+
+func TestWritableS3FSCreateAndWriteFile(t *testing.T) {
+	client := NewMemoryS3Client()
+	fsys := NewWritableS3FS(NewS3ProviderWithClient(client), "bucket", "data")
+
+	w, err := fsys.Create("notes/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "parte 1, "); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(w, "parte 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// O conteúdo só é enviado no Close
+	if _, err := fsys.Stat("notes/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("arquivo visível antes do Close: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("segundo Close: esperado fs.ErrClosed, obtido %v", err)
+	}
+
+	data, err := fs.ReadFile(fsys, "notes/a.txt")
+	if err != nil || string(data) != "parte 1, parte 2" {
+		t.Fatalf("ReadFile: obtido %q, %v", data, err)
+	}
+
+	if err := fsys.WriteFile("b.txt", []byte("bravo")); err != nil {
+		t.Fatal(err)
+	}
+	output, err := client.GetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("data/b.txt"),
+	})
+	if err != nil {
+		t.Fatalf("WriteFile não gravou sob o prefixo: %v", err)
+	}
+	output.Body.Close()
+
+	if _, err := fsys.Create("."); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("Create(\".\"): esperado fs.ErrInvalid, obtido %v", err)
+	}
+}
+
+func TestWritableS3FSRemove(t *testing.T) {
+	fsys := NewWritableS3FS(NewS3ProviderWithClient(NewMemoryS3Client()), "bucket", "")
+
+	if err := fsys.WriteFile("dir/a.txt", []byte("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove("dir"); err == nil {
+		t.Fatal("Remove de diretório não vazio deveria falhar")
+	}
+	if err := fsys.Remove("dir/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("dir/a.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("arquivo removido ainda existe: %v", err)
+	}
+
+	if err := fsys.Mkdir("empty"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("empty"); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("Mkdir repetido: esperado fs.ErrExist, obtido %v", err)
+	}
+	if err := fsys.Remove("empty"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("empty"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("diretório removido ainda existe: %v", err)
+	}
+	if err := fsys.Remove("missing"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Remove de inexistente: esperado fs.ErrNotExist, obtido %v", err)
+	}
+}
+
+func TestWritableS3FSRename(t *testing.T) {
+	fsys := NewWritableS3FS(NewS3ProviderWithClient(NewMemoryS3Client()), "bucket", "")
+
+	if err := fsys.WriteFile("old.txt", []byte("conteúdo")); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Rename("old.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("old.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("origem ainda existe após Rename: %v", err)
+	}
+	data, err := fs.ReadFile(fsys, "new.txt")
+	if err != nil || string(data) != "conteúdo" {
+		t.Fatalf("destino após Rename: obtido %q, %v", data, err)
+	}
+
+	for _, name := range []string{"src/a.txt", "src/sub/b.txt"} {
+		if err := fsys.WriteFile(name, []byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := fsys.Rename("src", "dst"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("src"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("diretório de origem ainda existe: %v", err)
+	}
+	if err := fstest.TestFS(fsys, "dst/a.txt", "dst/sub/b.txt", "new.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fsys.Rename("missing", "other"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("Rename de inexistente: esperado fs.ErrNotExist, obtido %v", err)
+	}
+}
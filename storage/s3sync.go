@@ -0,0 +1,631 @@
+package storage
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// ErrUnsafeSyncPath indica uma chave que, convertida em caminho local, sairia do diretório sincronizado
+var ErrUnsafeSyncPath = errors.New("caminho fora do diretório local")
+
+// DefaultMultipartPartSize é o tamanho de parte usado pelas ferramentas da AWS
+// (CLI e SDKs) ao enviar objetos em múltiplas partes
+const DefaultMultipartPartSize = 8 * 1024 * 1024
+
+// SyncDirection indica o sentido da sincronização
+type SyncDirection int
+
+const (
+	// SyncUpload envia o diretório local para o prefixo do bucket
+	SyncUpload SyncDirection = iota
+	// SyncDownload baixa o prefixo do bucket para o diretório local
+	SyncDownload
+)
+
+// SyncAction é a operação planejada para um arquivo
+type SyncAction string
+
+const (
+	SyncActionUpload   SyncAction = "upload"
+	SyncActionDownload SyncAction = "download"
+	SyncActionDelete   SyncAction = "delete"
+	SyncActionSkip     SyncAction = "skip"
+)
+
+// SyncOptions configura o comportamento da sincronização
+type SyncOptions struct {
+	// Delete remove do destino os arquivos que não existem na origem
+	Delete bool
+	// DryRun apenas calcula o plano, sem transferir nem remover nada
+	DryRun bool
+	// Concurrency é o número de transferências simultâneas (padrão 8)
+	Concurrency int
+	// PartSize é o tamanho de parte usado no cálculo de ETags multipart.
+	// Se zero, os tamanhos usuais são testados.
+	PartSize int64
+	// Exclude ignora os caminhos relativos para os quais retorna true
+	Exclude func(relPath string) bool
+}
+
+// SyncChange descreve uma operação do plano de sincronização
+type SyncChange struct {
+	Action     SyncAction
+	Path       string // caminho relativo, com separador "/"
+	Key        string // chave completa no bucket
+	Reason     string
+	LocalSize  int64
+	RemoteSize int64
+	LocalTime  time.Time
+	RemoteTime time.Time
+	Err        error // preenchido após a execução em caso de falha
+}
+
+// SyncPlan é o conjunto de operações calculado pela comparação
+type SyncPlan struct {
+	Direction SyncDirection
+	LocalDir  string
+	Bucket    string
+	Prefix    string
+	Changes   []SyncChange
+}
+
+// Count retorna o número de operações de um tipo
+func (p *SyncPlan) Count(action SyncAction) int {
+	count := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed retorna as operações que falharam na execução
+func (p *SyncPlan) Failed() []SyncChange {
+	var failed []SyncChange
+	for _, c := range p.Changes {
+		if c.Err != nil {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+// String formata o plano em linhas legíveis, omitindo os arquivos inalterados
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, c := range p.Changes {
+		if c.Action == SyncActionSkip {
+			continue
+		}
+		fmt.Fprintf(&b, "%-8s %s (%s)\n", c.Action, c.Path, c.Reason)
+	}
+	fmt.Fprintf(&b, "%d upload(s), %d download(s), %d remoção(ões), %d inalterado(s)\n",
+		p.Count(SyncActionUpload), p.Count(SyncActionDownload), p.Count(SyncActionDelete), p.Count(SyncActionSkip))
+	return b.String()
+}
+
+// syncEntry é um arquivo local ou objeto remoto a ser comparado
+type syncEntry struct {
+	size    int64
+	modTime time.Time
+	etag    string
+}
+
+// S3Syncer sincroniza diretórios locais com prefixos de buckets S3
+type S3Syncer struct {
+	provider *S3Provider
+	options  SyncOptions
+}
+
+// NewS3Syncer cria um sincronizador sobre o provedor S3
+func NewS3Syncer(p *S3Provider, options SyncOptions) *S3Syncer {
+	if options.Concurrency <= 0 {
+		options.Concurrency = 8
+	}
+	return &S3Syncer{
+		provider: p,
+		options:  options,
+	}
+}
+
+// Upload envia para o bucket os arquivos locais novos ou alterados
+func (s *S3Syncer) Upload(ctx context.Context, localDir, bucketName, prefix string) (*SyncPlan, error) {
+	return s.Sync(ctx, SyncUpload, localDir, bucketName, prefix)
+}
+
+// Download baixa do bucket os objetos novos ou alterados
+func (s *S3Syncer) Download(ctx context.Context, localDir, bucketName, prefix string) (*SyncPlan, error) {
+	return s.Sync(ctx, SyncDownload, localDir, bucketName, prefix)
+}
+
+// Sync calcula o plano e, se não for DryRun, executa as operações em paralelo.
+// O plano retornado indica em Err cada operação que falhou.
+func (s *S3Syncer) Sync(ctx context.Context, direction SyncDirection, localDir, bucketName, prefix string) (*SyncPlan, error) {
+	plan, err := s.Plan(ctx, direction, localDir, bucketName, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if s.options.DryRun {
+		return plan, nil
+	}
+
+	if err := s.execute(ctx, plan); err != nil {
+		return plan, err
+	}
+	return plan, nil
+}
+
+// Plan compara o diretório local e o prefixo do bucket e retorna as operações necessárias
+func (s *S3Syncer) Plan(ctx context.Context, direction SyncDirection, localDir, bucketName, prefix string) (*SyncPlan, error) {
+	prefix = strings.Trim(prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	local, err := s.listLocal(localDir)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := s.listRemote(ctx, localDir, bucketName, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &SyncPlan{
+		Direction: direction,
+		LocalDir:  localDir,
+		Bucket:    bucketName,
+		Prefix:    prefix,
+	}
+
+	source, target := local, remote
+	copyAction := SyncActionUpload
+	if direction == SyncDownload {
+		source, target = remote, local
+		copyAction = SyncActionDownload
+	}
+
+	for rel := range source {
+		change := SyncChange{Path: rel, Key: prefix + rel}
+		fillSyncSizes(&change, local[rel], remote[rel])
+
+		if _, exists := target[rel]; !exists {
+			change.Action = copyAction
+			change.Reason = "novo"
+		} else {
+			reason, err := s.compare(localDir, rel, direction, local[rel], remote[rel])
+			if err != nil {
+				return nil, err
+			}
+			change.Action = SyncActionSkip
+			change.Reason = "inalterado"
+			if reason != "" {
+				change.Action = copyAction
+				change.Reason = reason
+			}
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	if s.options.Delete {
+		for rel := range target {
+			if _, ok := source[rel]; ok {
+				continue
+			}
+			change := SyncChange{Path: rel, Key: prefix + rel, Action: SyncActionDelete, Reason: "ausente na origem"}
+			fillSyncSizes(&change, local[rel], remote[rel])
+			plan.Changes = append(plan.Changes, change)
+		}
+	}
+
+	sort.Slice(plan.Changes, func(i, j int) bool {
+		return plan.Changes[i].Path < plan.Changes[j].Path
+	})
+
+	log.Printf("Plano de sincronização para s3://%s/%s: %d operação(ões)", bucketName, prefix, len(plan.Changes)-plan.Count(SyncActionSkip))
+	return plan, nil
+}
+
+// compare retorna o motivo da cópia ou "" se os arquivos forem equivalentes.
+// A ordem é tamanho, data de modificação e, por fim, ETag.
+func (s *S3Syncer) compare(localDir, rel string, direction SyncDirection, local, remote syncEntry) (string, error) {
+	if local.size != remote.size {
+		return "tamanho diferente", nil
+	}
+
+	// Downloads ajustam a data local para a do objeto, então datas iguais indicam cópia já feita
+	if local.modTime.Truncate(time.Second).Equal(remote.modTime.Truncate(time.Second)) {
+		return "", nil
+	}
+
+	matched, known, err := s.matchETag(filepath.Join(localDir, filepath.FromSlash(rel)), local.size, remote.etag)
+	if err != nil {
+		return "", err
+	}
+	if known {
+		if matched {
+			return "", nil
+		}
+		return "conteúdo diferente", nil
+	}
+
+	// ETag não comparável (ex.: criptografia SSE-KMS): usar a origem mais recente
+	newer := local.modTime.After(remote.modTime)
+	if direction == SyncDownload {
+		newer = remote.modTime.After(local.modTime)
+	}
+	if newer {
+		return "origem mais recente", nil
+	}
+	return "", nil
+}
+
+// matchETag compara o ETag remoto com o calculado para o arquivo local.
+// known é false quando o formato do ETag não permite a comparação.
+func (s *S3Syncer) matchETag(path string, size int64, etag string) (matched bool, known bool, err error) {
+	etag = strings.Trim(etag, "\"")
+	hash, partsStr, multipart := strings.Cut(etag, "-")
+	if len(hash) != 32 {
+		return false, false, nil
+	}
+
+	if !multipart {
+		computed, err := computeFileETag(path, 0)
+		if err != nil {
+			return false, false, err
+		}
+		return computed == etag, true, nil
+	}
+
+	parts, err := strconv.ParseInt(partsStr, 10, 64)
+	if err != nil || parts <= 0 {
+		return false, false, nil
+	}
+
+	for _, partSize := range s.candidatePartSizes(size, parts) {
+		computed, err := computeFileETag(path, partSize)
+		if err != nil {
+			return false, false, err
+		}
+		if computed == etag {
+			return true, true, nil
+		}
+	}
+
+	// Nenhum tamanho de parte conhecido reproduz o número de partes
+	return false, false, nil
+}
+
+// candidatePartSizes retorna os tamanhos de parte compatíveis com o número de partes do ETag
+func (s *S3Syncer) candidatePartSizes(size, parts int64) []int64 {
+	const mib = 1024 * 1024
+	candidates := []int64{s.options.PartSize, DefaultMultipartPartSize, 5 * mib, 16 * mib, 15 * mib, 64 * mib, 100 * mib}
+	// Tamanho mínimo arredondado para MiB, usado por ferramentas que ajustam a parte ao arquivo
+	candidates = append(candidates, (size/parts+mib-1)/mib*mib)
+
+	var result []int64
+	seen := make(map[int64]bool)
+	for _, c := range candidates {
+		if c <= 0 || seen[c] {
+			continue
+		}
+		seen[c] = true
+		if (size+c-1)/c == parts {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// ComputeETag calcula o ETag que o S3 atribuiria ao conteúdo. Com partSize
+// zero, calcula o ETag de um envio simples (MD5); caso contrário, o ETag de
+// um envio multipart, no formato "md5-dos-md5s-N", se houver mais de uma parte.
+func ComputeETag(r io.Reader, partSize int64) (string, error) {
+	if partSize <= 0 {
+		h := md5.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	var sums []byte
+	parts := 0
+	for {
+		h := md5.New()
+		n, err := io.CopyN(h, r, partSize)
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		if n > 0 || parts == 0 {
+			sums = append(sums, h.Sum(nil)...)
+			parts++
+		}
+		if n < partSize {
+			break
+		}
+	}
+
+	if parts == 1 {
+		return hex.EncodeToString(sums), nil
+	}
+	total := md5.Sum(sums)
+	return fmt.Sprintf("%s-%d", hex.EncodeToString(total[:]), parts), nil
+}
+
+// computeFileETag calcula o ETag de um arquivo local
+func computeFileETag(path string, partSize int64) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("erro ao abrir arquivo %s: %w", path, err)
+	}
+	defer file.Close()
+
+	etag, err := ComputeETag(file, partSize)
+	if err != nil {
+		return "", fmt.Errorf("erro ao calcular ETag de %s: %w", path, err)
+	}
+	return etag, nil
+}
+
+// listLocal lista os arquivos regulares do diretório local
+func (s *S3Syncer) listLocal(localDir string) (map[string]syncEntry, error) {
+	entries := make(map[string]syncEntry)
+
+	err := filepath.WalkDir(localDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Um diretório local inexistente equivale a um diretório vazio
+			if p == localDir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if s.options.Exclude != nil && s.options.Exclude(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries[rel] = syncEntry{size: info.Size(), modTime: info.ModTime()}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("erro ao listar diretório local: %w", err)
+	}
+
+	return entries, nil
+}
+
+// listRemote lista os objetos sob o prefixo, ignorando marcadores de diretório
+// e chaves que não correspondem a um caminho dentro de localDir
+func (s *S3Syncer) listRemote(ctx context.Context, localDir, bucketName, prefix string) (map[string]syncEntry, error) {
+	entries := make(map[string]syncEntry)
+
+	paginator := s3.NewListObjectsV2Paginator(s.provider.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao listar objetos do S3: %w", err)
+		}
+
+		for _, obj := range page.Contents {
+			key := aws.ToString(obj.Key)
+			rel := strings.TrimPrefix(key, prefix)
+			if rel == "" || strings.HasSuffix(rel, "/") {
+				continue
+			}
+			if s.options.Exclude != nil && s.options.Exclude(rel) {
+				continue
+			}
+			// Chaves como "prefixo/../../x" escapariam do diretório local
+			if _, err := localSyncPath(localDir, rel); err != nil {
+				log.Printf("Ignorando objeto s3://%s/%s: %v", bucketName, key, err)
+				continue
+			}
+			entries[rel] = syncEntry{
+				size:    aws.ToInt64(obj.Size),
+				modTime: aws.ToTime(obj.LastModified),
+				etag:    aws.ToString(obj.ETag),
+			}
+		}
+	}
+
+	return entries, nil
+}
+
+// localSyncPath converte um caminho relativo do plano em caminho sob localDir.
+// Caminhos absolutos, com segmentos ".." ou que após a limpeza não ficam dentro
+// de localDir são rejeitados com ErrUnsafeSyncPath.
+func localSyncPath(localDir, rel string) (string, error) {
+	native := filepath.FromSlash(rel)
+	if rel == "" || strings.HasPrefix(rel, "/") || filepath.IsAbs(native) || filepath.VolumeName(native) != "" {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeSyncPath, rel)
+	}
+	isSeparator := func(r rune) bool { return r == '/' || r == filepath.Separator }
+	for _, segment := range strings.FieldsFunc(rel, isSeparator) {
+		if segment == ".." {
+			return "", fmt.Errorf("%w: %q", ErrUnsafeSyncPath, rel)
+		}
+	}
+
+	base := filepath.Clean(localDir)
+	joined := filepath.Join(base, native)
+	inside, err := filepath.Rel(base, joined)
+	if err != nil || inside == "." || inside == ".." || strings.HasPrefix(inside, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q", ErrUnsafeSyncPath, rel)
+	}
+	return joined, nil
+}
+
+// fillSyncSizes preenche no plano os tamanhos e datas de cada lado
+func fillSyncSizes(change *SyncChange, local, remote syncEntry) {
+	change.LocalSize, change.LocalTime = local.size, local.modTime
+	change.RemoteSize, change.RemoteTime = remote.size, remote.modTime
+}
+
+// execute executa as operações do plano com o número de workers configurado
+func (s *S3Syncer) execute(ctx context.Context, plan *SyncPlan) error {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.options.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				change := &plan.Changes[idx]
+				change.Err = s.apply(ctx, plan, change)
+				if change.Err != nil {
+					log.Printf("Erro ao sincronizar %s: %v", change.Path, change.Err)
+				}
+			}
+		}()
+	}
+
+	for i := range plan.Changes {
+		if plan.Changes[i].Action == SyncActionSkip {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if failed := plan.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d operação(ões) de sincronização falharam", len(failed))
+	}
+	return nil
+}
+
+// apply executa uma única operação do plano
+func (s *S3Syncer) apply(ctx context.Context, plan *SyncPlan, change *SyncChange) error {
+	localPath, err := localSyncPath(plan.LocalDir, change.Path)
+	if err != nil {
+		return err
+	}
+
+	switch change.Action {
+	case SyncActionUpload:
+		return s.upload(ctx, plan.Bucket, change.Key, localPath)
+	case SyncActionDownload:
+		return s.download(ctx, plan.Bucket, change.Key, localPath)
+	case SyncActionDelete:
+		if plan.Direction == SyncUpload {
+			_, err := s.provider.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(plan.Bucket),
+				Key:    aws.String(change.Key),
+			})
+			return err
+		}
+		return os.Remove(localPath)
+	}
+	return nil
+}
+
+// upload envia um arquivo local para o bucket
+func (s *S3Syncer) upload(ctx context.Context, bucketName, key, localPath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("erro ao abrir arquivo: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("erro ao obter informações do arquivo: %w", err)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(bucketName),
+		Key:           aws.String(key),
+		Body:          file,
+		ContentLength: aws.Int64(info.Size()),
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(localPath)); contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if _, err := s.provider.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("erro ao enviar objeto para o S3: %w", err)
+	}
+	return nil
+}
+
+// download baixa um objeto para um arquivo temporário e o renomeia,
+// ajustando a data de modificação para a do objeto
+func (s *S3Syncer) download(ctx context.Context, bucketName, key, localPath string) error {
+	output, err := s.provider.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("erro ao obter objeto do S3: %w", err)
+	}
+	defer output.Body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório local: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(localPath), ".sync-*")
+	if err != nil {
+		return fmt.Errorf("erro ao criar arquivo temporário: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, output.Body); err != nil {
+		tmp.Close()
+		return fmt.Errorf("erro ao gravar arquivo local: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("erro ao gravar arquivo local: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), localPath); err != nil {
+		return fmt.Errorf("erro ao mover arquivo local: %w", err)
+	}
+
+	if output.LastModified != nil {
+		if err := os.Chtimes(localPath, *output.LastModified, *output.LastModified); err != nil {
+			return fmt.Errorf("erro ao ajustar data do arquivo local: %w", err)
+		}
+	}
+	return nil
+}